@@ -0,0 +1,108 @@
+// Copyright (c) 2015, Ben Morgan. All rights reserved.
+// Use of this source code is governed by an MIT license
+// that can be found in the LICENSE file.
+
+package lex
+
+import (
+	"fmt"
+	"sync/atomic"
+	"unsafe"
+)
+
+// tokenOverhead is the memory a token takes besides its strings.
+const tokenOverhead = int(unsafe.Sizeof(Token{}))
+
+// A BudgetError reports that a lexer exceeded its memory budget, see
+// WithMemoryBudget. It is the Data of the TypeError token, and wrapped
+// by the *Error that Lexer.Err returns.
+type BudgetError struct {
+	Budget int // budget in bytes
+	Used   int // bytes that would have been used
+}
+
+func (e *BudgetError) Error() string {
+	return fmt.Sprintf("memory budget of %d bytes exceeded: %d bytes buffered", e.Budget, e.Used)
+}
+
+// budget tracks the memory of tokens that have been emitted, but not
+// received by the client yet. It is shared with sub-lexers.
+type budget struct {
+	limit int
+	used  int64 // accessed atomically, as the client releases memory
+}
+
+// WithMemoryBudget limits the memory that the lexer buffers to n bytes,
+// which protects services that lex untrusted input from inputs that
+// make the lexer emit huge amounts of tokens faster than they are
+// consumed, or huge coalesced tokens, see WithBufferSize and WithCoalesce.
+// The memory of a token is its size plus the size of its value and raw
+// text; the memory is released when the client receives the token.
+// If the budget would be exceeded, lexing stops with a TypeError token
+// whose Data is a *BudgetError:
+//
+//	if err := l.Err(); err != nil {
+//	    var b *lex.BudgetError
+//	    if errors.As(err, &b) {
+//	        // reject the upload
+//	    }
+//	}
+//
+// Tokens delivered with RunSink are not buffered and not accounted for.
+func WithMemoryBudget(n int) Option {
+	return func(l *Lexer) { l.budget = &budget{limit: n} }
+}
+
+// tokenMemory returns the memory t takes.
+func tokenMemory(t Token) int {
+	n := tokenOverhead + len(t.Value)
+	if t.Raw != t.Value {
+		n += len(t.Raw)
+	}
+	return n
+}
+
+// reserve accounts for the memory of t, which is about to be buffered.
+// If that exceeds the budget, it stops lexing with an error positioned
+// at t and returns false.
+func (l *Lexer) reserve(t Token) bool {
+	n := tokenMemory(t)
+	used := int(atomic.AddInt64(&l.budget.used, int64(n)))
+	if used <= l.budget.limit {
+		return true
+	}
+	atomic.AddInt64(&l.budget.used, -int64(n))
+	l.exceedBudget(t, used)
+	return false
+}
+
+// checkBudget stops lexing with an error positioned at t if t, which is
+// held back for coalescing, does not fit in the budget, and returns false.
+func (l *Lexer) checkBudget(t Token) bool {
+	used := int(atomic.LoadInt64(&l.budget.used)) + tokenMemory(t)
+	if used <= l.budget.limit {
+		return true
+	}
+	l.exceedBudget(t, used)
+	return false
+}
+
+// exceedBudget stops lexing with a *BudgetError for used bytes.
+func (l *Lexer) exceedBudget(t Token, used int) {
+	l.holding = false
+	l.stop()
+	l.ended = true
+	err := &BudgetError{Budget: l.budget.limit, Used: used}
+	e := Token{Type: TypeError, Pos: t.Pos, End: t.Pos, Value: err.Error(), Data: err, pb: t.pb}
+	if l.err == nil {
+		l.err = l.newError(e)
+	}
+	l.tokens <- e
+}
+
+// release frees the memory of t, which was received by the client.
+func (l *Lexer) release(t Token) {
+	if t.Type != TypeError {
+		atomic.AddInt64(&l.budget.used, -int64(tokenMemory(t)))
+	}
+}
@@ -0,0 +1,33 @@
+// Copyright (c) 2015, Ben Morgan. All rights reserved.
+// Use of this source code is governed by an MIT license
+// that can be found in the LICENSE file.
+
+package lex_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/goulash/lex"
+)
+
+func TestMemoryBudgetCollect(t *testing.T) {
+	in := strings.Repeat("ab ", 500)
+	l := lex.Lex("f", in, lexWords, lex.WithMemoryBudget(2000))
+	toks := lex.Collect(l)
+	if err := l.Err(); err != nil || len(toks) != 1001 {
+		t.Errorf("got %d tokens and error %v, want 1001 tokens", len(toks), err)
+	}
+}
+
+func TestMemoryBudgetExceeded(t *testing.T) {
+	in := strings.Repeat("ab ", 500)
+	l := lex.New("f", in, lex.WithMemoryBudget(2000), lex.WithBufferSize(1000))
+	l.Run(lexWords)
+	lex.Collect(l)
+	var b *lex.BudgetError
+	if err := l.Err(); !errors.As(err, &b) || b.Budget != 2000 {
+		t.Errorf("got error %v, want budget error", err)
+	}
+}
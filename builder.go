@@ -0,0 +1,81 @@
+// Copyright (c) 2015, Ben Morgan. All rights reserved.
+// Use of this source code is governed by an MIT license
+// that can be found in the LICENSE file.
+
+package lex
+
+// A Builder builds a state function from matchers, for lexers that are
+// simple enough not to need state functions of their own:
+//
+//	b := lex.NewBuilder()
+//	b.Skip(lex.MatchRun(lex.Space))
+//	b.Rule(TypeNumber, lex.MatchRun("0123456789"))
+//	b.Rule(TypePlus, lex.MatchString("+"))
+//	l := lex.Lex(name, input, b.StateFn())
+type Builder struct {
+	rules []builderRule
+	skips []Matcher
+}
+
+type builderRule struct {
+	t Type
+	m Matcher
+}
+
+// NewBuilder returns a new Builder without any rules.
+func NewBuilder() *Builder {
+	return new(Builder)
+}
+
+// Rule adds a rule emitting a token of type t for input matched by m.
+func (b *Builder) Rule(t Type, m Matcher) *Builder {
+	b.rules = append(b.rules, builderRule{t, m})
+	return b
+}
+
+// Skip adds a matcher for input that is ignored between tokens.
+func (b *Builder) Skip(m Matcher) *Builder {
+	b.skips = append(b.skips, m)
+	return b
+}
+
+// StateFn returns a state function lexing input with the rules of b.
+//
+// At every position, the rule with the longest match wins; of several
+// matches of the same length, the rule added first wins. If no rule
+// matches, an error is emitted. Rules added to b later are not used by
+// the state function.
+func (b *Builder) StateFn() StateFn {
+	rules := append([]builderRule(nil), b.rules...)
+	skips := append([]Matcher(nil), b.skips...)
+	return func(l *Lexer) StateFn {
+		for {
+			for skipped := true; skipped; {
+				skipped = false
+				for _, m := range skips {
+					if l.AcceptSeq(m) && l.pos > l.base {
+						l.Ignore()
+						skipped = true
+					}
+				}
+			}
+			r := l.Peek()
+			if r < 0 {
+				l.Emit(TypeEOF)
+				return nil
+			}
+			start, best, end := l.pos, -1, l.pos
+			for i, rule := range rules {
+				if l.AcceptSeq(rule.m) && l.pos > end {
+					best, end = i, l.pos
+				}
+				l.pos = start
+			}
+			if best < 0 {
+				return l.Errorf("unexpected %q", r)
+			}
+			l.pos, l.width = end, 0
+			l.Emit(rules[best].t)
+		}
+	}
+}
@@ -0,0 +1,97 @@
+// Copyright (c) 2015, Ben Morgan. All rights reserved.
+// Use of this source code is governed by an MIT license
+// that can be found in the LICENSE file.
+
+package lex
+
+import (
+	"bytes"
+	"unsafe"
+)
+
+// NewBytes is like New, but lexes input without copying it, such as
+// a memory-mapped file. The values of the tokens refer to input
+// directly, so input must not be modified while the lexer or any of
+// its tokens are in use.
+//
+// Options that rewrite the input, such as WithNormalizeNewlines and
+// WithStripBOM, still make a copy if there is something to rewrite.
+func NewBytes(name string, input []byte, opts ...Option) *Lexer {
+	return New(name, unsafe.String(unsafe.SliceData(input), len(input)), opts...)
+}
+
+// NextByte returns the next byte in the input, without decoding UTF-8.
+// If there is no more input left to read, EOF is returned, or
+// ErrMoreInput with WithIncremental.
+//
+// This is useful for binary-ish formats such as network protocols.
+// Backup can be used after NextByte as after Next.
+func (l *Lexer) NextByte() int {
+	if l.atEnd() {
+		l.width = 0
+		return int(l.endRune())
+	}
+	c := l.input[l.pos]
+	l.width = 1
+	l.pos++
+	return int(c)
+}
+
+// PeekByte returns but does not consume the next byte in the input.
+func (l *Lexer) PeekByte() int {
+	c := l.NextByte()
+	l.Backup()
+	return c
+}
+
+// AcceptBytes consumes the next byte if it is from the valid set.
+func (l *Lexer) AcceptBytes(valid []byte) bool {
+	c := l.NextByte()
+	if c >= 0 && bytes.IndexByte(valid, byte(c)) >= 0 {
+		return true
+	}
+	l.Backup()
+	return false
+}
+
+// AcceptBytesRun consumes a run of bytes from the valid set.
+// The number of bytes advanced is returned.
+func (l *Lexer) AcceptBytesRun(valid []byte) int {
+	var n int
+	for l.AcceptBytes(valid) {
+		n++
+	}
+	return n
+}
+
+// AcceptButBytesRun consumes bytes as long as they are not in the
+// invalid set. The number of bytes advanced is returned.
+func (l *Lexer) AcceptButBytesRun(invalid []byte) int {
+	var n int
+	for {
+		c := l.NextByte()
+		if c < 0 || bytes.IndexByte(invalid, byte(c)) >= 0 {
+			l.Backup()
+			return n
+		}
+		n++
+	}
+}
+
+// Take consumes exactly the next n bytes of input and returns them.
+// If fewer than n bytes are left, nothing is consumed and false is
+// returned. This is useful for length-prefixed and fixed-width fields.
+// The bytes must be in the current input: Take does not continue in
+// an input suspended by PushInput. With WithIncremental, input that
+// was appended is taken into account, but Take does not wait for more.
+func (l *Lexer) Take(n int) (string, bool) {
+	for len(l.input)-l.pos < n && len(l.stack) == 0 && (l.fill() || l.takeAppended()) {
+	}
+	if n < 0 || len(l.input)-l.pos < n {
+		return "", false
+	}
+	s := l.input[l.pos : l.pos+n]
+	l.pos += n
+	l.width = 0
+	return s, true
+}
@@ -0,0 +1,120 @@
+// Copyright (c) 2015, Ben Morgan. All rights reserved.
+// Use of this source code is governed by an MIT license
+// that can be found in the LICENSE file.
+
+package lex_test
+
+import (
+	"strings"
+	"testing"
+	"testing/iotest"
+	"unsafe"
+
+	"github.com/goulash/lex"
+)
+
+func TestNextByte(t *testing.T) {
+	var got []int
+	l := lex.Lex("a", "é12;x", func(l *lex.Lexer) lex.StateFn {
+		got = append(got, l.NextByte(), l.PeekByte(), l.NextByte())
+		l.Emit(typeOther)
+		got = append(got, l.AcceptBytesRun([]byte("0123456789")))
+		got = append(got, boolInt(l.AcceptBytes([]byte(";"))), boolInt(l.AcceptBytes([]byte(";"))))
+		l.Emit(typeWord)
+		got = append(got, l.AcceptButBytesRun([]byte(";")), l.NextByte())
+		l.Backup()
+		l.Emit(typeWord)
+		return nil
+	})
+	toks := lex.Collect(l)
+	want := []int{0xc3, 0xa9, 0xa9, 2, 1, 0, 1, lex.EOF}
+	if len(got) != len(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	for i := range got {
+		if got[i] != want[i] {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+	}
+	if v := values(toks); len(v) != 4 || v[0] != "é" || v[1] != "12;" || v[2] != "x" {
+		t.Errorf("got tokens %q", v)
+	}
+}
+
+func boolInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// lexFields lexes fields prefixed with their length as a digit.
+func lexFields(l *lex.Lexer) lex.StateFn {
+	for {
+		r := l.Next()
+		if r < '0' || r > '9' {
+			return nil
+		}
+		l.Ignore()
+		if _, ok := l.Take(int(r - '0')); !ok {
+			return l.Errorf("short field")
+		}
+		l.Emit(typeWord)
+	}
+}
+
+func TestTake(t *testing.T) {
+	const input = "3abc01 2é4xy"
+	toks := lex.Collect(lex.Lex("a", input, lexFields))
+	if got := strings.Join(values(toks), "|"); got != "abc|| |é|short field" {
+		t.Errorf("got %q", got)
+	}
+
+	s := lex.NewStream("a", iotest.OneByteReader(strings.NewReader(input)))
+	go s.Run(lexFields)
+	if got := strings.Join(values(lex.Collect(s)), "|"); got != "abc|| |é|short field" {
+		t.Errorf("got %q from a stream", got)
+	}
+
+	l := lex.New("a", "3a", lex.WithIncremental())
+	l.Append("bc2d")
+	l.Append("e")
+	l.CloseInput()
+	go l.Run(lexFields)
+	if got := strings.Join(values(lex.Collect(l)), "|"); got != "abc|de|" {
+		t.Errorf("got %q with appended input", got)
+	}
+
+	l = lex.Lex("a", "3x", func(l *lex.Lexer) lex.StateFn {
+		l.PushInput("b", "2a")
+		return lexFields
+	})
+	if got := strings.Join(values(lex.Collect(l)), "|"); got != "short field" {
+		t.Errorf("got %q, want Take to stay in the pushed input", got)
+	}
+}
+
+func TestNewBytes(t *testing.T) {
+	input := []byte("ab cd")
+	l := lex.NewBytes("a", input)
+	go l.Run(lexWords)
+	toks := lex.Collect(l)
+	if got := strings.Join(values(toks), "|"); got != "ab| |cd|" {
+		t.Fatalf("got %q", got)
+	}
+	if p := unsafe.StringData(toks[2].Value); p != &input[3] {
+		t.Errorf("token value is a copy, want it to refer to the input")
+	}
+
+	l = lex.NewBytes("a", []byte("ab\r\ncd"), lex.WithNormalizeNewlines())
+	go l.Run(lexWords)
+	if got := strings.Join(values(lex.Collect(l)), "|"); got != "ab|\n|cd|" {
+		t.Errorf("got %q with normalized newlines", got)
+	}
+
+	l = lex.NewBytes("a", nil)
+	go l.Run(lexWords)
+	if toks := lex.Collect(l); len(toks) != 1 || toks[0].Type != lex.TypeEOF {
+		t.Errorf("got %v from nil input, want EOF", toks)
+	}
+}
@@ -0,0 +1,51 @@
+// Copyright (c) 2015, Ben Morgan. All rights reserved.
+// Use of this source code is governed by an MIT license
+// that can be found in the LICENSE file.
+
+package lex
+
+import "sync"
+
+var categories = struct {
+	sync.RWMutex
+	m map[Type]map[Type]bool
+}{m: make(map[Type]map[Type]bool)}
+
+// RegisterCategory registers the types that belong to the category cat,
+// which is itself a Type that is not emitted, so that parsers can test
+// whether a token belongs to the category with Type.Is:
+//
+//	const (
+//	    TypeKeyword = (1+lex.TypeEOF)+iota // category
+//	    TypeIf
+//	    TypeFor
+//	    ...
+//	)
+//
+//	func init() {
+//	    lex.RegisterCategory(TypeKeyword, TypeIf, TypeFor)
+//	}
+//
+// Registering further members of the same category adds to it.
+func RegisterCategory(cat Type, members ...Type) {
+	categories.Lock()
+	defer categories.Unlock()
+	m := categories.m[cat]
+	if m == nil {
+		m = make(map[Type]bool, len(members))
+		categories.m[cat] = m
+	}
+	for _, t := range members {
+		m[t] = true
+	}
+}
+
+// Is reports whether t is cat or a member of the category cat.
+func (t Type) Is(cat Type) bool {
+	if t == cat {
+		return true
+	}
+	categories.RLock()
+	defer categories.RUnlock()
+	return categories.m[cat][t]
+}
@@ -0,0 +1,48 @@
+// Copyright (c) 2015, Ben Morgan. All rights reserved.
+// Use of this source code is governed by an MIT license
+// that can be found in the LICENSE file.
+
+package lex_test
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/goulash/lex"
+)
+
+func TestCategories(t *testing.T) {
+	const (
+		typeKeyword lex.Type = 100 + iota
+		typeIf
+		typeFor
+		typeIdent
+	)
+	lex.RegisterCategory(typeKeyword, typeIf)
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		lex.RegisterCategory(typeKeyword, typeFor)
+	}()
+	typeIf.Is(typeKeyword)
+	wg.Wait()
+
+	tests := []struct {
+		t, cat lex.Type
+		want   bool
+	}{
+		{typeIf, typeKeyword, true},
+		{typeFor, typeKeyword, true},
+		{typeKeyword, typeKeyword, true},
+		{typeIdent, typeIdent, true},
+		{typeIdent, typeKeyword, false},
+		{typeKeyword, typeIf, false},
+		{typeIf, typeIdent, false},
+	}
+	for _, tt := range tests {
+		if got := tt.t.Is(tt.cat); got != tt.want {
+			t.Errorf("%d.Is(%d) = %t, want %t", tt.t, tt.cat, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,49 @@
+// Copyright (c) 2015, Ben Morgan. All rights reserved.
+// Use of this source code is governed by an MIT license
+// that can be found in the LICENSE file.
+
+package lex
+
+// Clone returns an independent copy of the lexer at the same position,
+// with the same options and its own tokens channel. The input is shared,
+// as it is never modified. This lets a parser lex ahead speculatively on
+// the copy and discard it, without disturbing the original:
+//
+//	c := l.Clone()
+//	go c.Run(lexExpr)
+//	ok := tryParse(lex.NewReader(c))
+//	c.Drain()
+//
+// Clone must be called in the lexing goroutine of l, or when l is not
+// running. A clone of a lexer created with NewStream or WithIncremental
+// only sees the input read so far, which for NewStream includes the rest
+// of a rune split between reads, so that the clone lexes the same runes
+// as l. A token held back by WithCoalesce
+// is still delivered by l, not by the clone, and the clone delivers its
+// tokens on its channel even if l is run with RunSink.
+func (l *Lexer) Clone() *Lexer {
+	// Read the rest of a rune split between reads, which the clone
+	// could not read by itself.
+	for l.tail != "" && l.fill() {
+	}
+	c := *l
+	c.tokens = make(chan Token, l.bufSize)
+	c.done = make(chan struct{})
+	c.closed = false
+	c.stream, c.tail = nil, ""
+	c.incr = nil
+	c.sink, c.refSink = nil, nil
+	c.held, c.holding = Token{}, false
+	c.last = &lastRead{pb: l.pb}
+	c.shifts = append([]posShift(nil), l.shifts...)
+	c.stack = append([]inputFrame(nil), l.stack...)
+	c.modes = append([]StateFn(nil), l.modes...)
+	c.lines, c.scanned = nil, 0
+	if l.budget != nil {
+		c.budget = &budget{limit: l.budget.limit}
+	}
+	if l.interned != nil {
+		c.interned = make(map[string]string)
+	}
+	return &c
+}
@@ -0,0 +1,78 @@
+// Copyright (c) 2015, Ben Morgan. All rights reserved.
+// Use of this source code is governed by an MIT license
+// that can be found in the LICENSE file.
+
+package lex_test
+
+import (
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/goulash/lex"
+)
+
+func TestCloneIndependent(t *testing.T) {
+	var clone *lex.Lexer
+	l := lex.New("f", "ab cd ef", lex.WithCoalesce(typeWord, typeSpace))
+	var got []string
+	l.RunSink(func(l *lex.Lexer) lex.StateFn {
+		l.Inc(2)
+		l.Emit(typeWord)
+		clone = l.Clone()
+		return lexWords
+	}, func(tok lex.Token) bool {
+		got = append(got, tok.Value)
+		return true
+	})
+	if strings.Join(got, "|") != "ab| |cd| |ef|" {
+		t.Errorf("original got %q", got)
+	}
+	go clone.Run(lexWords)
+	if got := values(lex.Collect(clone)); strings.Join(got, "|") != " |cd| |ef|" {
+		t.Errorf("clone got %q", got)
+	}
+}
+
+func TestCloneIncremental(t *testing.T) {
+	l := lex.New("f", "ab", lex.WithIncremental())
+	c := l.Clone()
+	l.Append(" cd")
+	go c.Run(lexWords)
+	go c.Drain()
+	select {
+	case <-c.Done():
+	case <-time.After(time.Second):
+		t.Fatal("clone waits for input appended to the original")
+	}
+}
+
+func TestCloneStream(t *testing.T) {
+	// The first read ends in the middle of é.
+	r := io.MultiReader(strings.NewReader("ab\xc3"), strings.NewReader("\xa9cd"))
+	var clone *lex.Lexer
+	l := lex.NewStream("f", r)
+	var rest []rune
+	l.RunSink(func(l *lex.Lexer) lex.StateFn {
+		l.Next()
+		l.Next()
+		l.Ignore()
+		clone = l.Clone()
+		for r := l.Next(); r >= 0; r = l.Next() {
+			rest = append(rest, r)
+		}
+		return nil
+	}, func(lex.Token) bool { return true })
+	var cloneRest []rune
+	go clone.Run(func(l *lex.Lexer) lex.StateFn {
+		for r := l.Next(); r >= 0; r = l.Next() {
+			cloneRest = append(cloneRest, r)
+		}
+		return nil
+	})
+	clone.Drain()
+	if string(rest) != "écd" || string(cloneRest) != "écd" {
+		t.Errorf("original read %q, clone read %q; want écd", string(rest), string(cloneRest))
+	}
+}
@@ -0,0 +1,59 @@
+// Copyright (c) 2015, Ben Morgan. All rights reserved.
+// Use of this source code is governed by an MIT license
+// that can be found in the LICENSE file.
+
+// Command lexgen generates a lexer from a token spec, see package
+// github.com/goulash/lex/lexgen for the format. It is meant to be
+// used with go generate:
+//
+//	//go:generate go run github.com/goulash/lex/cmd/lexgen -o calc_lex.go calc.lex
+//
+// Without -o, the generated code is written to standard output.
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/goulash/lex/lexgen"
+)
+
+func main() {
+	out := flag.String("o", "", "write generated code to `file`")
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: lexgen [-o file] spec\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	if err := run(flag.Arg(0), *out); err != nil {
+		fmt.Fprintf(os.Stderr, "lexgen: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(name, out string) error {
+	src, err := os.ReadFile(name)
+	if err != nil {
+		return err
+	}
+	spec, err := lexgen.Parse(name, string(src))
+	if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	if err := lexgen.Generate(&buf, spec); err != nil {
+		return err
+	}
+	if out == "" {
+		_, err = io.Copy(os.Stdout, &buf)
+		return err
+	}
+	return os.WriteFile(out, buf.Bytes(), 0644)
+}
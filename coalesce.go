@@ -0,0 +1,78 @@
+// Copyright (c) 2015, Ben Morgan. All rights reserved.
+// Use of this source code is governed by an MIT license
+// that can be found in the LICENSE file.
+
+package lex
+
+// WithCoalesce makes the lexer merge consecutive tokens of the same type
+// into one token, for the given types. Tokens are only merged if they are
+// adjacent in the input. This can cut down the number of tokens for
+// text-heavy input, such as the text runs in a template language.
+//
+// Since a token can only be delivered once it is known that the next
+// token does not continue it, the client receives it one token later.
+func WithCoalesce(types ...Type) Option {
+	return func(l *Lexer) {
+		if l.coalesce == nil {
+			l.coalesce = make(map[Type]bool)
+		}
+		for _, t := range types {
+			l.coalesce[t] = true
+		}
+	}
+}
+
+// coalesceToken merges t into the held token if possible, and otherwise
+// delivers the held token and holds t back if it can be coalesced.
+func (l *Lexer) coalesceToken(t Token) {
+	if l.holding {
+		h := &l.held
+		if h.Type == t.Type && h.End == t.Pos && h.pb == t.pb {
+			if s, ok := l.joinedText(h, &t); ok {
+				h.Value, h.Raw = s, s
+			} else {
+				h.Value += t.Value
+				h.Raw += t.Raw
+			}
+			h.End = t.End
+			if l.budget != nil && l.sink == nil {
+				l.checkBudget(*h)
+			}
+			return
+		}
+		l.flushCoalesced()
+	}
+	if l.coalesce[t.Type] {
+		l.held, l.holding = t, true
+		return
+	}
+	l.send(t)
+}
+
+// joinedText returns the input spanned by h and t, if their values are
+// the input at their positions, so that merging them does not copy.
+// Emit hooks may have changed the positions or values of the tokens.
+// Comparing substrings of the input with themselves is cheap, since
+// strings with the same data are equal without comparing bytes.
+func (l *Lexer) joinedText(h, t *Token) (string, bool) {
+	b := h.pb
+	if b == nil || l.copyValues || h.Value != h.Raw || t.Value != t.Raw {
+		return "", false
+	}
+	if h.Pos < b.off || t.End-b.off > len(b.input) || t.End-h.Pos != len(h.Raw)+len(t.Raw) {
+		return "", false
+	}
+	s := b.input[h.Pos-b.off : t.End-b.off]
+	if s[:len(h.Raw)] != h.Raw || s[len(h.Raw):] != t.Raw {
+		return "", false
+	}
+	return s, true
+}
+
+// flushCoalesced delivers the held token, if any.
+func (l *Lexer) flushCoalesced() {
+	if l.holding {
+		l.holding = false
+		l.send(l.held)
+	}
+}
@@ -0,0 +1,54 @@
+// Copyright (c) 2015, Ben Morgan. All rights reserved.
+// Use of this source code is governed by an MIT license
+// that can be found in the LICENSE file.
+
+package lex_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/goulash/lex"
+)
+
+func TestWithCoalesce(t *testing.T) {
+	runes := func(l *lex.Lexer) lex.StateFn {
+		for r := l.Next(); r >= 0; r = l.Next() {
+			switch {
+			case r == ' ':
+				l.Emit(typeSpace)
+			case r == '!':
+				l.EmitMapped(typeOther, func(string) string { return "?" })
+			default:
+				l.Emit(typeWord)
+			}
+		}
+		return nil
+	}
+	shift := lex.WithEmitHook(func(t *lex.Token) { t.Pos, t.End = t.Pos+100, t.End+100 })
+	upper := lex.WithEmitHook(func(t *lex.Token) { t.Value = strings.ToUpper(t.Value) })
+	tests := []struct {
+		opts []lex.Option
+		want string
+	}{
+		{nil, "abc|  |d|!!|e|"},
+		{[]lex.Option{lex.WithCopyValues()}, "abc|  |d|!!|e|"},
+		{[]lex.Option{shift}, "abc|  |d|!!|e|"},
+		{[]lex.Option{upper}, "ABC|  |D|!!|E|"},
+	}
+	for i, tt := range tests {
+		opts := append([]lex.Option{lex.WithCoalesce(typeWord, typeSpace, typeOther)}, tt.opts...)
+		toks := lex.Collect(lex.Lex("a", "abc  d!!e", runes, opts...))
+		var raws []string
+		for _, tok := range toks {
+			raws = append(raws, tok.Raw)
+		}
+		if got := strings.Join(raws, "|"); got != "abc|  |d|!!|e|" {
+			t.Errorf("%d: got raw %q", i, got)
+		}
+		want := strings.ReplaceAll(tt.want, "!", "?")
+		if got := strings.Join(values(toks), "|"); got != want {
+			t.Errorf("%d: got %q, want %q", i, got, want)
+		}
+	}
+}
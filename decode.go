@@ -0,0 +1,66 @@
+// Copyright (c) 2015, Ben Morgan. All rights reserved.
+// Use of this source code is governed by an MIT license
+// that can be found in the LICENSE file.
+
+package lex
+
+import (
+	"fmt"
+	"sync"
+)
+
+var decoders = struct {
+	sync.RWMutex
+	m map[Type]func(string) (interface{}, error)
+}{m: make(map[Type]func(string) (interface{}, error))}
+
+// RegisterDecoder registers fn as the decoder of the values of tokens of
+// type t, which is used by Token.Decode. This keeps the decoding of
+// numbers, strings, and the like in one place instead of in every parser:
+//
+//	func init() {
+//	    lex.RegisterDecoder(TypeNumber, func(s string) (interface{}, error) {
+//	        return strconv.ParseFloat(s, 64)
+//	    })
+//	    lex.RegisterDecoder(TypeString, func(s string) (interface{}, error) {
+//	        return strconv.Unquote(s)
+//	    })
+//	}
+//
+// Registering nil removes the decoder of t.
+func RegisterDecoder(t Type, fn func(string) (interface{}, error)) {
+	decoders.Lock()
+	defer decoders.Unlock()
+	if fn == nil {
+		delete(decoders.m, t)
+		return
+	}
+	decoders.m[t] = fn
+}
+
+// Decode returns the value of t decoded by the decoder registered for its
+// type with RegisterDecoder. If there is none, or the decoder fails, an
+// *Error positioned at the token is returned, which wraps the error of
+// the decoder. Columns are counted in runes, regardless of the column
+// options of the lexer.
+func (t Token) Decode() (interface{}, error) {
+	decoders.RLock()
+	fn, ok := decoders.m[t.Type]
+	decoders.RUnlock()
+	if !ok {
+		return nil, t.decodeError(fmt.Sprintf("no decoder for %s", t.Type), nil)
+	}
+	v, err := fn(t.Value)
+	if err != nil {
+		return nil, t.decodeError(fmt.Sprintf("cannot decode %s: %v", t, err), err)
+	}
+	return v, nil
+}
+
+// decodeError returns an *Error with msg for t, wrapping err.
+func (t Token) decodeError(msg string, err error) *Error {
+	var l Lexer
+	e := l.newError(t)
+	e.Msg, e.Err = msg, err
+	return e
+}
@@ -0,0 +1,59 @@
+// Copyright (c) 2015, Ben Morgan. All rights reserved.
+// Use of this source code is governed by an MIT license
+// that can be found in the LICENSE file.
+
+package lex_test
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+
+	"github.com/goulash/lex"
+)
+
+func TestDecode(t *testing.T) {
+	const typeNumber lex.Type = 220
+	lex.RegisterTypeName(typeNumber, "Number")
+	lex.RegisterDecoder(typeNumber, func(s string) (interface{}, error) {
+		return strconv.Atoi(s)
+	})
+	toks := lex.Collect(lex.Lex("f", "ab\n12 x9", func(l *lex.Lexer) lex.StateFn {
+		for {
+			switch r := l.Peek(); {
+			case r < 0:
+				return nil
+			case '0' <= r && r <= '9' || r == 'x':
+				l.AcceptRun("0123456789x")
+				l.Emit(typeNumber)
+			case r == ' ' || r == '\n':
+				l.Next()
+				l.Ignore()
+			default:
+				l.AcceptRangeRun('a', 'z')
+				l.Emit(typeWord)
+			}
+		}
+	}))
+	if v, err := toks[1].Decode(); v != 12 || err != nil {
+		t.Errorf("Decode(%v) = %v, %v, want 12", toks[1], v, err)
+	}
+	var e *lex.Error
+	var num *strconv.NumError
+	_, err := toks[2].Decode()
+	if !errors.As(err, &e) || !errors.As(err, &num) || e.Line != 2 || e.Col != 4 {
+		t.Errorf("Decode(%v) error = %v, want a wrapped *strconv.NumError at 2:4", toks[2], err)
+	}
+	_, err = toks[0].Decode()
+	if !errors.As(err, &e) || err.Error() != "f:1:1: no decoder for Type(2)" || e.Err != nil {
+		t.Errorf("Decode(%v) error = %v, want no decoder", toks[0], err)
+	}
+
+	lex.RegisterDecoder(typeNumber, nil)
+	if _, err := toks[1].Decode(); err == nil || err.Error() != "f:2:1: no decoder for Number" {
+		t.Errorf("Decode after removing the decoder: error = %v", err)
+	}
+	if _, err := (lex.Token{Type: typeNumber, Value: "1"}).Decode(); err == nil {
+		t.Error("Decode of a handcrafted token: no error")
+	}
+}
@@ -0,0 +1,560 @@
+// Copyright (c) 2015, Ben Morgan. All rights reserved.
+// Use of this source code is governed by an MIT license
+// that can be found in the LICENSE file.
+
+package lex
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// maxDFAStates limits the number of states CompilePattern creates.
+const maxDFAStates = 10000
+
+// maxRepeat limits the counts of a repetition such as a{2,5}.
+const maxRepeat = 1000
+
+// A DFA is a deterministic finite automaton compiled from a pattern by
+// CompilePattern. Matching it takes linear time and does not allocate.
+// A DFA is immutable and may be shared between lexers.
+type DFA struct {
+	pattern string
+	states  []dfaState
+}
+
+type dfaState struct {
+	accept bool
+	edges  []dfaEdge // sorted and disjoint
+}
+
+type dfaEdge struct {
+	lo, hi rune
+	next   int
+}
+
+// CompilePattern compiles a pattern in a subset of the regular expression
+// syntax of package regexp into a DFA:
+//
+//	x         the rune x, unless it is one of \.+*?()|[]{}^$
+//	\x        the rune x, if it is punctuation
+//	\n \r \t  newline, carriage return, tab
+//	\d \w \s  ASCII digit, word rune, and white space, and \D \W \S
+//	.         any rune except newline
+//	[a-z_]    a rune in the class, [^...] a rune not in the class
+//	xy  x|y   concatenation and alternation
+//	(x)       grouping
+//	x* x+ x?  zero or more, one or more, zero or one x
+//	x{n,m}    n to m x, also x{n} and x{n,}
+//
+// Anchors, captures, flags, and non-greedy repetition are not supported;
+// a match always starts at the current position and is the longest one,
+// see Lexer.AcceptDFA. CompilePattern panics if the pattern is invalid,
+// like regexp.MustCompile, so it is meant to initialize variables:
+//
+//	var number = lex.CompilePattern(`[0-9]+(\.[0-9]+)?([eE][+-]?[0-9]+)?`)
+func CompilePattern(pattern string) *DFA {
+	p := &patternParser{src: pattern}
+	n := p.parseAlt()
+	if p.err == nil && p.pos < len(p.src) {
+		p.errorf("unexpected )")
+	}
+	if p.err != nil {
+		panic(fmt.Sprintf("lex: CompilePattern(%q): %v", pattern, p.err))
+	}
+	var a nfa
+	start, end := a.build(n)
+	d := &DFA{pattern: pattern}
+	if err := d.compile(&a, start, end); err != nil {
+		panic(fmt.Sprintf("lex: CompilePattern(%q): %v", pattern, err))
+	}
+	return d
+}
+
+// String returns the pattern d was compiled from.
+func (d *DFA) String() string { return d.pattern }
+
+// step returns the state following state s on r, or -1.
+func (d *DFA) step(s int, r rune) int {
+	edges := d.states[s].edges
+	i := sort.Search(len(edges), func(i int) bool { return edges[i].hi >= r })
+	if i < len(edges) && edges[i].lo <= r {
+		return edges[i].next
+	}
+	return -1
+}
+
+// AcceptDFA consumes the longest non-empty match of d at the current
+// position, and reports whether there was one. If there was none, the
+// position is not changed.
+func (l *Lexer) AcceptDFA(d *DFA) bool {
+	l.autoSkip()
+	f, stack := l.frame(), l.stack // see AcceptSeq
+	end, endWidth := -1, 0
+	for s := 0; ; {
+		r := l.Next()
+		if r < 0 {
+			break
+		}
+		if s = d.step(s, r); s < 0 {
+			break
+		}
+		if d.states[s].accept {
+			end, endWidth = l.pos, l.width
+		}
+	}
+	if end < 0 {
+		l.stack = stack
+		l.resume(f)
+		return false
+	}
+	l.pos, l.width = end, endWidth
+	return true
+}
+
+// MatchDFA returns a Matcher that consumes the longest non-empty match
+// of d, see Lexer.AcceptDFA.
+func MatchDFA(d *DFA) Matcher {
+	return func(l *Lexer) bool { return l.AcceptDFA(d) }
+}
+
+// A patternNode is a node of a parsed pattern.
+type patternNode struct {
+	op       patternOp
+	ranges   []runeRange // for opRunes
+	subs     []*patternNode
+	min, max int // for opRepeat, max is -1 if unbounded
+}
+
+type patternOp int
+
+const (
+	opEmpty patternOp = iota
+	opRunes
+	opConcat
+	opAlt
+	opRepeat
+)
+
+type runeRange struct{ lo, hi rune }
+
+var (
+	digitRanges = []runeRange{{'0', '9'}}
+	wordRanges  = []runeRange{{'0', '9'}, {'A', 'Z'}, {'_', '_'}, {'a', 'z'}}
+	spaceRanges = []runeRange{{'\t', '\n'}, {'\f', '\r'}, {' ', ' '}}
+	dotRanges   = []runeRange{{0, '\n' - 1}, {'\n' + 1, utf8.MaxRune}}
+)
+
+// patternParser parses a pattern by recursive descent.
+type patternParser struct {
+	src string
+	pos int
+	err error
+}
+
+func (p *patternParser) errorf(format string, args ...interface{}) {
+	if p.err == nil {
+		p.err = fmt.Errorf("%s at offset %d", fmt.Sprintf(format, args...), p.pos)
+	}
+}
+
+func (p *patternParser) peek() rune {
+	r, _ := p.decode()
+	return r
+}
+
+func (p *patternParser) next() rune {
+	r, w := p.decode()
+	p.pos += w
+	return r
+}
+
+// decode returns the rune at the current position and its width.
+func (p *patternParser) decode() (rune, int) {
+	if p.pos >= len(p.src) {
+		return EOF, 0
+	}
+	return utf8.DecodeRuneInString(p.src[p.pos:])
+}
+
+// parseAlt parses alternatives separated by |.
+func (p *patternParser) parseAlt() *patternNode {
+	n := &patternNode{op: opAlt, subs: []*patternNode{p.parseSeq()}}
+	for p.err == nil && p.peek() == '|' {
+		p.next()
+		n.subs = append(n.subs, p.parseSeq())
+	}
+	if len(n.subs) == 1 {
+		return n.subs[0]
+	}
+	return n
+}
+
+// parseSeq parses a concatenation of repeated atoms.
+func (p *patternParser) parseSeq() *patternNode {
+	n := &patternNode{op: opConcat}
+	for p.err == nil {
+		switch p.peek() {
+		case EOF, '|', ')':
+			return n
+		}
+		atom := p.parseAtom()
+		for p.err == nil {
+			min, max, ok := p.parseRepeat()
+			if !ok {
+				break
+			}
+			atom = &patternNode{op: opRepeat, subs: []*patternNode{atom}, min: min, max: max}
+		}
+		n.subs = append(n.subs, atom)
+	}
+	return n
+}
+
+// parseRepeat parses a repetition operator, if there is one.
+func (p *patternParser) parseRepeat() (min, max int, ok bool) {
+	switch p.peek() {
+	case '*':
+		p.next()
+		return 0, -1, true
+	case '+':
+		p.next()
+		return 1, -1, true
+	case '?':
+		p.next()
+		return 0, 1, true
+	case '{':
+		start := p.pos
+		p.next()
+		min, max, ok = p.parseCounts()
+		if !ok {
+			p.pos = start
+			p.errorf("invalid repetition")
+		}
+		return min, max, ok
+	}
+	return 0, 0, false
+}
+
+// parseCounts parses the counts of a repetition following {.
+func (p *patternParser) parseCounts() (min, max int, ok bool) {
+	end := strings.IndexByte(p.src[p.pos:], '}')
+	if end < 0 {
+		return 0, 0, false
+	}
+	counts := p.src[p.pos : p.pos+end]
+	p.pos += end + 1
+	lo, hi, comma := strings.Cut(counts, ",")
+	min, err := strconv.Atoi(lo)
+	if err != nil || min > maxRepeat {
+		return 0, 0, false
+	}
+	switch {
+	case !comma:
+		return min, min, true
+	case hi == "":
+		return min, -1, true
+	}
+	max, err = strconv.Atoi(hi)
+	if err != nil || max < min || max > maxRepeat {
+		return 0, 0, false
+	}
+	return min, max, true
+}
+
+// parseAtom parses a rune, class, or group.
+func (p *patternParser) parseAtom() *patternNode {
+	switch r := p.next(); r {
+	case '(':
+		n := p.parseAlt()
+		if p.next() != ')' {
+			p.errorf("missing )")
+		}
+		return n
+	case '[':
+		return &patternNode{op: opRunes, ranges: p.parseClass()}
+	case '.':
+		return &patternNode{op: opRunes, ranges: dotRanges}
+	case '\\':
+		return &patternNode{op: opRunes, ranges: p.parseEscape()}
+	case '*', '+', '?', '{':
+		p.errorf("missing argument to repetition operator %q", r)
+	case '^', '$':
+		p.errorf("anchors are not supported")
+	case ']', '}':
+		p.errorf("unexpected %q", r)
+	default:
+		return &patternNode{op: opRunes, ranges: []runeRange{{r, r}}}
+	}
+	return &patternNode{op: opEmpty}
+}
+
+// parseEscape parses an escape sequence following a backslash.
+func (p *patternParser) parseEscape() []runeRange {
+	switch r := p.next(); {
+	case r == EOF:
+		p.errorf("trailing backslash")
+	case r == 'n':
+		return []runeRange{{'\n', '\n'}}
+	case r == 'r':
+		return []runeRange{{'\r', '\r'}}
+	case r == 't':
+		return []runeRange{{'\t', '\t'}}
+	case r == 'd':
+		return digitRanges
+	case r == 'w':
+		return wordRanges
+	case r == 's':
+		return spaceRanges
+	case r == 'D':
+		return negateRanges(digitRanges)
+	case r == 'W':
+		return negateRanges(wordRanges)
+	case r == 'S':
+		return negateRanges(spaceRanges)
+	case r < utf8.RuneSelf && !IsAlphaNumeric(r):
+		return []runeRange{{r, r}}
+	default:
+		p.errorf("invalid escape \\%c", r)
+	}
+	return nil
+}
+
+// parseClass parses a character class following [.
+func (p *patternParser) parseClass() []runeRange {
+	negate := p.peek() == '^'
+	if negate {
+		p.next()
+	}
+	var ranges []runeRange
+	for first := true; p.err == nil; first = false {
+		r := p.next()
+		switch {
+		case r == EOF:
+			p.errorf("missing ]")
+			return nil
+		case r == ']' && !first:
+			ranges = normalizeRanges(ranges)
+			if negate {
+				ranges = negateRanges(ranges)
+			}
+			return ranges
+		case r == '\\':
+			esc := p.parseEscape()
+			if len(esc) != 1 || esc[0].lo != esc[0].hi || p.peek() != '-' {
+				ranges = append(ranges, esc...)
+				continue
+			}
+			r = esc[0].lo
+		}
+		lo := r
+		if p.peek() == '-' && !strings.HasPrefix(p.src[p.pos:], "-]") {
+			p.next()
+			hi := p.next()
+			if hi == '\\' {
+				esc := p.parseEscape()
+				if len(esc) != 1 || esc[0].lo != esc[0].hi {
+					p.errorf("invalid range end")
+					return nil
+				}
+				hi = esc[0].lo
+			}
+			if hi == EOF || hi < lo {
+				p.errorf("invalid range %c-%c", lo, hi)
+				return nil
+			}
+			ranges = append(ranges, runeRange{lo, hi})
+			continue
+		}
+		ranges = append(ranges, runeRange{lo, lo})
+	}
+	return nil
+}
+
+// normalizeRanges sorts ranges and merges overlapping and adjacent ones.
+func normalizeRanges(ranges []runeRange) []runeRange {
+	sort.Slice(ranges, func(i, j int) bool { return ranges[i].lo < ranges[j].lo })
+	var out []runeRange
+	for _, r := range ranges {
+		if n := len(out); n > 0 && r.lo <= out[n-1].hi+1 {
+			out[n-1].hi = max(out[n-1].hi, r.hi)
+			continue
+		}
+		out = append(out, r)
+	}
+	return out
+}
+
+// negateRanges returns the complement of the normalized ranges.
+func negateRanges(ranges []runeRange) []runeRange {
+	var out []runeRange
+	next := rune(0)
+	for _, r := range ranges {
+		if r.lo > next {
+			out = append(out, runeRange{next, r.lo - 1})
+		}
+		next = r.hi + 1
+	}
+	if next <= utf8.MaxRune {
+		out = append(out, runeRange{next, utf8.MaxRune})
+	}
+	return out
+}
+
+// An nfa is a nondeterministic finite automaton with epsilon moves.
+// Each state either has epsilon moves, or moves to next on a rune in
+// ranges.
+type nfa []nfaState
+
+type nfaState struct {
+	eps    []int
+	ranges []runeRange
+	next   int
+}
+
+func (a *nfa) add() int {
+	*a = append(*a, nfaState{next: -1})
+	return len(*a) - 1
+}
+
+func (a *nfa) eps(from, to int) {
+	(*a)[from].eps = append((*a)[from].eps, to)
+}
+
+// build adds the states for n and returns its start and end state.
+func (a *nfa) build(n *patternNode) (start, end int) {
+	switch n.op {
+	case opRunes:
+		start, end = a.add(), a.add()
+		(*a)[start].ranges, (*a)[start].next = n.ranges, end
+	case opConcat:
+		start = a.add()
+		end = start
+		for _, sub := range n.subs {
+			s, e := a.build(sub)
+			a.eps(end, s)
+			end = e
+		}
+	case opAlt:
+		start, end = a.add(), a.add()
+		for _, sub := range n.subs {
+			s, e := a.build(sub)
+			a.eps(start, s)
+			a.eps(e, end)
+		}
+	case opRepeat:
+		start = a.add()
+		end = start
+		for i := 0; i < n.min; i++ {
+			s, e := a.build(n.subs[0])
+			a.eps(end, s)
+			end = e
+		}
+		if n.max < 0 {
+			s, e := a.build(n.subs[0])
+			a.eps(end, s)
+			a.eps(e, s)
+			last := a.add()
+			a.eps(end, last)
+			a.eps(e, last)
+			return start, last
+		}
+		last := a.add()
+		for i := n.min; i < n.max; i++ {
+			s, e := a.build(n.subs[0])
+			a.eps(end, s)
+			a.eps(end, last)
+			end = e
+		}
+		a.eps(end, last)
+		end = last
+	default:
+		start = a.add()
+		end = start
+	}
+	return start, end
+}
+
+// closure returns the sorted set of states reachable from set by
+// epsilon moves.
+func (a nfa) closure(set []int) []int {
+	seen := make(map[int]bool)
+	stack := append([]int(nil), set...)
+	var out []int
+	for len(stack) > 0 {
+		s := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if seen[s] {
+			continue
+		}
+		seen[s] = true
+		out = append(out, s)
+		stack = append(stack, a[s].eps...)
+	}
+	sort.Ints(out)
+	return out
+}
+
+// compile builds the states of d from a by subset construction.
+func (d *DFA) compile(a *nfa, start, end int) error {
+	ids := make(map[string]int)
+	var sets [][]int
+	state := func(set []int) int {
+		key := fmt.Sprint(set)
+		if id, ok := ids[key]; ok {
+			return id
+		}
+		id := len(sets)
+		ids[key] = id
+		sets = append(sets, set)
+		accept := false
+		for _, s := range set {
+			accept = accept || s == end
+		}
+		d.states = append(d.states, dfaState{accept: accept})
+		return id
+	}
+	state(a.closure([]int{start}))
+	for i := 0; i < len(sets); i++ {
+		if len(sets) > maxDFAStates {
+			return fmt.Errorf("pattern needs more than %d states", maxDFAStates)
+		}
+		var bounds []rune
+		for _, s := range sets[i] {
+			for _, r := range (*a)[s].ranges {
+				bounds = append(bounds, r.lo, r.hi+1)
+			}
+		}
+		sort.Slice(bounds, func(i, j int) bool { return bounds[i] < bounds[j] })
+		var edges []dfaEdge
+		for j := 0; j+1 < len(bounds); j++ {
+			lo, hi := bounds[j], bounds[j+1]-1
+			if lo > hi {
+				continue
+			}
+			var targets []int
+			for _, s := range sets[i] {
+				for _, r := range (*a)[s].ranges {
+					if r.lo <= lo && hi <= r.hi {
+						targets = append(targets, (*a)[s].next)
+						break
+					}
+				}
+			}
+			if len(targets) == 0 {
+				continue
+			}
+			next := state(a.closure(targets))
+			if n := len(edges); n > 0 && edges[n-1].next == next && edges[n-1].hi+1 == lo {
+				edges[n-1].hi = hi
+				continue
+			}
+			edges = append(edges, dfaEdge{lo, hi, next})
+		}
+		d.states[i].edges = edges
+	}
+	return nil
+}
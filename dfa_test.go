@@ -0,0 +1,128 @@
+// Copyright (c) 2015, Ben Morgan. All rights reserved.
+// Use of this source code is governed by an MIT license
+// that can be found in the LICENSE file.
+
+package lex_test
+
+import (
+	"math/rand"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/goulash/lex"
+)
+
+// acceptDFA returns the length of the match of d at the start of input,
+// or -1 if AcceptDFA does not match.
+func acceptDFA(d *lex.DFA, input string, opts ...lex.Option) int {
+	n := -1
+	lex.Collect(lex.Lex("f", input, func(l *lex.Lexer) lex.StateFn {
+		if l.AcceptDFA(d) {
+			n = l.Pos()
+		}
+		return nil
+	}, opts...))
+	return n
+}
+
+func TestAcceptDFA(t *testing.T) {
+	tests := []struct {
+		pattern, input string
+		want           int
+	}{
+		{`[0-9]+(\.[0-9]+)?([eE][+-]?[0-9]+)?`, "3.14e-2x", 7},
+		{`[0-9]+(\.[0-9]+)?`, "3.x", 1},
+		{`a*`, "b", -1},
+		{`a*`, "aab", 2},
+		{`if|ifdef|\w+`, "ifdef(", 5},
+		{`.+`, "ab\ncd", 2},
+		{`[^\n]*\n`, "é\n", 3},
+		{`\s\S\d\D\w\W`, "\tx1a_-", 6},
+		{`x{2,3}`, "xxxx", 3},
+		{`x{2}`, "x", -1},
+		{`x{2,}`, "xxxxx", 5},
+		{`[]a]+`, "a]]b", 3},
+		{`[a\-]+`, "-a-b", 3},
+		{`é|ü`, "ü", 2},
+	}
+	for _, tt := range tests {
+		if got := acceptDFA(lex.CompilePattern(tt.pattern), tt.input); got != tt.want {
+			t.Errorf("%s on %q: got %d, want %d", tt.pattern, tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestCompilePatternInvalid(t *testing.T) {
+	for _, pattern := range []string{"(a", "a)", "[a", "*a", "a{1", "a{3,2}", "a{1001}", "^a", `\q`, `a\`, "[z-a]", "(a{1000}){1000}"} {
+		func() {
+			defer func() {
+				if msg, _ := recover().(string); !strings.HasPrefix(msg, "lex: CompilePattern(") {
+					t.Errorf("%s: got panic %q", pattern, msg)
+				}
+			}()
+			lex.CompilePattern(pattern)
+		}()
+	}
+}
+
+// TestAcceptDFARegexp compares AcceptDFA with the longest match of
+// package regexp for random patterns.
+func TestAcceptDFARegexp(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	atoms := []string{"a", "b", "ab", ".", "[a-c]", "[^a]", `\d`, `\w`, `\s`, `\D`, `\.`, "é", "[é-ü]", `\n`, "(a|b)", "()"}
+	var gen func(depth int) string
+	gen = func(depth int) string {
+		if depth > 3 {
+			return atoms[rnd.Intn(len(atoms))]
+		}
+		switch rnd.Intn(7) {
+		case 0:
+			return gen(depth+1) + gen(depth+1)
+		case 1:
+			return "(" + gen(depth+1) + "|" + gen(depth+1) + ")"
+		case 2:
+			return "(" + gen(depth+1) + ")" + []string{"*", "+", "?", "{2}", "{1,3}", "{0,}"}[rnd.Intn(6)]
+		}
+		return atoms[rnd.Intn(len(atoms))]
+	}
+	alphabet := []rune("ab1.é\n _üc")
+	for i := 0; i < 300; i++ {
+		pattern := gen(0)
+		d := lex.CompilePattern(pattern)
+		re := regexp.MustCompile(`^(?:` + pattern + `)`)
+		re.Longest()
+		for j := 0; j < 20; j++ {
+			input := make([]rune, rnd.Intn(8))
+			for k := range input {
+				input[k] = alphabet[rnd.Intn(len(alphabet))]
+			}
+			want := -1
+			if m := re.FindStringIndex(string(input)); m != nil && m[1] > 0 {
+				want = m[1]
+			}
+			if got := acceptDFA(d, string(input)); got != want {
+				t.Fatalf("%s on %q: got %d, want %d", pattern, string(input), got, want)
+			}
+		}
+	}
+}
+
+func TestAcceptDFAInput(t *testing.T) {
+	word := lex.CompilePattern(`[a-z]+`)
+	l := lex.Lex("f", "  ab1", func(l *lex.Lexer) lex.StateFn {
+		l.SetAutoSkip(" ")
+		l.AcceptDFA(word)
+		l.Emit(typeWord)
+		l.PushInput("g", "")
+		if l.AcceptDFA(word) {
+			l.Emit(typeWord)
+		}
+		l.AcceptRun("1")
+		l.Emit(typeOther)
+		return nil
+	})
+	if got := describeTypes(lex.Collect(l)); got != `2:"ab" 4:"1" 1:""` {
+		t.Errorf("got %s", got)
+	}
+}
@@ -0,0 +1,64 @@
+// Copyright (c) 2015, Ben Morgan. All rights reserved.
+// Use of this source code is governed by an MIT license
+// that can be found in the LICENSE file.
+
+package lex
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// DumpOptions configure DumpTokens.
+type DumpOptions struct {
+	// Input is the input the tokens were read from. If set, positions
+	// are written as line:column, otherwise as byte offsets.
+	Input string
+
+	// Raw makes the raw text of a token be written as well, if it
+	// differs from its value.
+	Raw bool
+
+	// ASCII makes strings be quoted with only ASCII characters, so that
+	// the output does not change with the characters that the Go version
+	// considers printable.
+	ASCII bool
+}
+
+// DumpTokens writes toks to w, one per line, with their position, type
+// name, and quoted value:
+//
+//	1:1	Ident	"foo"
+//	1:5	Assign	"="
+//	1:7	Number	"42"
+//
+// The output is stable, which makes it suitable for golden tests and
+// bug reports. Type names are registered with RegisterTypeName.
+func DumpTokens(w io.Writer, toks []Token, opts DumpOptions) error {
+	var index *LineIndex
+	if opts.Input != "" {
+		index = NewLineIndex(opts.Input)
+	}
+	quote := strconv.Quote
+	if opts.ASCII {
+		quote = strconv.QuoteToASCII
+	}
+	for _, t := range toks {
+		pos := strconv.Itoa(t.Pos)
+		if index != nil {
+			line, col := index.Resolve(t.Pos)
+			pos = fmt.Sprintf("%d:%d", line, col)
+		}
+		var err error
+		if opts.Raw && t.Raw != t.Value {
+			_, err = fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", pos, t.Type, quote(t.Value), quote(t.Raw))
+		} else {
+			_, err = fmt.Fprintf(w, "%s\t%s\t%s\n", pos, t.Type, quote(t.Value))
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
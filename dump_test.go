@@ -0,0 +1,70 @@
+// Copyright (c) 2015, Ben Morgan. All rights reserved.
+// Use of this source code is governed by an MIT license
+// that can be found in the LICENSE file.
+
+package lex_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/goulash/lex"
+)
+
+func TestTypeNames(t *testing.T) {
+	const typeIdent lex.Type = 200
+	lex.RegisterTypeName(typeIdent, "Ident")
+	tests := []struct {
+		got, want string
+	}{
+		{typeIdent.String(), "Ident"},
+		{lex.Type(201).String(), "Type(201)"},
+		{lex.TypeEOF.String(), "EOF"},
+		{lex.Token{Type: typeIdent, Value: "a\tb"}.String(), `Ident "a\tb"`},
+		{lex.Token{Value: "bad"}.String(), `Error "bad"`},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("got %s, want %s", tt.got, tt.want)
+		}
+	}
+}
+
+func TestDumpTokens(t *testing.T) {
+	const typeUpper lex.Type = 202
+	lex.RegisterTypeName(typeUpper, "Upper")
+	const input = "ab\né\n"
+	toks := lex.Collect(lex.Lex("a", input, func(l *lex.Lexer) lex.StateFn {
+		l.AcceptRun("ab")
+		l.EmitMapped(typeUpper, strings.ToUpper)
+		l.Next()
+		l.Ignore()
+		l.Next()
+		l.Emit(typeOther)
+		return nil
+	}))
+	tests := []struct {
+		opts lex.DumpOptions
+		want string
+	}{
+		{lex.DumpOptions{}, "0\tUpper\t\"AB\"\n3\tType(4)\t\"é\"\n6\tEOF\t\"\"\n"},
+		{lex.DumpOptions{Input: input, Raw: true, ASCII: true}, "1:1\tUpper\t\"AB\"\t\"ab\"\n2:1\tType(4)\t\"\\u00e9\"\n3:1\tEOF\t\"\"\n"},
+	}
+	for _, tt := range tests {
+		var b strings.Builder
+		if err := lex.DumpTokens(&b, toks, tt.opts); err != nil {
+			t.Fatal(err)
+		}
+		if b.String() != tt.want {
+			t.Errorf("%+v:\ngot\n%swant\n%s", tt.opts, b.String(), tt.want)
+		}
+	}
+	if err := lex.DumpTokens(failWriter{}, toks, lex.DumpOptions{}); err == nil {
+		t.Error("got no error from a failing writer")
+	}
+}
+
+type failWriter struct{}
+
+func (failWriter) Write(p []byte) (int, error) { return 0, errors.New("fail") }
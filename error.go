@@ -0,0 +1,46 @@
+// Copyright (c) 2015, Ben Morgan. All rights reserved.
+// Use of this source code is governed by an MIT license
+// that can be found in the LICENSE file.
+
+package lex
+
+import "fmt"
+
+// An Error is a lexing error, created from a TypeError token.
+type Error struct {
+	File string
+	Line int
+	Col  int
+	Msg  string
+	Err  error // underlying error, such as a *BudgetError, or nil
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s:%d:%d: %s", e.File, e.Line, e.Col, e.Msg)
+}
+
+// Unwrap returns the underlying error, if any.
+func (e *Error) Unwrap() error { return e.Err }
+
+func (l *Lexer) newError(t Token) *Error {
+	file, line, col := l.position(t)
+	err, _ := t.Data.(error)
+	return &Error{File: file, Line: line, Col: col, Msg: t.Value, Err: err}
+}
+
+// Err returns the first error emitted by the lexer as an *Error,
+// or nil if there was none. It may only be called after the lexer
+// has finished, such as after the tokens have been drained:
+//
+//	toks := lex.Collect(l)
+//	if err := l.Err(); err != nil {
+//	    return err
+//	}
+func (l *Lexer) Err() error { return l.err }
+
+// RunErr is like Run, but returns the first error emitted.
+// As with Run, the tokens must be consumed by another goroutine.
+func (l *Lexer) RunErr(fn StateFn) error {
+	l.Run(fn)
+	return l.Err()
+}
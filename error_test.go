@@ -0,0 +1,40 @@
+// Copyright (c) 2015, Ben Morgan. All rights reserved.
+// Use of this source code is governed by an MIT license
+// that can be found in the LICENSE file.
+
+package lex_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/goulash/lex"
+)
+
+func TestErr(t *testing.T) {
+	sf := func(l *lex.Lexer) lex.StateFn {
+		lexWords(l)
+		l.Errorf("first")
+		return l.Errorf("second")
+	}
+	l := lex.Lex("f", "ab\ncd", sf)
+	l.Drain()
+	var e *lex.Error
+	if err := l.Err(); !errors.As(err, &e) || err.Error() != "f:2:3: first" || e.Unwrap() != nil {
+		t.Errorf("got %v, want the first error at f:2:3", err)
+	}
+
+	l = lex.Lex("f", "ab", lexWords)
+	l.Drain()
+	if err := l.Err(); err != nil {
+		t.Errorf("got %v, want no error", err)
+	}
+
+	l = lex.New("f", "ab cd", lex.WithMemoryBudget(1))
+	go l.Drain()
+	err := l.RunErr(lexWords)
+	var b *lex.BudgetError
+	if !errors.As(err, &e) || !errors.As(err, &b) {
+		t.Errorf("got %#v from RunErr, want an *Error wrapping a *BudgetError", err)
+	}
+}
@@ -0,0 +1,204 @@
+// Copyright (c) 2015, Ben Morgan. All rights reserved.
+// Use of this source code is governed by an MIT license
+// that can be found in the LICENSE file.
+
+package lex
+
+import (
+	"fmt"
+	"html"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// EscapeRules configures the escape sequences recognized by ScanEscape.
+type EscapeRules struct {
+	// Simple maps the rune following a backslash to the rune it
+	// stands for, such as 'n' to '\n'.
+	Simple map[rune]rune
+
+	Hex     bool // \xNN, a byte in hexadecimal
+	Unicode bool // \uNNNN and \UNNNNNNNN, a Unicode code point
+	Octal   bool // \N to \NNN, a byte in octal
+}
+
+// GoEscapes are the escape sequences of Go string literals, except
+// that octal escapes may have fewer than three digits.
+var GoEscapes = EscapeRules{
+	Simple: map[rune]rune{
+		'a': '\a', 'b': '\b', 'f': '\f', 'n': '\n', 'r': '\r',
+		't': '\t', 'v': '\v', '\\': '\\', '\'': '\'', '"': '"',
+	},
+	Hex:     true,
+	Unicode: true,
+	Octal:   true,
+}
+
+// ScanEscape consumes an escape sequence starting with a backslash at
+// the current position and returns the rune it stands for. Hex and octal
+// escapes return the value of a byte, which the caller may need to treat
+// differently from a code point.
+//
+// If the escape sequence is invalid, it is consumed up to the offending
+// rune and an *Error is returned, positioned at the backslash.
+func (l *Lexer) ScanEscape(rules EscapeRules) (rune, error) {
+	if !l.Consume(`\`) {
+		return 0, l.errorAt(l.pos, "expected escape sequence")
+	}
+	start := l.pos - 1 // Consume may have skipped runes, see SetAutoSkip
+	r := l.Next()
+	if v, ok := rules.Simple[r]; ok {
+		return v, nil
+	}
+	switch {
+	case r == 'x' && rules.Hex:
+		return l.escapeDigits(start, 2, 16, 0xff)
+	case r == 'u' && rules.Unicode:
+		return l.escapeDigits(start, 4, 16, utf8.MaxRune)
+	case r == 'U' && rules.Unicode:
+		return l.escapeDigits(start, 8, 16, utf8.MaxRune)
+	case '0' <= r && r <= '7' && rules.Octal:
+		l.Backup()
+		return l.escapeDigits(start, -3, 8, 0xff)
+	case r < 0 || IsEndline(r):
+		if r >= 0 {
+			l.Backup()
+		}
+		return 0, l.errorAt(start, "unterminated escape sequence")
+	}
+	return 0, l.errorAt(start, "unknown escape sequence \\%c", r)
+}
+
+// ScanPercentEscape consumes a percent-encoded byte such as %2F at the
+// current position, as used in URLs and form data, and returns the byte.
+//
+// If the escape is invalid, it is consumed up to the offending rune and
+// an *Error is returned, positioned at the percent sign.
+func (l *Lexer) ScanPercentEscape() (byte, error) {
+	if !l.Consume("%") {
+		return 0, l.errorAt(l.pos, "expected percent escape")
+	}
+	start := l.pos - 1 // see ScanEscape
+	var b byte
+	for i := 0; i < 2; i++ {
+		r := l.Next()
+		d := digitVal(r)
+		if d == 16 {
+			if r >= 0 {
+				l.Backup()
+			}
+			return 0, l.errorAt(start, "invalid percent escape %s", l.input[start:l.pos])
+		}
+		b = b<<4 | byte(d)
+	}
+	return b, nil
+}
+
+// ScanHTMLEntity consumes an HTML character reference at the current
+// position, such as &amp;, &#39;, or &#x1F600;, and returns the text it
+// stands for, which may be more than one rune. The terminating semicolon
+// is required, and named references must be defined by HTML5.
+//
+// If the reference is invalid, it is consumed up to the offending rune
+// and an *Error is returned, positioned at the ampersand.
+func (l *Lexer) ScanHTMLEntity() (string, error) {
+	if !l.Consume("&") {
+		return "", l.errorAt(l.pos, "expected character reference")
+	}
+	start := l.pos - 1 // see ScanEscape
+	if l.Consume("#") {
+		base, digits := 10, "0123456789"
+		if l.Accept("xX") {
+			base, digits = 16, "0123456789abcdefABCDEF"
+		}
+		from := l.pos
+		if l.AcceptRun(digits) == 0 {
+			return "", l.errorAt(start, "missing digits in character reference")
+		}
+		num := l.input[from:l.pos]
+		if !l.Consume(";") {
+			return "", l.errorAt(start, "missing ; in character reference")
+		}
+		v, err := strconv.ParseUint(num, base, 32)
+		if err != nil || v == 0 || !utf8.ValidRune(rune(v)) {
+			return "", l.errorAt(start, "character reference %s is an invalid code point", l.input[start:l.pos])
+		}
+		return string(rune(v)), nil
+	}
+	if l.AcceptFuncRun(isASCIIAlphaNumeric) == 0 {
+		return "", l.errorAt(start, "expected character reference")
+	}
+	if !l.Consume(";") {
+		return "", l.errorAt(start, "missing ; in character reference")
+	}
+	// A reference that is not defined is left as it is, or decoded up
+	// to a legacy reference it starts with, such as &amp in &ampx;,
+	// leaving the rest including the semicolon.
+	ref := l.input[start:l.pos]
+	s := html.UnescapeString(ref)
+	if s == ref || strings.HasSuffix(s, ";") && ref != "&semi;" {
+		return "", l.errorAt(start, "unknown character reference %s", ref)
+	}
+	return s, nil
+}
+
+// isASCIIAlphaNumeric reports whether r is an ASCII letter or digit.
+func isASCIIAlphaNumeric(r rune) bool {
+	return 'a' <= r && r <= 'z' || 'A' <= r && r <= 'Z' || '0' <= r && r <= '9'
+}
+
+// escapeDigits consumes n digits in base and returns their value,
+// which must not exceed max. If n is negative, up to -n digits are
+// consumed, but at least one.
+func (l *Lexer) escapeDigits(start, n, base int, max rune) (rune, error) {
+	exact := n > 0
+	if !exact {
+		n = -n
+	}
+	var v rune
+	for i := 0; i < n; i++ {
+		r := l.Next()
+		d := digitVal(r)
+		if d >= base {
+			if r >= 0 {
+				l.Backup()
+			}
+			if !exact && i > 0 {
+				break
+			}
+			if r < 0 || IsEndline(r) {
+				return 0, l.errorAt(start, "unterminated escape sequence")
+			}
+			return 0, l.errorAt(start, "invalid character %q in escape sequence", r)
+		}
+		v = v*rune(base) + rune(d)
+	}
+	if v > max {
+		return 0, l.errorAt(start, "escape sequence %s is out of range", l.input[start:l.pos])
+	}
+	if max == utf8.MaxRune && !utf8.ValidRune(v) {
+		return 0, l.errorAt(start, "escape sequence %s is an invalid code point", l.input[start:l.pos])
+	}
+	return v, nil
+}
+
+// errorAt returns an *Error for the input from start to the current
+// position.
+func (l *Lexer) errorAt(start int, format string, args ...interface{}) error {
+	return l.newError(Token{Type: TypeError, Pos: start, End: l.pos, Value: fmt.Sprintf(format, args...), pb: l.pb})
+}
+
+// digitVal returns the value of r as a hexadecimal digit, or 16 if it
+// is none.
+func digitVal(r rune) int {
+	switch {
+	case '0' <= r && r <= '9':
+		return int(r - '0')
+	case 'a' <= r && r <= 'f':
+		return int(r - 'a' + 10)
+	case 'A' <= r && r <= 'F':
+		return int(r - 'A' + 10)
+	}
+	return 16
+}
@@ -0,0 +1,168 @@
+// Copyright (c) 2015, Ben Morgan. All rights reserved.
+// Use of this source code is governed by an MIT license
+// that can be found in the LICENSE file.
+
+package lex_test
+
+import (
+	"testing"
+
+	"github.com/goulash/lex"
+)
+
+// scanEscapes returns the runes of input with the escape sequences
+// replaced by ScanEscape, and the first error it returned.
+func scanEscapes(input string, rules lex.EscapeRules, skip string) ([]rune, string) {
+	var runes []rune
+	var msg string
+	l := lex.Lex("f", input, func(l *lex.Lexer) lex.StateFn {
+		l.SetAutoSkip(skip)
+		for {
+			if l.Peek() != '\\' && (skip == "" || l.Peek() != ' ') {
+				r := l.Next()
+				if r < 0 {
+					return nil
+				}
+				runes = append(runes, r)
+				continue
+			}
+			r, err := l.ScanEscape(rules)
+			if err != nil {
+				msg = err.Error()
+				return nil
+			}
+			runes = append(runes, r)
+		}
+	})
+	l.Drain()
+	return runes, msg
+}
+
+func TestScanEscape(t *testing.T) {
+	tests := []struct {
+		input string
+		rules lex.EscapeRules
+		want  string
+		err   string
+	}{
+		{`a\n\t\\\"`, lex.GoEscapes, "a\n\t\\\"", ""},
+		{`\x41\x7e`, lex.GoEscapes, "A~", ""},
+		{`\u00e9\U0001F600`, lex.GoEscapes, "é😀", ""},
+		{`\101\0\12x`, lex.GoEscapes, "A\x00\nx", ""},
+		{`\1018`, lex.GoEscapes, "A8", ""},
+		{`\400`, lex.GoEscapes, "", `f:1:1: escape sequence \400 is out of range`},
+		{`\x4`, lex.GoEscapes, "", "f:1:1: unterminated escape sequence"},
+		{`\x4g`, lex.GoEscapes, "", `f:1:1: invalid character 'g' in escape sequence`},
+		{"\\u12\n", lex.GoEscapes, "", "f:1:1: unterminated escape sequence"},
+		{`ab\q`, lex.GoEscapes, "ab", `f:1:3: unknown escape sequence \q`},
+		{`\`, lex.GoEscapes, "", "f:1:1: unterminated escape sequence"},
+		{"x\\\ny", lex.GoEscapes, "x", "f:1:2: unterminated escape sequence"},
+		{`\U00110000`, lex.GoEscapes, "", `f:1:1: escape sequence \U00110000 is out of range`},
+		{`\ud800`, lex.GoEscapes, "", `f:1:1: escape sequence \ud800 is an invalid code point`},
+		{`\x41`, lex.EscapeRules{}, "", `f:1:1: unknown escape sequence \x`},
+		{`\u0041`, lex.EscapeRules{Hex: true}, "", `f:1:1: unknown escape sequence \u`},
+		{`\0`, lex.EscapeRules{Unicode: true}, "", `f:1:1: unknown escape sequence \0`},
+		{`\e`, lex.EscapeRules{Simple: map[rune]rune{'e': 0x1b}}, "\x1b", ""},
+	}
+	for _, tt := range tests {
+		runes, err := scanEscapes(tt.input, tt.rules, "")
+		if string(runes) != tt.want || err != tt.err {
+			t.Errorf("%q: got %q, %q, want %q, %q", tt.input, string(runes), err, tt.want, tt.err)
+		}
+	}
+}
+
+func TestScanEscapeAutoSkip(t *testing.T) {
+	runes, err := scanEscapes(`  \q`, lex.GoEscapes, " ")
+	if len(runes) != 0 || err != `f:1:3: unknown escape sequence \q` {
+		t.Errorf("got %q, %q, want the error at the backslash", string(runes), err)
+	}
+}
+
+// scanDecoded returns input with the sequences starting with lead
+// replaced by scan, and the first error it returned.
+func scanDecoded(input string, lead rune, skip string, scan func(l *lex.Lexer) (string, error)) (string, string) {
+	var out []rune
+	var msg string
+	l := lex.Lex("f", input, func(l *lex.Lexer) lex.StateFn {
+		l.SetAutoSkip(skip)
+		for {
+			if l.Peek() != lead && (skip == "" || l.Peek() != ' ') {
+				r := l.Next()
+				if r < 0 {
+					return nil
+				}
+				out = append(out, r)
+				continue
+			}
+			s, err := scan(l)
+			if err != nil {
+				msg = err.Error()
+				return nil
+			}
+			out = append(out, []rune(s)...)
+		}
+	})
+	l.Drain()
+	return string(out), msg
+}
+
+func scanPercent(l *lex.Lexer) (string, error) {
+	b, err := l.ScanPercentEscape()
+	return string(rune(b)), err
+}
+
+func TestScanPercentEscape(t *testing.T) {
+	tests := []struct {
+		input, want, err string
+	}{
+		{"a%2Fb%2f", "a/b/", ""},
+		{"%41%7E", "A~", ""},
+		{"%C3", "Ã", ""},
+		{"x%4", "x", "f:1:2: invalid percent escape %4"},
+		{"%4g", "", "f:1:1: invalid percent escape %4"},
+		{"%%", "", "f:1:1: invalid percent escape %"},
+		{"%", "", "f:1:1: invalid percent escape %"},
+	}
+	for _, tt := range tests {
+		got, err := scanDecoded(tt.input, '%', "", scanPercent)
+		if got != tt.want || err != tt.err {
+			t.Errorf("%q: got %q, %q, want %q, %q", tt.input, got, err, tt.want, tt.err)
+		}
+	}
+}
+
+func TestScanHTMLEntity(t *testing.T) {
+	tests := []struct {
+		input, want, err string
+	}{
+		{"a&amp;b&lt;&gt;", "a&b<>", ""},
+		{"&#39;&#x1F600;&#X41;", "'\U0001F600A", ""},
+		{"&semi;&NotEqualTilde;", ";≂̸", ""},
+		{"&amp", "", "f:1:1: missing ; in character reference"},
+		{"x&ampx;", "x", "f:1:2: unknown character reference &ampx;"},
+		{"&foo;", "", "f:1:1: unknown character reference &foo;"},
+		{"&;", "", "f:1:1: expected character reference"},
+		{"&#;", "", "f:1:1: missing digits in character reference"},
+		{"&#x;", "", "f:1:1: missing digits in character reference"},
+		{"&#12", "", "f:1:1: missing ; in character reference"},
+		{"&#0;", "", "f:1:1: character reference &#0; is an invalid code point"},
+		{"&#xD800;", "", "f:1:1: character reference &#xD800; is an invalid code point"},
+		{"&#99999999999;", "", "f:1:1: character reference &#99999999999; is an invalid code point"},
+	}
+	for _, tt := range tests {
+		got, err := scanDecoded(tt.input, '&', "", (*lex.Lexer).ScanHTMLEntity)
+		if got != tt.want || err != tt.err {
+			t.Errorf("%q: got %q, %q, want %q, %q", tt.input, got, err, tt.want, tt.err)
+		}
+	}
+}
+
+func TestScanDecodedAutoSkip(t *testing.T) {
+	if got, err := scanDecoded("  %4g", '%', " ", scanPercent); got != "" || err != "f:1:3: invalid percent escape %4" {
+		t.Errorf("ScanPercentEscape: got %q, %q, want the error at the percent sign", got, err)
+	}
+	if got, err := scanDecoded("  &foo;", '&', " ", (*lex.Lexer).ScanHTMLEntity); got != "" || err != "f:1:3: unknown character reference &foo;" {
+		t.Errorf("ScanHTMLEntity: got %q, %q, want the error at the ampersand", got, err)
+	}
+}
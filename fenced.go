@@ -0,0 +1,84 @@
+// Copyright (c) 2015, Ben Morgan. All rights reserved.
+// Use of this source code is governed by an MIT license
+// that can be found in the LICENSE file.
+
+package lex
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// ScanFenced consumes a block delimited by fences, starting with fence at
+// the current position. The fence begins with a run of a single rune, which
+// may be repeated more often in the opening fence to allow the contents to
+// contain the shorter fence.
+//
+// If fence consists only of that run, such as ``` or ~~~, the block is
+// line-based as in Markdown: the rest of the opening line belongs to the
+// block, and the block is closed by a line holding a run of the rune at
+// least as long as the opening run. If allowIndent is true, the closing
+// fence may be indented by spaces and tabs.
+//
+// Otherwise the closing fence mirrors the opening one and may appear
+// anywhere, as in Rust raw strings: after consuming r, the fence #" opens
+// a string such as r##"a "# b"## that is closed by "##.
+//
+// If the block is not closed, the input is consumed to the end and an
+// *Error is returned, positioned at the opening fence. If there is no
+// opening fence, nothing is consumed and an *Error is returned.
+func (l *Lexer) ScanFenced(fence string, allowIndent bool) error {
+	l.autoSkip() // so that start is the position of the fence
+	start := l.pos
+	c, size := utf8.DecodeRuneInString(fence)
+	run := 0
+	for strings.HasPrefix(fence[run:], string(c)) {
+		run += size
+	}
+	rest := fence[run:]
+	if run == 0 || !l.Consume(fence[:run]) {
+		return l.errorAt(start, "expected %q", fence)
+	}
+	n := run + l.AcceptRun(string(c)) // length of the run in bytes
+	if !l.Consume(rest) {
+		l.pos, l.width = start, 0
+		return l.errorAt(start, "expected %q", fence)
+	}
+	runes := strings.Repeat(string(c), n/size)
+
+	if rest != "" {
+		closing := reverse(rest) + runes
+		for !l.Consume(closing) {
+			if l.Next() < 0 {
+				return l.errorAt(start, "unterminated block, expected %q", closing)
+			}
+		}
+		return nil
+	}
+	for {
+		l.AcceptButRun(Endline)
+		if r := l.Next(); r < 0 {
+			return l.errorAt(start, "unterminated block, expected %s", runes)
+		} else if r == '\r' {
+			l.Consume("\n")
+		}
+		if allowIndent {
+			l.AcceptRun(Space)
+		}
+		if l.AcceptRun(string(c)) >= n {
+			l.AcceptRun(Space)
+			if r := l.Peek(); r < 0 || IsEndline(r) {
+				return nil
+			}
+		}
+	}
+}
+
+// reverse returns s with its runes in reverse order.
+func reverse(s string) string {
+	r := []rune(s)
+	for i, j := 0, len(r)-1; i < j; i, j = i+1, j-1 {
+		r[i], r[j] = r[j], r[i]
+	}
+	return string(r)
+}
@@ -0,0 +1,72 @@
+// Copyright (c) 2015, Ben Morgan. All rights reserved.
+// Use of this source code is governed by an MIT license
+// that can be found in the LICENSE file.
+
+package lex_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/goulash/lex"
+)
+
+func TestScanFenced(t *testing.T) {
+	tests := []struct {
+		input, fence string
+		indent       bool
+		want         string
+	}{
+		{"```go\na\n```\nb", "```", false, "\"```go\\na\\n```\" \"\\n\" \"b\" \"\""},
+		{"````\n```\n````` \nb", "```", false, "\"````\\n```\\n````` \" \"\\n\" \"b\" \"\""},
+		{"```\n  ```\n```", "```", false, "\"```\\n  ```\\n```\" \"\""},
+		{"```\n  ```\nb", "```", true, "\"```\\n  ```\" \"\\n\" \"b\" \"\""},
+		{"~~~\r\n~~~\r\n", "~~~", false, "\"~~~\\r\\n~~~\" \"\\r\" \"\\n\" \"\""},
+		{"```\na", "```", false, "f:1:1: unterminated block, expected ```"},
+		{"``x", "```", false, "f:1:1: expected \"```\""},
+		{`#"a "# b"#x`, `#"`, false, `"#\"a \"#" " " "b" "\"" "#" "x" ""`},
+		{`##"a "# b"##x`, `#"`, false, `"##\"a \"# b\"##" "x" ""`},
+		{`##a`, `#"`, false, `f:1:1: expected "#\""`},
+		{`#"a"`, `#"`, false, `f:1:1: unterminated block, expected "\"#"`},
+		{"ééé\nx\néééé\n", "ééé", false, "\"ééé\\nx\\néééé\" \"\\n\" \"\""},
+		{"éééé\néééé", "ééé", false, "\"éééé\\néééé\" \"\""},
+		{"éééé\nééé", "ééé", false, "f:1:1: unterminated block, expected éééé"},
+		{"```", "", false, `f:1:1: expected ""`},
+	}
+	for _, tt := range tests {
+		var msg string
+		l := lex.Lex("f", tt.input, func(l *lex.Lexer) lex.StateFn {
+			if err := l.ScanFenced(tt.fence, tt.indent); err != nil {
+				msg = err.Error()
+				return nil
+			}
+			l.Emit(typeWord)
+			return lexWords
+		})
+		var got []string
+		for _, tok := range lex.Collect(l) {
+			got = append(got, fmt.Sprintf("%q", tok.Value))
+		}
+		if msg != "" {
+			got = []string{msg}
+		}
+		if s := strings.Join(got, " "); s != tt.want {
+			t.Errorf("ScanFenced(%q) on %q:\ngot  %s\nwant %s", tt.fence, tt.input, s, tt.want)
+		}
+	}
+}
+
+func TestScanFencedAutoSkip(t *testing.T) {
+	var msg string
+	l := lex.Lex("f", "  ``x", func(l *lex.Lexer) lex.StateFn {
+		l.SetAutoSkip(" ")
+		if err := l.ScanFenced("```", false); err != nil {
+			msg = err.Error()
+		}
+		return lexWords
+	})
+	if got := strings.Join(values(lex.Collect(l)), "|"); got != "`|`|x|" || msg != `f:1:3: expected "`+"```"+`"` {
+		t.Errorf("got %q, %s; want the error at the fence and nothing consumed", got, msg)
+	}
+}
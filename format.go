@@ -0,0 +1,69 @@
+// Copyright (c) 2015, Ben Morgan. All rights reserved.
+// Use of this source code is governed by an MIT license
+// that can be found in the LICENSE file.
+
+package lex
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// FormatError formats an error message about tok for display, with an
+// excerpt of the line of input containing tok and carets marking it:
+//
+//	error: unexpected ')'
+//	 --> input.conf:3:12
+//	  |
+//	3 | x = (1 + 2))
+//	  |            ^
+//
+// The file is omitted if tok does not come from a Lexer.
+func FormatError(input string, tok Token, msg string) string {
+	pos := min(max(tok.Pos, 0), len(input))
+	start := strings.LastIndex(input[:pos], "\n") + 1
+	end := strings.IndexByte(input[pos:], '\n')
+	if end < 0 {
+		end = len(input)
+	} else {
+		end += pos
+	}
+	line := 1 + strings.Count(input[:start], "\n")
+	text := strings.TrimRight(input[start:end], "\r")
+	end = max(start+len(text), pos)
+
+	// Pad with tabs where the line has tabs, so the carets line up.
+	var pad strings.Builder
+	for _, r := range input[start:pos] {
+		if r == '\t' {
+			pad.WriteByte('\t')
+		} else {
+			pad.WriteByte(' ')
+		}
+	}
+	tokEnd := tok.End
+	if tokEnd > end {
+		tokEnd = end
+	}
+	carets := 1
+	if tokEnd > pos {
+		carets = utf8.RuneCountInString(input[pos:tokEnd])
+	}
+
+	loc := fmt.Sprintf("%d:%d", line, 1+utf8.RuneCountInString(input[start:pos]))
+	if tok.pb != nil {
+		loc = tok.pb.file + ":" + loc
+	}
+	num := strconv.Itoa(line)
+	gutter := strings.Repeat(" ", len(num))
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "error: %s\n", msg)
+	fmt.Fprintf(&b, "%s--> %s\n", gutter, loc)
+	fmt.Fprintf(&b, "%s |\n", gutter)
+	fmt.Fprintf(&b, "%s | %s\n", num, text)
+	fmt.Fprintf(&b, "%s | %s%s\n", gutter, pad.String(), strings.Repeat("^", carets))
+	return b.String()
+}
@@ -0,0 +1,37 @@
+// Copyright (c) 2015, Ben Morgan. All rights reserved.
+// Use of this source code is governed by an MIT license
+// that can be found in the LICENSE file.
+
+package lex_test
+
+import (
+	"testing"
+
+	"github.com/goulash/lex"
+)
+
+func TestFormatError(t *testing.T) {
+	const input = "a = 1\n\tbé  cd\r\nlast"
+	toks := lex.Collect(lex.Lex("in.conf", input, lexWords))
+	var cd lex.Token
+	for _, tok := range toks {
+		if tok.Value == "cd" {
+			cd = tok
+		}
+	}
+	tests := []struct {
+		tok  lex.Token
+		want string
+	}{
+		{cd, "error: msg\n --> in.conf:2:6\n  |\n2 | \tbé  cd\n  | \t    ^^\n"},
+		{toks[len(toks)-1], "error: msg\n --> in.conf:3:5\n  |\n3 | last\n  |     ^\n"},
+		{lex.Token{Pos: 7, End: 99}, "error: msg\n --> 2:2\n  |\n2 | \tbé  cd\n  | \t^^^^^^\n"},
+		{lex.Token{Pos: -3, End: -1}, "error: msg\n --> 1:1\n  |\n1 | a = 1\n  | ^\n"},
+		{lex.Token{Pos: 99, End: 100}, "error: msg\n --> 3:5\n  |\n3 | last\n  |     ^\n"},
+	}
+	for _, tt := range tests {
+		if got := lex.FormatError(input, tt.tok, "msg"); got != tt.want {
+			t.Errorf("%d-%d:\ngot\n%swant\n%s", tt.tok.Pos, tt.tok.End, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,49 @@
+// Copyright (c) 2015, Ben Morgan. All rights reserved.
+// Use of this source code is governed by an MIT license
+// that can be found in the LICENSE file.
+
+package lex
+
+import "go/token"
+
+// AddToFileSet adds the input of the lexer read so far to fset as a file
+// with the name of the lexer and its lines, and returns the file. This
+// lets tools that report diagnostics with go/token work with lexers
+// written with this package:
+//
+//	f := l.AddToFileSet(fset)
+//	...
+//	pos := lex.GoPos(f, t)
+//	fmt.Println(fset.Position(pos))
+//
+// It should be called once lexing has finished. Note that go/token counts
+// columns in bytes, and does not know about SetPosition. Since go/token
+// has no lines starting at the end of a file, the end of input following
+// a final newline is at the end of the last line instead. If go/token
+// rejects the lines, such as when lines joined by WithLineContinuation
+// start at the end of the input, the lines are taken from the line
+// endings of the input instead.
+func (l *Lexer) AddToFileSet(fset *token.FileSet) *token.File {
+	f := fset.AddFile(l.name, -1, len(l.input))
+	lines := l.LineOffsets()
+	for len(lines) > 1 && lines[len(lines)-1] >= len(l.input) {
+		lines = lines[:len(lines)-1]
+	}
+	if !f.SetLines(lines) {
+		f.SetLinesForContent([]byte(l.input))
+	}
+	return f
+}
+
+// GoPos returns the go/token position of the start of t in f, which must
+// have been returned by AddToFileSet for the lexer that emitted t.
+func GoPos(f *token.File, t Token) token.Pos {
+	return f.Pos(t.Pos)
+}
+
+// GoOffset returns the offset in the input of the go/token position p in
+// f, which is comparable with the Pos and End fields of tokens, such as
+// for TokenIndex.AtOffset.
+func GoOffset(f *token.File, p token.Pos) int {
+	return f.Offset(p)
+}
@@ -0,0 +1,57 @@
+// Copyright (c) 2015, Ben Morgan. All rights reserved.
+// Use of this source code is governed by an MIT license
+// that can be found in the LICENSE file.
+
+package lex
+
+import "unicode"
+
+const zwj = '\u200d' // zero width joiner
+
+// NextGrapheme consumes the next grapheme cluster in the input and
+// returns it. If there is no more input left to read, "" is returned.
+//
+// A grapheme cluster is what a user perceives as a single character,
+// such as a letter followed by combining marks, an emoji ZWJ sequence,
+// a flag made of two regional indicators, or CR LF. This is a pragmatic
+// approximation of the extended grapheme clusters of Unicode UAX #29.
+//
+// Backup cannot be used to undo NextGrapheme.
+func (l *Lexer) NextGrapheme() string {
+	r := l.Next()
+	start := l.pos - l.width // Next may have resumed a suspended input
+	switch {
+	case r < 0:
+		return ""
+	case r == '\r':
+		l.Accept("\n")
+		return l.input[start:l.pos]
+	case isRegionalIndicator(r):
+		l.AcceptFunc(isRegionalIndicator)
+	}
+	for {
+		r := l.Next()
+		switch {
+		case r == zwj:
+			// The rune following a joiner is part of the cluster.
+			if p := l.Peek(); p >= 0 && !IsEndline(p) {
+				l.Next()
+			}
+		case isGraphemeExtend(r):
+		default:
+			l.Backup()
+			return l.input[start:l.pos]
+		}
+	}
+}
+
+// isGraphemeExtend reports whether r extends the preceding grapheme.
+func isGraphemeExtend(r rune) bool {
+	return unicode.In(r, unicode.Mn, unicode.Me, unicode.Mc) ||
+		(r >= 0x1F3FB && r <= 0x1F3FF) || // emoji modifiers
+		(r >= 0xE0020 && r <= 0xE007F) // tags
+}
+
+func isRegionalIndicator(r rune) bool {
+	return r >= 0x1F1E6 && r <= 0x1F1FF
+}
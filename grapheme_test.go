@@ -0,0 +1,49 @@
+// Copyright (c) 2015, Ben Morgan. All rights reserved.
+// Use of this source code is governed by an MIT license
+// that can be found in the LICENSE file.
+
+package lex_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/goulash/lex"
+)
+
+func TestNextGrapheme(t *testing.T) {
+	var got []string
+	l := lex.Lex("a", "é👍🏽🇩🇪👨‍👩‍👧\r\nx", func(l *lex.Lexer) lex.StateFn {
+		for g := l.NextGrapheme(); g != ""; g = l.NextGrapheme() {
+			got = append(got, g)
+			l.Emit(typeOther)
+		}
+		if l.NextGrapheme() != "" {
+			t.Error("no empty grapheme at the end")
+		}
+		return nil
+	})
+	toks := lex.Collect(l)
+	want := []string{"é", "👍🏽", "🇩🇪", "👨‍👩‍👧", "\r\n", "x"}
+	if strings.Join(got, "|") != strings.Join(want, "|") || len(toks) != len(want)+1 {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestNextGraphemeAfterPushInput(t *testing.T) {
+	var got []string
+	l := lex.Lex("a", "abc", func(l *lex.Lexer) lex.StateFn {
+		got = append(got, l.NextGrapheme())
+		l.Emit(typeOther)
+		l.PushInput("b", "xyz")
+		for g := l.NextGrapheme(); g != ""; g = l.NextGrapheme() {
+			got = append(got, g)
+			l.Emit(typeOther)
+		}
+		return nil
+	})
+	lex.Collect(l)
+	if strings.Join(got, "") != "axyzbc" {
+		t.Errorf("got %q, want a, x, y, z, b, c", got)
+	}
+}
@@ -0,0 +1,38 @@
+// Copyright (c) 2015, Ben Morgan. All rights reserved.
+// Use of this source code is governed by an MIT license
+// that can be found in the LICENSE file.
+
+package lex
+
+import (
+	"encoding/binary"
+	"hash/fnv"
+)
+
+// HashTokens returns a hash of the types and values of toks, which does
+// not depend on their positions, so that build tools can tell whether a
+// change to an input is significant. Tokens whose type is one of ignore,
+// or a member of one of these categories, see RegisterCategory, do not
+// contribute to the hash:
+//
+//	key := lex.HashTokens(toks, TypeComment, TypeSpace)
+//
+// The hash is stable across processes and versions of package lex, but
+// changes when the values of the types change.
+func HashTokens(toks []Token, ignore ...Type) uint64 {
+	h := fnv.New64a()
+	var buf [2 * binary.MaxVarintLen64]byte
+tokens:
+	for _, t := range toks {
+		for _, cat := range ignore {
+			if t.Type.Is(cat) {
+				continue tokens
+			}
+		}
+		n := binary.PutVarint(buf[:], int64(t.Type))
+		n += binary.PutUvarint(buf[n:], uint64(len(t.Value)))
+		h.Write(buf[:n])
+		h.Write([]byte(t.Value))
+	}
+	return h.Sum64()
+}
@@ -0,0 +1,42 @@
+// Copyright (c) 2015, Ben Morgan. All rights reserved.
+// Use of this source code is governed by an MIT license
+// that can be found in the LICENSE file.
+
+package lex_test
+
+import (
+	"testing"
+
+	"github.com/goulash/lex"
+)
+
+func TestHashTokens(t *testing.T) {
+	hash := func(input string, ignore ...lex.Type) uint64 {
+		return lex.HashTokens(lex.Collect(lex.Lex("f", input, lexWords)), ignore...)
+	}
+	if h := hash("ab cd"); h != 0xf4b8fa35a9c95d34 {
+		t.Errorf("got %#x, want a stable hash", h)
+	}
+	if hash("ab cd", typeSpace) != hash("ab  \t cd", typeSpace, typeOther) {
+		t.Error("ignored tokens changed the hash")
+	}
+	if hash("ab cd") == hash("ab  cd") || hash("ab cd") == hash("cd ab") || hash("ab!", typeSpace) == hash("ab", typeSpace) {
+		t.Error("different tokens have the same hash")
+	}
+
+	const typeTrivia lex.Type = 104
+	lex.RegisterCategory(typeTrivia, typeSpace)
+	if hash("ab cd", typeTrivia) != hash("ab     cd", typeTrivia) {
+		t.Error("tokens of an ignored category changed the hash")
+	}
+
+	toks := []lex.Token{{Type: typeWord, Value: "ab"}, {Type: typeWord, Value: "c"}}
+	split := []lex.Token{{Type: typeWord, Value: "a"}, {Type: typeWord, Value: "bc"}}
+	if lex.HashTokens(toks) == lex.HashTokens(split) {
+		t.Error("values split differently have the same hash")
+	}
+	moved := []lex.Token{{Type: typeWord, Value: "ab", Pos: 10, End: 12}, {Type: typeWord, Value: "c", Pos: 20}}
+	if lex.HashTokens(toks) != lex.HashTokens(moved) {
+		t.Error("positions changed the hash")
+	}
+}
@@ -0,0 +1,98 @@
+// Copyright (c) 2015, Ben Morgan. All rights reserved.
+// Use of this source code is governed by an MIT license
+// that can be found in the LICENSE file.
+
+// Package highlight renders input with syntax highlighting, using the
+// tokens that a lexer emitted for it.
+//
+// Each token type can be given a style. The input is rendered as is,
+// with the text of each token that has a style wrapped in an HTML span
+// or ANSI escape sequences:
+//
+//	styles := highlight.Styles{
+//	    TypeKeyword: {ANSI: highlight.Bold, Class: "kw"},
+//	    TypeString:  {ANSI: highlight.Green, Class: "str"},
+//	}
+//	highlight.ANSI(os.Stdout, input, lex.Collect(l), styles)
+//
+// Input not covered by any token, such as ignored whitespace, is
+// rendered without style.
+package highlight
+
+import (
+	"html"
+	"io"
+
+	"github.com/goulash/lex"
+)
+
+// Some ANSI SGR parameters for use in Style.
+const (
+	Bold    = "1"
+	Faint   = "2"
+	Italic  = "3"
+	Red     = "31"
+	Green   = "32"
+	Yellow  = "33"
+	Blue    = "34"
+	Magenta = "35"
+	Cyan    = "36"
+)
+
+// A Style describes how the text of a token is rendered.
+type Style struct {
+	ANSI  string // SGR parameters, such as "1;34" for bold blue
+	Class string // HTML class of the span
+}
+
+// Styles maps token types to their styles.
+type Styles map[lex.Type]Style
+
+// HTML writes input to w as HTML, with the text of each token wrapped
+// in a span with the class of its style. Tokens must be sorted by
+// position; tokens that overlap previous ones are ignored.
+func HTML(w io.Writer, input string, toks []lex.Token, styles Styles) error {
+	return render(w, input, toks, func(s string, t lex.Type) string {
+		st, ok := styles[t]
+		s = html.EscapeString(s)
+		if !ok || st.Class == "" {
+			return s
+		}
+		return `<span class="` + html.EscapeString(st.Class) + `">` + s + "</span>"
+	})
+}
+
+// ANSI writes input to w with the text of each token wrapped in the ANSI
+// escape sequences of its style, for display on a terminal. Tokens must
+// be sorted by position; tokens that overlap previous ones are ignored.
+func ANSI(w io.Writer, input string, toks []lex.Token, styles Styles) error {
+	return render(w, input, toks, func(s string, t lex.Type) string {
+		st, ok := styles[t]
+		if !ok || st.ANSI == "" {
+			return s
+		}
+		return "\x1b[" + st.ANSI + "m" + s + "\x1b[0m"
+	})
+}
+
+// render writes input to w, formatting the text of each token with
+// format. Text between tokens is formatted with type -1.
+func render(w io.Writer, input string, toks []lex.Token, format func(string, lex.Type) string) error {
+	var pos int
+	for _, t := range toks {
+		if t.Pos < pos || t.End <= t.Pos || t.End > len(input) {
+			continue
+		}
+		if t.Pos > pos {
+			if _, err := io.WriteString(w, format(input[pos:t.Pos], -1)); err != nil {
+				return err
+			}
+		}
+		if _, err := io.WriteString(w, format(input[t.Pos:t.End], t.Type)); err != nil {
+			return err
+		}
+		pos = t.End
+	}
+	_, err := io.WriteString(w, format(input[pos:], -1))
+	return err
+}
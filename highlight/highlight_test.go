@@ -0,0 +1,73 @@
+// Copyright (c) 2015, Ben Morgan. All rights reserved.
+// Use of this source code is governed by an MIT license
+// that can be found in the LICENSE file.
+
+package highlight
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/goulash/lex"
+)
+
+const (
+	typeWord lex.Type = 2 + iota
+	typeOther
+)
+
+func toks(input string) []lex.Token {
+	return lex.Collect(lex.Lex("f", input, func(l *lex.Lexer) lex.StateFn {
+		for {
+			l.AcceptRun(" ")
+			l.Ignore()
+			switch r := l.Peek(); {
+			case r < 0:
+				return nil
+			case r >= 'a' && r <= 'z':
+				l.AcceptRun("abcdefghijklmnopqrstuvwxyz")
+				l.Emit(typeWord)
+			default:
+				l.Next()
+				l.Emit(typeOther)
+			}
+		}
+	}))
+}
+
+func TestRender(t *testing.T) {
+	const input = "a <b> & c"
+	styles := Styles{typeWord: {ANSI: Bold, Class: "w"}, typeOther: {ANSI: Red}}
+	var b strings.Builder
+	if err := HTML(&b, input, toks(input), styles); err != nil {
+		t.Fatal(err)
+	}
+	if want := `<span class="w">a</span> &lt;<span class="w">b</span>&gt; &amp; <span class="w">c</span>`; b.String() != want {
+		t.Errorf("HTML:\ngot  %s\nwant %s", b.String(), want)
+	}
+	b.Reset()
+	if err := ANSI(&b, input, toks(input), styles); err != nil {
+		t.Fatal(err)
+	}
+	if want := "\x1b[1ma\x1b[0m \x1b[31m<\x1b[0m\x1b[1mb\x1b[0m\x1b[31m>\x1b[0m \x1b[31m&\x1b[0m \x1b[1mc\x1b[0m"; b.String() != want {
+		t.Errorf("ANSI:\ngot  %q\nwant %q", b.String(), want)
+	}
+}
+
+func TestRenderInvalidTokens(t *testing.T) {
+	const input = "abc"
+	bad := []lex.Token{
+		{Type: typeWord, Pos: 1, End: 2},
+		{Type: typeWord, Pos: 0, End: 3}, // overlaps
+		{Type: typeWord, Pos: 2, End: 9}, // beyond the input
+		{Type: typeWord, Pos: -1, End: 1},
+		{Type: typeWord, Pos: 2, End: 2}, // empty
+	}
+	var b strings.Builder
+	if err := HTML(&b, input, bad, Styles{typeWord: {Class: "w"}}); err != nil {
+		t.Fatal(err)
+	}
+	if want := `a<span class="w">b</span>c`; b.String() != want {
+		t.Errorf("got %s, want %s", b.String(), want)
+	}
+}
@@ -0,0 +1,146 @@
+// Copyright (c) 2015, Ben Morgan. All rights reserved.
+// Use of this source code is governed by an MIT license
+// that can be found in the LICENSE file.
+
+package lex
+
+import "sync"
+
+// ErrMoreInput is returned by Next instead of EOF when the lexer was
+// created with WithIncremental and more input may still be appended.
+// Like EOF it is negative, so loops that stop at r < 0 stop at either.
+const ErrMoreInput = -2
+
+// incremental holds input appended while lexing, see WithIncremental.
+type incremental struct {
+	mu       sync.Mutex
+	cond     sync.Cond
+	appended []string
+	closed   bool
+	midToken bool // whether ErrMoreInput was last returned mid-token
+}
+
+// WithIncremental allows input to be appended with Append while the
+// lexer is running, as in a REPL that lexes line by line. When Next
+// reaches the end of the input read so far, it returns ErrMoreInput
+// instead of EOF until CloseInput is called. A state function can then
+// wait for more input with WaitInput:
+//
+//	func lexText(l *lex.Lexer) lex.StateFn {
+//	    switch r := l.Next(); {
+//	    case r == lex.ErrMoreInput:
+//	        if l.WaitInput() {
+//	            return lexText
+//	        }
+//	        ...
+//	    }
+//	}
+func WithIncremental() Option {
+	return func(l *Lexer) {
+		l.incr = &incremental{}
+		l.incr.cond.L = &l.incr.mu
+	}
+}
+
+// Append appends more to the input of the lexer. It may be called from
+// any goroutine, but only for lexers created with WithIncremental.
+func (l *Lexer) Append(more string) {
+	if l.incr == nil {
+		panic("lex: Append requires WithIncremental")
+	}
+	l.incr.mu.Lock()
+	defer l.incr.mu.Unlock()
+	if l.incr.closed {
+		panic("lex: Append after CloseInput")
+	}
+	l.incr.appended = append(l.incr.appended, more)
+	l.incr.cond.Broadcast()
+}
+
+// CloseInput marks the end of the input of a lexer created with
+// WithIncremental: once all input has been read, Next returns EOF.
+// It may be called from any goroutine.
+func (l *Lexer) CloseInput() {
+	if l.incr == nil {
+		return
+	}
+	l.incr.mu.Lock()
+	defer l.incr.mu.Unlock()
+	l.incr.closed = true
+	l.incr.cond.Broadcast()
+}
+
+// WaitInput blocks until more input is appended or the input is closed,
+// and reports whether more input is available. It is called by state
+// functions after Next returned ErrMoreInput.
+func (l *Lexer) WaitInput() bool {
+	if l.incr == nil {
+		return false
+	}
+	for {
+		l.incr.mu.Lock()
+		for len(l.incr.appended) == 0 && !l.incr.closed {
+			l.incr.cond.Wait()
+		}
+		closed := l.incr.closed
+		l.incr.mu.Unlock()
+		if l.takeAppended() {
+			return true
+		}
+		if closed {
+			return false
+		}
+	}
+}
+
+// takeAppended adds the appended input to the input of the lexer,
+// and reports whether there was any.
+func (l *Lexer) takeAppended() bool {
+	if l.incr == nil {
+		return false
+	}
+	l.incr.mu.Lock()
+	more, closed := l.incr.appended, l.incr.closed
+	l.incr.appended = nil
+	l.incr.mu.Unlock()
+	start := len(l.input)
+	for _, s := range more {
+		l.extendInput(s)
+	}
+	if closed {
+		l.endInput()
+	}
+	return len(l.input) > start
+}
+
+// endRune returns the rune that Next returns at the end of the input.
+func (l *Lexer) endRune() rune {
+	if l.incr == nil || l.halted || len(l.stack) > 0 {
+		return EOF
+	}
+	l.incr.mu.Lock()
+	defer l.incr.mu.Unlock()
+	if l.incr.closed && len(l.incr.appended) == 0 {
+		return EOF
+	}
+	l.incr.midToken = l.pos > l.base
+	return ErrMoreInput
+}
+
+// NeedsMoreInput reports whether the lexer ran out of input in the middle
+// of a token, such as in an unterminated string or an open block comment,
+// the last time Next returned ErrMoreInput. A REPL can use this to print a
+// continuation prompt instead of reporting a syntax error. It may be
+// called from any goroutine, but only makes sense with WithIncremental.
+//
+// A token is pending if input was read since the last call to Emit or
+// Ignore, so state functions should ignore insignificant input, such as
+// whitespace, before reading more.
+func (l *Lexer) NeedsMoreInput() bool {
+	if l.incr == nil {
+		return false
+	}
+	l.incr.mu.Lock()
+	defer l.incr.mu.Unlock()
+	return l.incr.midToken
+}
@@ -0,0 +1,102 @@
+// Copyright (c) 2015, Ben Morgan. All rights reserved.
+// Use of this source code is governed by an MIT license
+// that can be found in the LICENSE file.
+
+package lex_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/goulash/lex"
+)
+
+// lexRepl lexes words like lexWords, waiting for more input at the end
+// of the input appended so far.
+func lexRepl(l *lex.Lexer) lex.StateFn {
+	for {
+		if !lexWord(l) {
+			if l.Peek() == lex.ErrMoreInput && l.WaitInput() {
+				continue
+			}
+			return nil
+		}
+	}
+}
+
+func TestIncremental(t *testing.T) {
+	l := lex.New("a", "ab", lex.WithIncremental())
+	go l.Run(lexRepl)
+	r := lex.NewReader(l)
+	next := func() string { return r.Next().Value }
+	if got := next(); got != "ab" {
+		t.Fatalf("got %q, want ab", got)
+	}
+	l.Append(" c")
+	l.Append("\xc3")
+	if got := next() + "|" + next(); got != " |c" {
+		t.Fatalf("got %q, want the appended tokens", got)
+	}
+	l.Append("\xa9d!")
+	l.CloseInput()
+	var rest []string
+	for tok := range r.All() {
+		rest = append(rest, tok.Value)
+	}
+	if got := strings.Join(rest, "|"); got != "é|d|!|" {
+		t.Errorf("got %q, want the rune split between appends joined", got)
+	}
+}
+
+func TestAppendPanics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("Append after CloseInput did not panic")
+		}
+	}()
+	l := lex.New("a", "", lex.WithIncremental())
+	l.CloseInput()
+	l.Append("x")
+}
+
+func TestNeedsMoreInput(t *testing.T) {
+	waiting := make(chan bool, 1)
+	var lexString lex.StateFn
+	lexString = func(l *lex.Lexer) lex.StateFn {
+		quoted := l.Accept(`"`)
+		for {
+			switch r := l.Next(); {
+			case r == lex.ErrMoreInput:
+				waiting <- l.NeedsMoreInput()
+				if !l.WaitInput() {
+					return nil
+				}
+			case r < 0:
+				return nil
+			case r == '"' && quoted:
+				l.Emit(typeOther)
+				return lexString
+			}
+		}
+	}
+	l := lex.New("a", `"ab`, lex.WithIncremental())
+	go l.Run(lexString)
+	r := lex.NewReader(l)
+	if !<-waiting {
+		t.Error("got false in the string")
+	}
+	l.Append(`c"`)
+	if tok := r.Next(); tok.Value != `"abc"` {
+		t.Errorf("got %v, want the string", tok)
+	}
+	if <-waiting {
+		t.Error("got true after the string")
+	}
+	l.CloseInput()
+	if tok := r.Next(); tok.Type != lex.TypeEOF {
+		t.Errorf("got %v, want EOF", tok)
+	}
+	if lex.New("a", "").NeedsMoreInput() {
+		t.Error("got true without WithIncremental")
+	}
+}
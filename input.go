@@ -0,0 +1,93 @@
+// Copyright (c) 2015, Ben Morgan. All rights reserved.
+// Use of this source code is governed by an MIT license
+// that can be found in the LICENSE file.
+
+package lex
+
+// An inputFrame stores the state of an input suspended by PushInput.
+type inputFrame struct {
+	input string
+	pb    *posBase
+	base  int
+	pos   int
+	width int
+	wsEnd int
+
+	origin *Origin
+}
+
+// PushInput suspends the current input and continues lexing with input,
+// which is reported under the given name. This can be used to implement
+// include directives.
+//
+// When Next reaches the end of the pushed input and no input is pending,
+// lexing continues in the suspended input where it left off. If a token
+// is still pending, Next returns EOF first so it can be emitted.
+//
+// PushInput should be called when no input is pending, such as directly
+// after Emit or Ignore. When lexing continues in the suspended input, the
+// origin active at the call is restored, see PushOrigin.
+func (l *Lexer) PushInput(name, input string) {
+	l.stack = append(l.stack, l.frame())
+	input, _, joins := l.normalize(input, nil)
+	l.input = input
+	l.pb = &posBase{input: input, file: name, line: 1, joins: joins}
+	l.base, l.pos, l.width, l.wsEnd = 0, 0, 0, 0
+}
+
+// popInput resumes the input suspended by the last call to PushInput.
+func (l *Lexer) popInput() {
+	f := l.stack[len(l.stack)-1]
+	l.stack = l.stack[:len(l.stack)-1]
+	l.resume(f)
+}
+
+// frame returns the state of the current input.
+func (l *Lexer) frame() inputFrame {
+	return inputFrame{
+		input: l.input,
+		pb:    l.pb,
+		base:  l.base,
+		pos:   l.pos,
+		width: l.width,
+		wsEnd: l.wsEnd,
+
+		origin: l.origin,
+	}
+}
+
+// resume restores the state of the input saved in f.
+func (l *Lexer) resume(f inputFrame) {
+	l.input, l.pb = f.input, f.pb
+	l.base, l.pos, l.width, l.wsEnd = f.base, f.pos, f.width, f.wsEnd
+	l.origin = f.origin
+}
+
+// atEnd reports whether no more input can be read, because the end of
+// the input has been reached or lexing was halted. At the end of a pushed
+// input, the suspended input is resumed if no input is pending.
+func (l *Lexer) atEnd() bool {
+	if l.halted {
+		return true
+	}
+	if l.ticks++; l.ticks%1024 == 0 && l.expired() {
+		return true
+	}
+	if l.maxTokenLen > 0 && l.pos-l.base > l.maxTokenLen {
+		l.haltTooLong()
+		return true
+	}
+	for l.pos >= len(l.input) {
+		if len(l.stack) == 0 {
+			if !l.fill() && !l.takeAppended() {
+				return true
+			}
+			continue
+		}
+		if l.pos != l.base {
+			return true
+		}
+		l.popInput()
+	}
+	return false
+}
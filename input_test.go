@@ -0,0 +1,67 @@
+// Copyright (c) 2015, Ben Morgan. All rights reserved.
+// Use of this source code is governed by an MIT license
+// that can be found in the LICENSE file.
+
+package lex_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/goulash/lex"
+)
+
+// lexInclude is like lexWords, but lexes the input named by @name in its
+// place, where name is a letter that is looked up in includes.
+func lexInclude(includes map[string]string) lex.StateFn {
+	var sf lex.StateFn
+	sf = func(l *lex.Lexer) lex.StateFn {
+		if l.Consume("@") {
+			l.Next()
+			name := l.Value()[1:]
+			l.Ignore()
+			l.PushInput(name, includes[name])
+			return sf
+		}
+		if l.Peek() == '@' || lexWord(l) {
+			return sf
+		}
+		return nil
+	}
+	return sf
+}
+
+// lexWord lexes a single token like lexWords, and returns false at the
+// end of the input.
+func lexWord(l *lex.Lexer) bool {
+	switch r := l.Peek(); {
+	case r < 0:
+		return false
+	case r >= 'a' && r <= 'z':
+		l.AcceptRangeRun('a', 'z')
+		l.Emit(typeWord)
+	case r == ' ' || r == '\n':
+		l.Next()
+		l.Emit(typeSpace)
+	default:
+		l.Next()
+		l.Emit(typeOther)
+	}
+	return true
+}
+
+func TestPushInput(t *testing.T) {
+	includes := map[string]string{"x": "one @y", "y": "two\nthree"}
+	l := lex.Lex("f", "a @x b@y", lexInclude(includes))
+	got := strings.Join(positions(l), " ")
+	// a, space, one, space, two, newline, three, space, b, two, newline, three, EOF
+	want := "f:1:1 f:1:2 x:1:1 x:1:4 y:1:1 y:1:4 y:2:1 f:1:5 f:1:6 y:1:1 y:1:4 y:2:1 f:1:9"
+	if got != want {
+		t.Errorf("got  %s\nwant %s", got, want)
+	}
+
+	toks := lex.Collect(lex.Lex("f", "a @x b@y", lexInclude(includes)))
+	if got, want := strings.Join(values(toks), ""), "a one two\nthree btwo\nthree"; got != want {
+		t.Errorf("got values %q, want %q", got, want)
+	}
+}
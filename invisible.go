@@ -0,0 +1,68 @@
+// Copyright (c) 2015, Ben Morgan. All rights reserved.
+// Use of this source code is governed by an MIT license
+// that can be found in the LICENSE file.
+
+package lex
+
+import (
+	"fmt"
+	"unicode"
+	"unicode/utf8"
+)
+
+// WithInvisibleWarnings makes the lexer emit a diagnostic token of type t
+// after every token that contains an invisible character, such as a
+// zero-width space, or a bidirectional control character, which can make
+// source code look different from how it is lexed ("Trojan Source").
+//
+// The diagnostic is positioned at the offending character, which is its
+// Raw value, and its Value describes the problem. Whether to treat it as
+// a warning or an error is up to the client.
+func WithInvisibleWarnings(t Type) Option {
+	return func(l *Lexer) {
+		l.invisible = t
+		l.warnInvisible = true
+	}
+}
+
+// invisibleWarnings returns the diagnostic tokens for the invisible
+// characters in t.
+func (l *Lexer) invisibleWarnings(t Token) []Token {
+	var diags []Token
+	for i, r := range t.Raw {
+		kind := invisibleKind(r)
+		if kind == "" {
+			continue
+		}
+		pos := t.Pos + i
+		if len(t.Raw) != t.End-t.Pos {
+			pos = t.Pos // Raw is not the input of the token
+		}
+		diags = append(diags, Token{
+			Type:   l.invisible,
+			Pos:    pos,
+			End:    pos + utf8.RuneLen(r),
+			Value:  fmt.Sprintf("%s U+%04X in token", kind, r),
+			Raw:    string(r),
+			Origin: t.Origin,
+			pb:     t.pb,
+		})
+	}
+	return diags
+}
+
+// invisibleKind describes r if it is invisible, and returns the empty
+// string otherwise.
+func invisibleKind(r rune) string {
+	switch {
+	case r == '\u061c', r == '\u200e', r == '\u200f',
+		'\u202a' <= r && r <= '\u202e', '\u2066' <= r && r <= '\u2069':
+		return "bidirectional control character"
+	case r == '\u200b', r == '\u200c', r == zwj, r == '\u2060', r == '\ufeff':
+		return "zero-width character"
+	case r == '\u034f', r == '\u115f', r == '\u1160', r == '\u3164', r == '\uffa0',
+		unicode.Is(unicode.Cf, r):
+		return "invisible character"
+	}
+	return ""
+}
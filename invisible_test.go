@@ -0,0 +1,54 @@
+// Copyright (c) 2015, Ben Morgan. All rights reserved.
+// Use of this source code is governed by an MIT license
+// that can be found in the LICENSE file.
+
+package lex_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/goulash/lex"
+)
+
+func TestWithInvisibleWarnings(t *testing.T) {
+	lexFields := func(l *lex.Lexer) lex.StateFn {
+		for l.AcceptFuncRun(func(r rune) bool { return r >= 0 && r != ' ' }) > 0 {
+			l.Emit(typeWord)
+			l.AcceptRun(" ")
+			l.Emit(typeSpace)
+		}
+		return nil
+	}
+	const input = "a\u200bb \"x\u202ey\u202c\" c\u00ad"
+	toks := lex.Collect(lex.Lex("f", input, lexFields, lex.WithInvisibleWarnings(typeWarning)))
+	var got []string
+	for _, tok := range toks {
+		got = append(got, fmt.Sprintf("%d:%q@%d-%d", tok.Type, tok.Value, tok.Pos, tok.End))
+	}
+	want := []string{
+		`2:"a\u200bb"@0-5`,
+		`5:"zero-width character U+200B in token"@1-4`,
+		`3:" "@5-6`,
+		`2:"\"x\u202ey\u202c\""@6-16`,
+		`5:"bidirectional control character U+202E in token"@8-11`,
+		`5:"bidirectional control character U+202C in token"@12-15`,
+		`3:" "@16-17`,
+		`2:"c\u00ad"@17-20`,
+		`5:"invisible character U+00AD in token"@18-20`,
+		`3:""@20-20`,
+		`1:""@20-20`,
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+	if toks[1].Raw != "\u200b" {
+		t.Errorf("diagnostic has raw %q, want the invisible character", toks[1].Raw)
+	}
+
+	toks = lex.Collect(lex.Lex("f", input, lexFields))
+	if len(toks) != 7 {
+		t.Errorf("got %s without the option", describeTypes(toks))
+	}
+}
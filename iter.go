@@ -0,0 +1,48 @@
+// Copyright (c) 2015, Ben Morgan. All rights reserved.
+// Use of this source code is governed by an MIT license
+// that can be found in the LICENSE file.
+
+package lex
+
+import "iter"
+
+// All returns an iterator over the remaining tokens of r, up to and
+// including the first TypeEOF or TypeError token, or up to the end of the
+// tokens if the lexer finishes without either, see WithAutoEOF:
+//
+//	for t := range r.All() {
+//	    ...
+//	}
+//
+// If the loop is exited early and r reads directly from a Lexer,
+// the Lexer is drained so that the lexing goroutine exits.
+func (r *Reader) All() iter.Seq[Token] {
+	return func(yield func(Token) bool) {
+		for {
+			t := r.Next()
+			if isEnd(t) {
+				return
+			}
+			if !yield(t) {
+				if l, ok := r.src.(*Lexer); ok {
+					l.Drain()
+				}
+				return
+			}
+			if t.Type == TypeEOF || t.Type == TypeError {
+				return
+			}
+		}
+	}
+}
+
+// isEnd reports whether t is the zero token that is read after a lexer
+// has finished. Tokens emitted by a lexer always have a position base.
+func isEnd(t Token) bool {
+	return t.Type == TypeError && t.Value == "" && t.pb == nil
+}
+
+// Collect returns all tokens emitted by l until it finishes.
+func Collect(l *Lexer) []Token {
+	return Record(l).Tokens
+}
@@ -0,0 +1,51 @@
+// Copyright (c) 2015, Ben Morgan. All rights reserved.
+// Use of this source code is governed by an MIT license
+// that can be found in the LICENSE file.
+
+package lex_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/goulash/lex"
+)
+
+func TestReaderAll(t *testing.T) {
+	tests := []struct {
+		opts []lex.Option
+		want string
+	}{
+		{nil, "ab| |cd|"},
+		{[]lex.Option{lex.WithAutoEOF(false)}, "ab| |cd"},
+	}
+	for _, tt := range tests {
+		var vs []string
+		for tok := range lex.NewReader(lex.Lex("f", "ab cd", lexWords, tt.opts...)).All() {
+			vs = append(vs, tok.Value)
+		}
+		if got := strings.Join(vs, "|"); got != tt.want {
+			t.Errorf("got %q, want %q", got, tt.want)
+		}
+	}
+
+	l := lex.Lex("f", strings.Repeat("ab ", 1000), lexWords)
+	for tok := range lex.NewReader(l).All() {
+		if tok.Value == " " {
+			break
+		}
+	}
+	select {
+	case <-l.Done():
+	case <-time.After(time.Second):
+		t.Error("lexer still running after breaking out of the loop")
+	}
+}
+
+func TestCollect(t *testing.T) {
+	toks := lex.Collect(lex.Lex("f", "ab!", lexWords))
+	if got := strings.Join(values(toks), "|"); got != "ab|!|" || toks[2].Type != lex.TypeEOF {
+		t.Errorf("got %q, want ab, !, and EOF", got)
+	}
+}
@@ -0,0 +1,69 @@
+// Copyright (c) 2015, Ben Morgan. All rights reserved.
+// Use of this source code is governed by an MIT license
+// that can be found in the LICENSE file.
+
+package lex
+
+import "strings"
+
+// Keywords matches words against a fixed set of keywords, see
+// Lexer.EmitKeyword.
+type Keywords struct {
+	types map[string]Type
+	canon map[string]string
+
+	// FoldCase makes keywords match regardless of case.
+	FoldCase bool
+
+	// Canonicalize makes EmitKeyword emit keywords with the value they
+	// were defined with, such as SELECT for select or Select. The text
+	// as written in the input remains available in the Raw field.
+	// This only has an effect with FoldCase.
+	Canonicalize bool
+}
+
+// NewKeywords returns a new keyword matcher for the given keywords.
+func NewKeywords(keywords map[string]Type) *Keywords {
+	k := &Keywords{
+		types: make(map[string]Type, len(keywords)),
+		canon: make(map[string]string, len(keywords)),
+	}
+	for s, t := range keywords {
+		k.types[s] = t
+		k.canon[strings.ToLower(s)] = s
+	}
+	return k
+}
+
+// Lookup returns the type of the keyword s and its canonical spelling.
+// If s is not a keyword, false is returned.
+func (k *Keywords) Lookup(s string) (t Type, canonical string, ok bool) {
+	if t, ok := k.types[s]; ok {
+		return t, s, true
+	}
+	if !k.FoldCase {
+		return 0, "", false
+	}
+	canonical, ok = k.canon[strings.ToLower(s)]
+	if !ok {
+		return 0, "", false
+	}
+	return k.types[canonical], canonical, true
+}
+
+// EmitKeyword emits the pending input as a keyword token if it is one
+// of the keywords in k, and as a token of type t otherwise.
+// It returns whether a keyword was emitted.
+func (l *Lexer) EmitKeyword(k *Keywords, t Type) bool {
+	kt, canonical, ok := k.Lookup(l.Value())
+	if !ok {
+		l.Emit(t)
+		return false
+	}
+	if k.Canonicalize {
+		l.EmitMapped(kt, func(string) string { return canonical })
+	} else {
+		l.Emit(kt)
+	}
+	return true
+}
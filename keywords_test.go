@@ -0,0 +1,54 @@
+// Copyright (c) 2015, Ben Morgan. All rights reserved.
+// Use of this source code is governed by an MIT license
+// that can be found in the LICENSE file.
+
+package lex_test
+
+import (
+	"testing"
+	"unicode"
+
+	"github.com/goulash/lex"
+)
+
+const (
+	typeSelect lex.Type = 20 + iota
+	typeFrom
+)
+
+func TestEmitKeyword(t *testing.T) {
+	tests := []struct {
+		fold, canon bool
+		want        string
+	}{
+		{false, false, `2:"select" 20:"SELECT" 2:"From" 21:"FROM" 1:""`},
+		{true, false, `20:"select" 20:"SELECT" 21:"From" 21:"FROM" 1:""`},
+		{true, true, `20:"SELECT" 20:"SELECT" 21:"FROM" 21:"FROM" 1:""`},
+		{false, true, `2:"select" 20:"SELECT" 2:"From" 21:"FROM" 1:""`},
+	}
+	for _, tt := range tests {
+		k := lex.NewKeywords(map[string]lex.Type{"SELECT": typeSelect, "FROM": typeFrom})
+		k.FoldCase, k.Canonicalize = tt.fold, tt.canon
+		keywords := 0
+		l := lex.Lex("a", "select SELECT From FROM", func(l *lex.Lexer) lex.StateFn {
+			for l.AcceptFuncRun(unicode.IsLetter) > 0 {
+				if l.EmitKeyword(k, typeWord) {
+					keywords++
+				}
+				l.AcceptRun(" ")
+				l.Ignore()
+			}
+			return nil
+		})
+		toks := lex.Collect(l)
+		if got := describeTypes(toks); got != tt.want {
+			t.Errorf("fold %t, canonicalize %t:\ngot  %s\nwant %s", tt.fold, tt.canon, got, tt.want)
+		}
+		if toks[0].Raw != "select" {
+			t.Errorf("got raw %q, want select", toks[0].Raw)
+		}
+		if want := 2 + 2*boolInt(tt.fold); keywords != want {
+			t.Errorf("EmitKeyword reported %d keywords, want %d", keywords, want)
+		}
+	}
+}
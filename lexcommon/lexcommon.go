@@ -0,0 +1,89 @@
+// Copyright (c) 2015, Ben Morgan. All rights reserved.
+// Use of this source code is governed by an MIT license
+// that can be found in the LICENSE file.
+
+// Package lexcommon provides token types and scanning functions for
+// syntax commonly found in C-like languages.
+//
+// The state function Lex lexes a complete C-like token stream and serves
+// as a starting point for a new lexer:
+//
+//	l := lex.Lex("input", input, lexcommon.Lex)
+//
+// To customize it, copy Lex and modify it: the Scan functions it uses
+// are exported and can be combined freely.
+package lexcommon
+
+import "github.com/goulash/lex"
+
+// The token types emitted by Lex. Your own types can continue where
+// lexcommon left off:
+//
+//	const (
+//	    TypeKeyword = (1+lexcommon.TypePunct)+iota
+//	    ...
+//	)
+const (
+	TypeSpace   lex.Type = (1 + lex.TypeEOF) + iota // spaces and tabs
+	TypeNewline                                     // run of line endings
+	TypeComment                                     // line comment
+	TypeIdent                                       // identifier
+	TypeInt                                         // integer literal
+	TypeFloat                                       // floating-point literal
+	TypeString                                      // double-quoted string literal
+	TypeChar                                        // single-quoted character literal
+	TypePunct                                       // any other single rune
+)
+
+// Lex is a state function lexing C-like syntax with the token types
+// of this package, using // for line comments.
+func Lex(l *lex.Lexer) lex.StateFn {
+	for {
+		r := l.Peek()
+		switch {
+		case r < 0:
+			l.Emit(lex.TypeEOF)
+			return nil
+		case lex.IsSpace(r):
+			l.AcceptRun(lex.Space)
+			l.Emit(TypeSpace)
+		case lex.IsEndline(r):
+			l.AcceptRun(lex.Endline)
+			l.Emit(TypeNewline)
+		case l.HasPrefix("//"):
+			ScanLineComment(l, "//")
+			l.Emit(TypeComment)
+		case r == '"' || r == '\'':
+			if !ScanString(l, r) {
+				return l.Errorf("unterminated string literal")
+			}
+			if r == '"' {
+				l.Emit(TypeString)
+			} else {
+				l.Emit(TypeChar)
+			}
+		case IsDigit(r) || (r == '.' && IsDigit(peekAfter(l))):
+			t, ok := ScanNumber(l)
+			if !ok {
+				return l.Errorf("malformed number: %q", l.Value())
+			}
+			l.Emit(t)
+		case IsIdentStart(r):
+			ScanIdent(l)
+			l.Emit(TypeIdent)
+		default:
+			l.Next()
+			l.Emit(TypePunct)
+		}
+	}
+}
+
+// peekAfter returns the rune after the next one without consuming it.
+// It reads more of a stream if necessary, unlike Lexer.Input.
+func peekAfter(l *lex.Lexer) rune {
+	start := l.Pos()
+	l.Next()
+	r := l.Peek()
+	l.Dec(l.Pos() - start)
+	return r
+}
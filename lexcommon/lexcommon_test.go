@@ -0,0 +1,89 @@
+// Copyright (c) 2015, Ben Morgan. All rights reserved.
+// Use of this source code is governed by an MIT license
+// that can be found in the LICENSE file.
+
+package lexcommon
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/goulash/lex"
+)
+
+// describe returns the types and values of the tokens of input.
+func describe(input string) string {
+	var s []string
+	for _, t := range lex.Collect(lex.Lex("f", input, Lex)) {
+		s = append(s, fmt.Sprintf("%d:%q", t.Type, t.Value))
+	}
+	return strings.Join(s, " ")
+}
+
+func TestLex(t *testing.T) {
+	tests := []struct {
+		input, want string
+	}{
+		{"x_1 = .5+0x1F; // done\n\n",
+			`5:"x_1" 2:" " 10:"=" 2:" " 7:".5" 10:"+" 6:"0x1F" 10:";" 2:" " 4:"// done" 3:"\n\n" 1:""`},
+		{`s("a\"b", 'c')`,
+			`5:"s" 10:"(" 8:"\"a\\\"b\"" 10:"," 2:" " 9:"'c'" 10:")" 1:""`},
+		{`"open`, `0:"unterminated string literal"`},
+		{"1e", `0:"malformed number: \"1e\""`},
+		{"", `1:""`},
+	}
+	for _, tt := range tests {
+		if got := describe(tt.input); got != tt.want {
+			t.Errorf("%q:\ngot  %s\nwant %s", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestLexStream(t *testing.T) {
+	// The tokens do not depend on where the chunks of a stream end.
+	for _, input := range []string{"x = .5+a.b->c; // c\n", `"a\"b" 'c' 1.5e3`} {
+		want := describe(input)
+		for i := range input {
+			r := io.MultiReader(strings.NewReader(input[:i]), strings.NewReader(input[i:]))
+			var s []string
+			for _, t := range lex.Collect(runStream(lex.NewStream("f", r))) {
+				s = append(s, fmt.Sprintf("%d:%q", t.Type, t.Value))
+			}
+			if got := strings.Join(s, " "); got != want {
+				t.Errorf("%q split at %d:\ngot  %s\nwant %s", input, i, got, want)
+			}
+		}
+	}
+}
+
+// runStream runs Lex on l and returns l.
+func runStream(l *lex.Lexer) *lex.Lexer {
+	go l.Run(Lex)
+	return l
+}
+
+func TestPeekAfterStream(t *testing.T) {
+	var got []rune
+	l := lex.NewStream("f", io.MultiReader(strings.NewReader("a."), strings.NewReader("5")))
+	l.RunSink(func(l *lex.Lexer) lex.StateFn {
+		l.Next()
+		got = append(got, peekAfter(l), l.Next())
+		return nil
+	}, func(lex.Token) bool { return true })
+	if string(got) != "5." {
+		t.Errorf("got %q, want the rune after . read from the stream", string(got))
+	}
+}
+
+func FuzzLex(f *testing.F) {
+	for _, input := range []string{"x = 1.5e3; // c\n", `"a\"b" 'c'`, "0x1_F 0b1 .5"} {
+		f.Add(input)
+	}
+	f.Fuzz(func(t *testing.T, input string) {
+		if err := lex.Verify(input, lex.Collect(lex.Lex("fuzz", input, Lex))); err != nil {
+			t.Fatal(err)
+		}
+	})
+}
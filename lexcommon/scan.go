@@ -0,0 +1,187 @@
+// Copyright (c) 2015, Ben Morgan. All rights reserved.
+// Use of this source code is governed by an MIT license
+// that can be found in the LICENSE file.
+
+package lexcommon
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/goulash/lex"
+)
+
+const (
+	Digits    = "0123456789"
+	HexDigits = "0123456789abcdefABCDEF"
+	OctDigits = "01234567"
+	BinDigits = "01"
+)
+
+func IsDigit(r rune) bool {
+	return r >= '0' && r <= '9'
+}
+
+func IsIdentStart(r rune) bool {
+	return r == '_' || unicode.IsLetter(r)
+}
+
+// ScanIdent consumes an identifier, which starts with a letter or
+// underscore, followed by letters, digits, and underscores.
+// It returns whether an identifier was consumed.
+func ScanIdent(l *lex.Lexer) bool {
+	if !l.AcceptFunc(IsIdentStart) {
+		return false
+	}
+	l.AcceptFuncRun(lex.IsAlphaNumeric)
+	return true
+}
+
+// ScanNumber consumes an integer or floating-point literal and returns
+// its type, which is either TypeInt or TypeFloat.
+//
+// Integers are decimal, hexadecimal with prefix 0x, octal with prefix
+// 0o, or binary with prefix 0b. Floats are decimal with a fraction,
+// an exponent, or both, such as 1.5, .5, 1., 1e9, and 2.5E-3.
+//
+// If the literal is malformed, such as 0x without digits or an exponent
+// without digits, false is returned and the input consumed so far
+// remains pending. ScanNumber does not accept digit separators, see
+// ScanNumberDialect.
+func ScanNumber(l *lex.Lexer) (lex.Type, bool) {
+	t, err := ScanNumberDialect(l, NumberDialect{})
+	return t, err == nil
+}
+
+// A NumberDialect configures the number literals accepted by
+// ScanNumberDialect.
+type NumberDialect struct {
+	// Underscores allows underscores between digits and directly
+	// after a base prefix, such as in 1_000_000 and 0x_ff.
+	Underscores bool
+}
+
+// GoNumbers is the dialect of Go number literals, apart from imaginary
+// and hexadecimal floating-point literals.
+var GoNumbers = NumberDialect{Underscores: true}
+
+// ScanNumberDialect is like ScanNumber, but accepts literals of dialect d,
+// and returns an error describing the problem if the literal is malformed,
+// such as an underscore that does not separate digits.
+func ScanNumberDialect(l *lex.Lexer, d NumberDialect) (lex.Type, error) {
+	digits := false
+	if l.Accept("0") {
+		switch {
+		case l.Accept("xX"):
+			return TypeInt, d.digits(l, HexDigits, true, "hexadecimal literal")
+		case l.Accept("oO"):
+			return TypeInt, d.digits(l, OctDigits, true, "octal literal")
+		case l.Accept("bB"):
+			return TypeInt, d.digits(l, BinDigits, true, "binary literal")
+		}
+		digits = true
+		if err := d.digits(l, Digits, true, ""); err != nil && err != errNoDigits {
+			return TypeInt, err
+		}
+	} else if err := d.digits(l, Digits, false, ""); err == nil {
+		digits = true
+	} else if err != errNoDigits {
+		return TypeInt, err
+	}
+	t := TypeInt
+	if l.Accept(".") {
+		t = TypeFloat
+		if err := d.digits(l, Digits, false, ""); err == nil {
+			digits = true
+		} else if err != errNoDigits {
+			return t, err
+		}
+	}
+	if !digits {
+		return t, errors.New("number literal has no digits")
+	}
+	if l.Accept("eE") {
+		t = TypeFloat
+		l.Accept("+-")
+		if err := d.digits(l, Digits, false, "exponent"); err != nil {
+			return t, err
+		}
+	}
+	return t, nil
+}
+
+var (
+	errNoDigits  = errors.New("no digits")
+	errSeparator = errors.New("'_' must separate successive digits")
+)
+
+// digits consumes a run of digits in set, which may be separated by
+// underscores in dialect d. If prefixed, the run follows a base prefix or
+// digit, so that it may start with an underscore. An empty run is an
+// error, which mentions what if it is not empty, and errNoDigits otherwise.
+func (d NumberDialect) digits(l *lex.Lexer, set string, prefixed bool, what string) error {
+	n, sep, under := 0, !prefixed, false
+	var err error
+	for {
+		r := l.Peek()
+		switch {
+		case r >= 0 && strings.ContainsRune(set, r):
+			n, sep = n+1, false
+		case r == '_' && d.Underscores:
+			if sep && err == nil {
+				err = errSeparator
+			}
+			sep, under = true, true
+		default:
+			if n == 0 && under {
+				return errSeparator
+			}
+			if n == 0 {
+				if what != "" {
+					return fmt.Errorf("%s has no digits", what)
+				}
+				return errNoDigits
+			}
+			if sep && err == nil {
+				err = errSeparator
+			}
+			return err
+		}
+		l.Next()
+	}
+}
+
+// ScanString consumes a string literal delimited by quote, in which
+// a backslash escapes the following rune. It returns false if the
+// literal is not terminated before the end of the line or input.
+func ScanString(l *lex.Lexer, quote rune) bool {
+	if !l.Accept(string(quote)) {
+		return false
+	}
+	for {
+		switch r := l.Next(); {
+		case r == quote:
+			return true
+		case r == '\\':
+			if r := l.Next(); r < 0 || lex.IsEndline(r) {
+				return false
+			}
+		case r < 0 || lex.IsEndline(r):
+			l.Backup()
+			return false
+		}
+	}
+}
+
+// ScanLineComment consumes a comment starting with prefix and extending
+// to the end of the line, excluding the line ending.
+// It returns whether a comment was consumed.
+func ScanLineComment(l *lex.Lexer, prefix string) bool {
+	if !l.Consume(prefix) {
+		return false
+	}
+	l.AcceptButRun(lex.Endline)
+	return true
+}
@@ -0,0 +1,111 @@
+// Copyright (c) 2015, Ben Morgan. All rights reserved.
+// Use of this source code is governed by an MIT license
+// that can be found in the LICENSE file.
+
+package lexcommon
+
+import (
+	"testing"
+	"time"
+
+	"github.com/goulash/lex"
+)
+
+// scanNumber runs ScanNumberDialect on input and returns the type, the
+// input consumed, and the error.
+func scanNumber(input string, d NumberDialect) (t lex.Type, consumed string, err error) {
+	l := lex.Lex("f", input, func(l *lex.Lexer) lex.StateFn {
+		t, err = ScanNumberDialect(l, d)
+		consumed = l.Value()
+		return nil
+	})
+	lex.Collect(l)
+	return t, consumed, err
+}
+
+func TestScanNumber(t *testing.T) {
+	tests := []struct {
+		input    string
+		typ      lex.Type
+		consumed string
+	}{
+		{"0", TypeInt, "0"},
+		{"123+", TypeInt, "123"},
+		{"0x1f", TypeInt, "0x1f"},
+		{"0o17", TypeInt, "0o17"},
+		{"0b101", TypeInt, "0b101"},
+		{"1.5", TypeFloat, "1.5"},
+		{".5", TypeFloat, ".5"},
+		{"1.", TypeFloat, "1."},
+		{"1e9", TypeFloat, "1e9"},
+		{"2.5E-3", TypeFloat, "2.5E-3"},
+		{"-1", TypeInt, "-1"},
+		{"+.5", TypeFloat, "+.5"},
+	}
+	for _, tt := range tests {
+		l := lex.Lex("f", tt.input, func(l *lex.Lexer) lex.StateFn {
+			l.Accept("+-")
+			typ, ok := ScanNumber(l)
+			if !ok || typ != tt.typ || l.Value() != tt.consumed {
+				t.Errorf("%q: got %v, %v, %q", tt.input, typ, ok, l.Value())
+			}
+			return nil
+		})
+		lex.Collect(l)
+	}
+}
+
+func TestScanNumberInvalid(t *testing.T) {
+	for _, input := range []string{"", "-", "-.", "-e5", ".", ".e5", "0x", "1e", "1e+"} {
+		l := lex.Lex("f", input, func(l *lex.Lexer) lex.StateFn {
+			l.Accept("+-")
+			if typ, ok := ScanNumber(l); ok {
+				t.Errorf("%q: got %v, want no number", input, typ)
+			}
+			return nil
+		})
+		lex.Collect(l)
+	}
+}
+
+func TestScanNumberDialect(t *testing.T) {
+	tests := []struct {
+		input string
+		ok    bool
+	}{
+		{"1_000_000", true},
+		{"0x_ff", true},
+		{"0_7", true},
+		{"1__0", false},
+		{"1_", false},
+		{"_1", false},
+		{"0x_", false},
+		{"1.5_0", true},
+		{"1e1_0", true},
+	}
+	for _, tt := range tests {
+		_, consumed, err := scanNumber(tt.input, GoNumbers)
+		if (err == nil) != tt.ok {
+			t.Errorf("%q: got error %v after %q", tt.input, err, consumed)
+		}
+	}
+}
+
+func TestScanStringIncremental(t *testing.T) {
+	done := make(chan bool)
+	l := lex.New("f", `"abc`, lex.WithIncremental())
+	go l.Run(func(l *lex.Lexer) lex.StateFn {
+		done <- ScanString(l, '"')
+		return nil
+	})
+	select {
+	case ok := <-done:
+		if ok {
+			t.Error("unterminated string scanned")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("ScanString does not stop at ErrMoreInput")
+	}
+	l.CloseInput()
+	l.Drain()
+}
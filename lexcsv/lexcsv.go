@@ -0,0 +1,114 @@
+// Copyright (c) 2015, Ben Morgan. All rights reserved.
+// Use of this source code is governed by an MIT license
+// that can be found in the LICENSE file.
+
+// Package lexcsv provides a lexer for CSV and TSV files.
+//
+// Each field of a record is emitted as a TypeField token, with the
+// value unquoted. The end of each record is marked by a TypeRecordEnd
+// token, with the line ending as its value:
+//
+//	l := lex.Lex("data.csv", input, lexcsv.CSV.StateFn())
+//
+// Fields may be quoted, in which case they can contain delimiters,
+// line endings, and quotes, which are escaped by doubling them.
+// Empty lines are skipped.
+package lexcsv
+
+import (
+	"strings"
+
+	"github.com/goulash/lex"
+)
+
+const (
+	TypeField     lex.Type = (1 + lex.TypeEOF) + iota // field, unquoted
+	TypeRecordEnd                                     // end of a record
+)
+
+// A Dialect describes a variant of the CSV format.
+type Dialect struct {
+	Delimiter rune // separates fields
+	Quote     rune // quotes fields
+}
+
+var (
+	CSV = Dialect{Delimiter: ',', Quote: '"'}
+	TSV = Dialect{Delimiter: '\t', Quote: '"'}
+)
+
+// StateFn returns the state function for lexing input in dialect d.
+func (d Dialect) StateFn() lex.StateFn {
+	lx := &lexer{
+		Dialect: d,
+		stop:    string(d.Delimiter) + lex.Endline,
+		quote:   string(d.Quote),
+	}
+	return lx.lexRecord
+}
+
+type lexer struct {
+	Dialect
+	stop  string // runes that end an unquoted field
+	quote string
+}
+
+func (lx *lexer) lexRecord(l *lex.Lexer) lex.StateFn {
+	if l.AcceptRun(lex.Endline) > 0 {
+		l.Ignore()
+	}
+	if l.Peek() < 0 {
+		l.Emit(lex.TypeEOF)
+		return nil
+	}
+	return lx.lexField
+}
+
+func (lx *lexer) lexField(l *lex.Lexer) lex.StateFn {
+	if l.Peek() == lx.Quote {
+		return lx.lexQuoted
+	}
+	l.AcceptButRun(lx.stop)
+	l.Emit(TypeField)
+	return lx.lexSeparator
+}
+
+func (lx *lexer) lexQuoted(l *lex.Lexer) lex.StateFn {
+	l.Next()
+	for {
+		switch r := l.Next(); {
+		case r == lx.Quote:
+			if l.Accept(lx.quote) {
+				continue
+			}
+			l.EmitMapped(TypeField, lx.unquote)
+			return lx.lexSeparator
+		case r < 0:
+			return l.Errorf("unterminated quoted field")
+		}
+	}
+}
+
+// lexSeparator lexes what follows a field.
+func (lx *lexer) lexSeparator(l *lex.Lexer) lex.StateFn {
+	switch r := l.Next(); {
+	case r == lx.Delimiter:
+		l.Ignore()
+		return lx.lexField
+	case r == '\r':
+		l.Accept("\n")
+		l.Emit(TypeRecordEnd)
+	case r == '\n' || r < 0:
+		l.Emit(TypeRecordEnd)
+	default:
+		return l.Errorf("unexpected %q after quoted field", r)
+	}
+	return lx.lexRecord
+}
+
+// unquote removes the surrounding quotes of a field and unescapes
+// doubled quotes.
+func (lx *lexer) unquote(s string) string {
+	s = s[len(lx.quote) : len(s)-len(lx.quote)]
+	return strings.Replace(s, lx.quote+lx.quote, lx.quote, -1)
+}
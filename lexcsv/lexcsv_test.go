@@ -0,0 +1,53 @@
+// Copyright (c) 2015, Ben Morgan. All rights reserved.
+// Use of this source code is governed by an MIT license
+// that can be found in the LICENSE file.
+
+package lexcsv
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/goulash/lex"
+)
+
+// describe returns the fields of the records in input, with records
+// separated by |, or the error of the lexer.
+func describe(d Dialect, input string) string {
+	var b strings.Builder
+	for _, t := range lex.Collect(lex.Lex("f", input, d.StateFn())) {
+		switch t.Type {
+		case TypeField:
+			fmt.Fprintf(&b, "%q ", t.Value)
+		case TypeRecordEnd:
+			fmt.Fprintf(&b, "| ")
+		case lex.TypeError:
+			fmt.Fprintf(&b, "error %s", t.Value)
+		}
+	}
+	return strings.TrimSpace(b.String())
+}
+
+func TestCSV(t *testing.T) {
+	tests := []struct {
+		input, want string
+	}{
+		{"a,b\r\n\r\n,c\n", `"a" "b" | "" "c" |`},
+		{`"a,""b""",` + "\"x\ny\"\nlast", `"a,\"b\"" "x\ny" | "last" |`},
+		{`"a"b`, `"a" error unexpected 'b' after quoted field`},
+		{`"open`, `error unterminated quoted field`},
+		{"", ``},
+	}
+	for _, tt := range tests {
+		if got := describe(CSV, tt.input); got != tt.want {
+			t.Errorf("%q:\ngot  %s\nwant %s", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestTSV(t *testing.T) {
+	if got, want := describe(TSV, "a,b\t\"c\td\"\n"), `"a,b" "c\td" |`; got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
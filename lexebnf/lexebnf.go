@@ -0,0 +1,439 @@
+// Copyright (c) 2015, Ben Morgan. All rights reserved.
+// Use of this source code is governed by an MIT license
+// that can be found in the LICENSE file.
+
+// Package lexebnf builds lexers at runtime from terminal definitions
+// written in a subset of EBNF, which is useful for prototypes:
+//
+//	g, err := lexebnf.Parse("tokens", `
+//	    ident  = letter {letter | digit}
+//	    number = digit {digit} ["." digit {digit}]
+//	    space  = " " {" "}
+//	`)
+//	...
+//	b := lex.NewBuilder()
+//	b.Skip(g.Matcher("space"))
+//	err = g.Configure(b, map[string]lex.Type{"ident": TypeIdent, "number": TypeNumber})
+//	l := lex.Lex(name, input, b.StateFn())
+//
+// A production is a name followed by = and an expression, optionally
+// terminated by a period. Expressions are built from:
+//
+//	"abc" `abc`   literal string
+//	"a" … "z"     range of runes, also written "a" ... "z"
+//	name          another production
+//	a b           sequence
+//	a | b         alternatives, the first that matches wins
+//	[a]           optional
+//	{a}           zero or more repetitions
+//	(a)           grouping
+//
+// The productions letter, matching a Unicode letter or underscore, and
+// digit, matching a decimal digit, are predefined unless redefined.
+// Matching does not backtrack into alternatives once one has matched.
+// Left-recursive productions are not supported, and Parse returns an
+// error for them.
+package lexebnf
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/goulash/lex"
+)
+
+const (
+	typeIdent lex.Type = (1 + lex.TypeEOF) + iota
+	typeString
+	typeEllipsis
+	typePunct
+)
+
+// A Grammar is a set of parsed productions.
+type Grammar struct {
+	names    []string
+	matchers map[string]lex.Matcher
+}
+
+// Parse parses the productions in src, which is read from the file name.
+// Errors are returned as *lex.Error.
+func Parse(name, src string) (*Grammar, error) {
+	rec := lex.Record(lex.Lex(name, src, lexEBNF))
+	p := &parser{r: rec.Reader(), refs: make(map[string]lex.Token), nodes: make(map[string]*node)}
+	g := &Grammar{matchers: map[string]lex.Matcher{
+		"letter": lex.MatchFunc(func(r rune) bool { return r == '_' || unicode.IsLetter(r) }),
+		"digit":  lex.MatchFunc(func(r rune) bool { return '0' <= r && r <= '9' }),
+	}}
+	p.g = g
+	defined := make(map[string]bool)
+	for !p.r.At(lex.TypeEOF) {
+		t := p.r.Next()
+		if t.Type == lex.TypeError {
+			return nil, p.errorf(t, "%s", t.Value)
+		}
+		if t.Type != typeIdent {
+			return nil, p.errorf(t, "expected production name, got %s", desc(t))
+		}
+		if defined[t.Value] {
+			return nil, p.errorf(t, "production %s redefined", t.Value)
+		}
+		if _, err := p.expect("="); err != nil {
+			return nil, err
+		}
+		m, n, err := p.alternatives()
+		if err != nil {
+			return nil, err
+		}
+		if t := p.r.Peek(); t.Type == typePunct {
+			if t.Value != "." {
+				return nil, p.errorf(t, "unexpected %s", desc(t))
+			}
+			p.r.Next()
+		}
+		defined[t.Value] = true
+		g.names = append(g.names, t.Value)
+		g.matchers[t.Value] = m
+		p.nodes[t.Value] = n
+	}
+	for name, t := range p.refs {
+		if g.matchers[name] == nil {
+			return nil, p.errorf(t, "undefined production %s", name)
+		}
+	}
+	if err := p.checkLeftRecursion(g.names); err != nil {
+		return nil, err
+	}
+	return g, nil
+}
+
+// Names returns the names of the productions defined in the source,
+// in order.
+func (g *Grammar) Names() []string {
+	return g.names
+}
+
+// Matcher returns a matcher for the production name, or nil if there
+// is no such production.
+func (g *Grammar) Matcher(name string) lex.Matcher {
+	return g.matchers[name]
+}
+
+// Configure adds a rule to b for every production that has a type in
+// types, in the order the productions are defined.
+func (g *Grammar) Configure(b *lex.Builder, types map[string]lex.Type) error {
+	for name := range types {
+		if g.matchers[name] == nil {
+			return fmt.Errorf("lexebnf: undefined production %s", name)
+		}
+	}
+	for _, name := range g.names {
+		if t, ok := types[name]; ok {
+			b.Rule(t, g.matchers[name])
+		}
+	}
+	return nil
+}
+
+type parser struct {
+	r     *lex.Reader
+	g     *Grammar
+	refs  map[string]lex.Token // first reference to each production
+	nodes map[string]*node     // expression of each production
+}
+
+// A node describes the structure of an expression, which is needed to
+// find left-recursive productions.
+type node struct {
+	ref   *lex.Token // reference to another production
+	empty bool       // matches the empty string, such as [a] or {a}
+	seq   []*node    // sequence, or the expression of [a] or {a}
+	alts  []*node    // alternatives
+}
+
+func (p *parser) errorf(t lex.Token, format string, args ...interface{}) error {
+	file, line, col := p.r.PosOf(t)
+	return &lex.Error{File: file, Line: line, Col: col, Msg: fmt.Sprintf(format, args...)}
+}
+
+func (p *parser) expect(punct string) (lex.Token, error) {
+	t := p.r.Next()
+	if t.Type != typePunct || t.Value != punct {
+		return t, p.errorf(t, "expected %s, got %s", punct, desc(t))
+	}
+	return t, nil
+}
+
+// atEnd reports whether the next token ends a sequence.
+func (p *parser) atEnd() bool {
+	t := p.r.Peek()
+	switch t.Type {
+	case lex.TypeEOF, lex.TypeError:
+		return true
+	case typePunct:
+		return strings.Contains("|)]}.", t.Value)
+	case typeIdent:
+		// The name of the next production.
+		p.r.Next()
+		next := p.r.Peek()
+		p.r.Backup(t)
+		return next.Type == typePunct && next.Value == "="
+	}
+	return false
+}
+
+func (p *parser) alternatives() (lex.Matcher, *node, error) {
+	var alts []lex.Matcher
+	var nodes []*node
+	for {
+		m, n, err := p.sequence()
+		if err != nil {
+			return nil, nil, err
+		}
+		alts = append(alts, m)
+		nodes = append(nodes, n)
+		if t := p.r.Peek(); t.Type != typePunct || t.Value != "|" {
+			break
+		}
+		p.r.Next()
+	}
+	if len(alts) == 1 {
+		return alts[0], nodes[0], nil
+	}
+	return func(l *lex.Lexer) bool {
+		for _, m := range alts {
+			if l.AcceptSeq(m) {
+				return true
+			}
+		}
+		return false
+	}, &node{alts: nodes}, nil
+}
+
+func (p *parser) sequence() (lex.Matcher, *node, error) {
+	var seq []lex.Matcher
+	var nodes []*node
+	for !p.atEnd() {
+		m, n, err := p.term()
+		if err != nil {
+			return nil, nil, err
+		}
+		seq = append(seq, m)
+		nodes = append(nodes, n)
+	}
+	if len(seq) == 0 {
+		return nil, nil, p.errorf(p.r.Peek(), "expected expression, got %s", desc(p.r.Peek()))
+	}
+	if len(seq) == 1 {
+		return seq[0], nodes[0], nil
+	}
+	return func(l *lex.Lexer) bool { return l.AcceptSeq(seq...) }, &node{seq: nodes}, nil
+}
+
+func (p *parser) term() (lex.Matcher, *node, error) {
+	t := p.r.Next()
+	switch t.Type {
+	case typeIdent:
+		if _, ok := p.refs[t.Value]; !ok {
+			p.refs[t.Value] = t
+		}
+		name, g := t.Value, p.g
+		return func(l *lex.Lexer) bool { return g.matchers[name](l) }, &node{ref: &t}, nil
+	case typeString:
+		if _, ok := p.r.Accept(typeEllipsis); !ok {
+			return lex.MatchString(t.Value), &node{empty: t.Value == ""}, nil
+		}
+		hi := p.r.Next()
+		if hi.Type != typeString || utf8.RuneCountInString(t.Value) != 1 || utf8.RuneCountInString(hi.Value) != 1 {
+			return nil, nil, p.errorf(t, "range bounds must be single characters")
+		}
+		lo, _ := utf8.DecodeRuneInString(t.Value)
+		up, _ := utf8.DecodeRuneInString(hi.Value)
+		if lo > up {
+			return nil, nil, p.errorf(t, "empty range %q … %q", lo, up)
+		}
+		return lex.MatchFunc(func(r rune) bool { return lo <= r && r <= up }), &node{}, nil
+	case typePunct:
+		var closing string
+		switch t.Value {
+		case "(":
+			closing = ")"
+		case "[":
+			closing = "]"
+		case "{":
+			closing = "}"
+		default:
+			return nil, nil, p.errorf(t, "unexpected %s", t.Value)
+		}
+		m, n, err := p.alternatives()
+		if err != nil {
+			return nil, nil, err
+		}
+		if _, err := p.expect(closing); err != nil {
+			return nil, nil, err
+		}
+		switch closing {
+		case "]":
+			return lex.MatchOptional(m), &node{empty: true, seq: []*node{n}}, nil
+		case "}":
+			return func(l *lex.Lexer) bool {
+				for pos := l.Pos(); l.AcceptSeq(m) && l.Pos() > pos; pos = l.Pos() {
+				}
+				return true
+			}, &node{empty: true, seq: []*node{n}}, nil
+		}
+		return m, n, nil
+	}
+	return nil, nil, p.errorf(t, "unexpected %s", desc(t))
+}
+
+// checkLeftRecursion returns an error if one of the productions names
+// can reference itself without consuming input, which would recurse
+// until the stack overflows when matching.
+func (p *parser) checkLeftRecursion(names []string) error {
+	// Find the productions that can match the empty string.
+	empty := make(map[string]bool)
+	for changed := true; changed; {
+		changed = false
+		for _, name := range names {
+			if !empty[name] && p.nullable(p.nodes[name], empty) {
+				empty[name], changed = true, true
+			}
+		}
+	}
+	// Follow the references that can be reached without consuming input.
+	const (
+		visiting = 1
+		done     = 2
+	)
+	state := make(map[string]int)
+	var path []string
+	var visit func(name string) error
+	visit = func(name string) error {
+		state[name] = visiting
+		path = append(path, name)
+		for _, t := range p.leftRefs(p.nodes[name], empty, nil) {
+			switch state[t.Value] {
+			case visiting:
+				i := 0
+				for path[i] != t.Value {
+					i++
+				}
+				cycle := append(path[i:len(path):len(path)], t.Value)
+				return p.errorf(t, "left-recursive production %s: %s", t.Value, strings.Join(cycle, " -> "))
+			case 0:
+				if err := visit(t.Value); err != nil {
+					return err
+				}
+			}
+		}
+		path = path[:len(path)-1]
+		state[name] = done
+		return nil
+	}
+	for _, name := range names {
+		if state[name] == 0 {
+			if err := visit(name); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// nullable reports whether n can match the empty string, given the
+// productions known to match it.
+func (p *parser) nullable(n *node, empty map[string]bool) bool {
+	switch {
+	case n.empty:
+		return true
+	case n.ref != nil:
+		return empty[n.ref.Value]
+	case len(n.alts) > 0:
+		for _, a := range n.alts {
+			if p.nullable(a, empty) {
+				return true
+			}
+		}
+		return false
+	case len(n.seq) > 0:
+		for _, s := range n.seq {
+			if !p.nullable(s, empty) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// leftRefs appends to refs the references to defined productions that
+// n can match before consuming input.
+func (p *parser) leftRefs(n *node, empty map[string]bool, refs []lex.Token) []lex.Token {
+	switch {
+	case n.ref != nil:
+		if p.nodes[n.ref.Value] != nil {
+			refs = append(refs, *n.ref)
+		}
+	case len(n.alts) > 0:
+		for _, a := range n.alts {
+			refs = p.leftRefs(a, empty, refs)
+		}
+	default:
+		for _, s := range n.seq {
+			refs = p.leftRefs(s, empty, refs)
+			if !p.nullable(s, empty) {
+				break
+			}
+		}
+	}
+	return refs
+}
+
+// desc describes t for error messages.
+func desc(t lex.Token) string {
+	if t.Type == lex.TypeEOF {
+		return "end of input"
+	}
+	return strconv.Quote(t.Value)
+}
+
+// lexEBNF is the state function for lexing productions.
+func lexEBNF(l *lex.Lexer) lex.StateFn {
+	for {
+		l.AcceptRun(lex.Space + lex.Endline)
+		l.Ignore()
+		switch r := l.Peek(); {
+		case r < 0:
+			l.Emit(lex.TypeEOF)
+			return nil
+		case r == '_' || unicode.IsLetter(r):
+			l.AcceptFuncRun(lex.IsAlphaNumeric)
+			l.Emit(typeIdent)
+		case r == '"' || r == '`':
+			l.Next()
+			for c := l.Next(); c != r; c = l.Next() {
+				if c == '\\' && r == '"' {
+					l.Next()
+				} else if c < 0 || lex.IsEndline(c) {
+					return l.Errorf("unterminated string")
+				}
+			}
+			s, err := strconv.Unquote(l.Value())
+			if err != nil {
+				return l.Errorf("invalid string %s", l.Value())
+			}
+			l.EmitMapped(typeString, func(string) string { return s })
+		case l.Consume("…") || l.Consume("..."):
+			l.Emit(typeEllipsis)
+		case strings.ContainsRune("=|()[]{}.", r):
+			l.Next()
+			l.Emit(typePunct)
+		default:
+			l.Next()
+			return l.Errorf("unexpected %q", r)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+// Copyright (c) 2015, Ben Morgan. All rights reserved.
+// Use of this source code is governed by an MIT license
+// that can be found in the LICENSE file.
+
+package lexebnf_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/goulash/lex"
+	"github.com/goulash/lex/lexebnf"
+)
+
+const (
+	typeIdent lex.Type = (1 + lex.TypeEOF) + iota
+	typeNumber
+	typeParens
+)
+
+func TestParse(t *testing.T) {
+	g, err := lexebnf.Parse("tokens", `
+		ident  = letter {letter | digit}
+		number = digit {digit} ["." digit {digit}]
+		parens = "(" [parens] ")"
+		space  = " " {" "}
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := lex.NewBuilder()
+	b.Skip(g.Matcher("space"))
+	types := map[string]lex.Type{"ident": typeIdent, "number": typeNumber, "parens": typeParens}
+	if err := g.Configure(b, types); err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, tok := range lex.Collect(lex.Lex("f", "x1 3.14 ((()))", b.StateFn())) {
+		got = append(got, tok.Value)
+	}
+	if want := []string{"x1", "3.14", "((()))", ""}; strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestParseLeftRecursion(t *testing.T) {
+	tests := []struct {
+		src, cycle string
+	}{
+		{`a = a "x"`, "a -> a"},
+		{`a = "x" | a "y"`, "a -> a"},
+		{`a = b "x"
+		  b = "y" | a`, "a -> b -> a"},
+		{`a = ["x"] {"y"} (a)`, "a -> a"},
+		{`a = b a
+		  b = [ "x" ]`, "a -> a"},
+		{`a = "x" b
+		  b = c
+		  c = {b}`, "b -> c -> b"},
+	}
+	for _, tt := range tests {
+		_, err := lexebnf.Parse("f", tt.src)
+		if err == nil || !strings.Contains(err.Error(), "left-recursive") || !strings.Contains(err.Error(), tt.cycle) {
+			t.Errorf("%s: got error %v, want cycle %s", tt.src, err, tt.cycle)
+		}
+	}
+}
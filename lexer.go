@@ -42,8 +42,11 @@
 package lex
 
 import (
+	"context"
 	"fmt"
+	"io"
 	"strings"
+	"time"
 	"unicode/utf8"
 )
 
@@ -69,10 +72,27 @@ const (
 	TypeEOF               // end-of-file, last reserved type
 )
 
+// A Token is a lexical item emitted by the Lexer.
 type Token struct {
 	Type
 	Pos   int
+	End   int // offset directly after the token in the input
 	Value string
+
+	// Raw is the part of the input the token was read from.
+	// It only differs from Value when the token was emitted by EmitMapped.
+	Raw string
+
+	// Data is a payload attached by EmitWith, such as the parsed value
+	// of a number literal.
+	Data interface{}
+
+	// Origin is where the token was originally defined, if it was
+	// emitted from input marked with PushOrigin, such as an expanded
+	// macro.
+	Origin *Origin
+
+	pb *posBase
 }
 
 type StateFn func(*Lexer) StateFn
@@ -83,78 +103,312 @@ type Lexer struct {
 	width   int
 	base    int
 	pos     int
-	lastPos int
+	pb      *posBase
+	stack   []inputFrame
+	stream  io.Reader // source of more input, see NewStream
+	tail    string    // end of the input held back, see extendInput
+	incr    *incremental
+	ctx     context.Context
+	sink    func(Token) bool    // receives tokens instead of the channel
+	refSink func(TokenRef) bool // receives references instead, see RunRefs
+	origin  *Origin
+	last    *lastRead // last token received by the client
+	lines   []int     // line starts found so far, see LineOffsets
+	scanned int       // offset up to which lines were found
+	state   StateFn   // state function being run
+	modes   []StateFn // mode stack, see PushState
 	tokens  chan Token
+	done    chan struct{}
+	closed  bool
+	ended   bool // whether TypeEOF or TypeError was emitted
+	nested  bool // whether this is a sub-lexer, see SubLex
+	halted  bool // whether lexing was stopped by an error, see halt
+	slow    bool // whether Next must check more than the end of the input, see atEnd
+	err     error
+
+	// Options
+	bufSize      int
+	noAutoEOF    bool
+	newlines     bool
+	stripBOM     bool
+	continuation string
+	utf8Policy   InvalidUTF8Policy
+	budget       *budget
+	skipSet      string
+	validate     bool
+	strict       bool
+	timeout      time.Duration
+	deadline     time.Time
+	ticks        uint
+	shifts       []posShift
+	trace        io.Writer
+	metrics      Collector
+	interned     map[string]string
+	copyValues   bool
+	detached     *posBase // copy of the current line, see detach
+	detachedFrom *posBase
+	fold         Normalizer
+	foldTypes    map[Type]bool
+	coalesce     map[Type]bool
+	held         Token // token held back for coalescing
+	holding      bool
+	tabWidth     int
+	displayWidth bool
+	skip         map[Type]bool
+	maxTokenLen  int
+	stateHooks   []StateHook
+	emitHooks    []func(*Token)
+	wsEmit       bool
+	wsSpace      Type
+	wsNewline    Type
+	wsEnd        int // end of the last token, see WithEmitWhitespace
+
+	// Diagnostics, see WithInvisibleWarnings and WithWarnings
+	invisible     Type
+	warnInvisible bool
+	warning       Type
+	warn          bool
 }
 
 // New creates a new Lexer and returns it.
 //
 // Before calling NextToken, it should be run in a separate goroutine:
 //
-//  l := lex.New(name, input)
-//  go l.Run(sf)
-//  ...
-//  t := l.NextToken()
-func New(name, input string) *Lexer {
-	l := &Lexer{
-		name:   name,
-		input:  input,
-		tokens: make(chan Token),
+//	l := lex.New(name, input)
+//	go l.Run(sf)
+//	...
+//	t := l.NextToken()
+//
+// The behavior of the lexer can be configured with options.
+func New(name, input string, opts ...Option) *Lexer {
+	l := new(Lexer)
+	for _, opt := range opts {
+		opt(l)
 	}
+	l.tokens = make(chan Token, l.bufSize)
+	l.done = make(chan struct{})
+	l.Reset(name, input)
 	return l
 }
 
-// Lex creates a new Lexer and starts running it with sf.
-func Lex(name, input string, sf StateFn) *Lexer {
-	l := New(name, input)
+// Reset clears all state of the lexer so that it can be reused for input,
+// which avoids allocating a new Lexer for every input lexed.
+//
+// Reset must not be called while the lexer is running; the tokens of the
+// previous run must have been consumed completely, for example with Drain.
+func (l *Lexer) Reset(name, input string) {
+	if l.closed {
+		l.tokens = make(chan Token, l.bufSize)
+		l.done = make(chan struct{})
+		l.closed = false
+	}
+	var joins []int
+	input, l.shifts, joins = l.normalize(input, l.shifts[:0])
+	l.stream, l.tail = nil, ""
+	l.name = name
+	l.input = input
+	l.width, l.base, l.pos = 0, 0, 0
+	l.pb = &posBase{input: input, file: name, line: 1, joins: joins}
+	l.stack = l.stack[:0]
+	l.modes = l.modes[:0]
+	l.last = &lastRead{pb: l.pb}
+	l.ended = false
+	l.halted = false
+	l.err = nil
+	l.holding = false
+	l.skipSet = ""
+	l.origin = nil
+	l.detached, l.detachedFrom = nil, nil
+	l.lines, l.scanned = l.lines[:0], 0
+	l.wsEnd = 0
+	if l.budget != nil {
+		l.budget = &budget{limit: l.budget.limit}
+	}
+	if l.incr != nil {
+		l.incr.mu.Lock()
+		l.incr.appended, l.incr.closed, l.incr.midToken = nil, false, false
+		l.incr.mu.Unlock()
+	}
+	l.deadline = time.Time{}
+	l.setSlow()
+	if l.interned != nil {
+		l.interned = make(map[string]string)
+	}
+}
+
+// Lex creates a new Lexer with the given options and starts running it
+// with sf.
+func Lex(name, input string, sf StateFn, opts ...Option) *Lexer {
+	l := New(name, input, opts...)
 	go l.Run(sf)
 	return l
 }
 
 // Run starts the lexer with the given StateFn.
 // After receiving a nil StateFn, it closes the tokens channel.
+//
+// If the state functions did not emit a TypeEOF or TypeError token,
+// Run emits a TypeEOF token positioned at the end of the input before
+// closing the channel, so the client always receives one of these last.
+// This can be disabled with WithAutoEOF. With WithStrict, a TypeError
+// token is emitted instead if input was dropped.
+//
+// If a state function panics, the panic is recovered and reported as a
+// TypeError token at the current position, with a StateSnapshot as its
+// Data, see DebugState. When built with the lexdebug build tag, the
+// panic is propagated after the channel is closed.
 func (l *Lexer) Run(fn StateFn) {
-	for state := fn; state != nil; {
-		state = state(l)
+	var start time.Time
+	if l.metrics != nil {
+		start = time.Now()
+		l.metrics.LexerStarted()
+	}
+	defer l.finish(start)
+	if l.timeout > 0 {
+		l.deadline = time.Now().Add(l.timeout)
+	}
+	l.setSlow()
+	l.runStates(fn)
+	if l.strict && !l.ended {
+		l.checkDropped()
+	}
+	if !l.ended && !l.noAutoEOF {
+		n := len(l.input)
+		l.emit(Token{Type: TypeEOF, Pos: n, End: n, pb: l.pb})
+	}
+}
+
+// runStates runs the state functions starting with fn until one
+// returns nil.
+func (l *Lexer) runStates(fn StateFn) {
+	for state := fn; state != nil && !l.halted; {
+		l.state = state
+		if l.expired() {
+			l.state = nil
+			return
+		}
+		next := state(l)
+		l.state = nil
+		for _, hook := range l.stateHooks {
+			hook(state, next, l)
+		}
+		state = next
+	}
+}
+
+// finish recovers from a panic in a state function and closes the
+// tokens channel. It must be deferred by Run, which started at start.
+//
+// Closing the tokens channel lets the client Reset the lexer, so the
+// lexer must not be accessed afterwards.
+func (l *Lexer) finish(start time.Time) {
+	r := recover()
+	if r != nil {
+		msg := fmt.Sprintf("panic: %v", r)
+		if l.state != nil {
+			msg = fmt.Sprintf("panic in state %s: %v", l.State(), r)
+		}
+		pos := min(max(l.pos, 0), len(l.input))
+		l.emit(Token{Type: TypeError, Pos: pos, End: pos, Value: msg, Data: l.DebugState(), pb: l.pb})
+	}
+	l.flushCoalesced()
+	if l.metrics != nil {
+		l.metrics.LexerFinished(l.pos, time.Since(start))
+	}
+	tokens, done := l.tokens, l.done
+	l.closed = true
+	close(tokens)
+	close(done)
+	if r != nil && debug {
+		panic(r)
 	}
-	close(l.tokens)
 }
 
+// Done returns a channel that is closed when Run returns, after the
+// tokens channel has been closed. It lets supervisors wait for the
+// lexing goroutine to exit, such as after Drain.
+func (l *Lexer) Done() <-chan struct{} { return l.done }
+
 // NextToken returns the next token from the input.
 // Called by the parser, not in the lexing goroutine.
 //
 // Note: if l.Run has not been called, NextToken will block.
 func (l *Lexer) NextToken() Token {
 	t := <-l.tokens
-	l.lastPos = t.Pos
+	l.received(t)
 	return t
 }
 
+// nextTokenWithin is like NextToken, but waits at most d for a token,
+// or not at all if d is not positive.
+func (l *Lexer) nextTokenWithin(d time.Duration) (Token, bool) {
+	var t Token
+	if d <= 0 {
+		select {
+		case t = <-l.tokens:
+		default:
+			return t, false
+		}
+	} else {
+		timer := time.NewTimer(d)
+		defer timer.Stop()
+		select {
+		case t = <-l.tokens:
+		case <-timer.C:
+			return t, false
+		}
+	}
+	l.received(t)
+	return t, true
+}
+
+// lastRead is the position of the last token received by the client.
+// It is kept apart from the Lexer, which is copied in the lexing
+// goroutine by SubLex and Clone.
+type lastRead struct {
+	pos int
+	pb  *posBase
+}
+
+// received records the position of t, which was received by the client.
+func (l *Lexer) received(t Token) {
+	if l.budget != nil {
+		l.release(t)
+	}
+	l.last.pos = t.Pos
+	if t.pb != nil {
+		l.last.pb = t.pb
+	}
+}
+
 // Drain drains the output so the lexing goroutine will exit.
 // Called by the parser, not in the lexing goroutine.
 func (l *Lexer) Drain() {
-	for range l.tokens {
+	for t := range l.tokens {
+		l.received(t)
 	}
 }
 
 // LineNumber reports the line of the last token returned by NextToken.
 func (l *Lexer) LineNumber() int {
-	return 1 + strings.Count(l.input[:l.lastPos], "\n")
+	line, _ := l.lineCol(l.last.pb, l.last.pos)
+	return line
 }
 
 // ColumnNumber reports the column of the last token returned by NextToken.
+// Columns count runes, see also WithTabWidth and WithDisplayWidth.
 func (l *Lexer) ColumnNumber() int {
-	code := l.input[:l.lastPos]
-	if i := strings.LastIndex(code, "\n"); i >= 0 {
-		return l.lastPos - i
-	} else {
-		return 1 + len(code)
-	}
+	_, col := l.lineCol(l.last.pb, l.last.pos)
+	return col
 }
 
 // Name returns the name of the input.
 func (l *Lexer) Name() string { return l.name }
 
+// Filename reports the file of the last token returned by NextToken.
+// This is the name of the input, unless overridden by SetPosition.
+func (l *Lexer) Filename() string { return l.last.pb.file }
+
 // Value returns the current token value, essentially the part of input
 // from l.base to l.pos.
 func (l *Lexer) Value() string { return l.input[l.base:l.pos] }
@@ -163,27 +417,56 @@ func (l *Lexer) Value() string { return l.input[l.base:l.pos] }
 func (l *Lexer) Len() int { return l.pos - l.base }
 
 // Inc increments the position by n.
-func (l *Lexer) Inc(n int) { l.pos += n }
+func (l *Lexer) Inc(n int) {
+	l.pos += n
+	l.check("Inc")
+}
 
 // Dec decrements the position by n.
-func (l *Lexer) Dec(n int) { l.pos -= n }
+func (l *Lexer) Dec(n int) {
+	l.pos -= n
+	l.check("Dec")
+}
 
 // Pos returns the current position in the input.
 func (l *Lexer) Pos() int { return l.pos }
 
+// Base returns the position in the input where the pending token starts.
+func (l *Lexer) Base() int { return l.base }
+
+// Remaining returns the number of bytes left to read in the current
+// input. Inputs suspended by PushInput and input not yet read from a
+// stream are not counted.
+func (l *Lexer) Remaining() int { return len(l.input) - l.pos }
+
 // Input returns a slice of the current position plus n.
 func (l *Lexer) Input(n int) string {
 	return l.input[l.pos+n:]
 }
 
 // Next returns the next rune in the input.
-// If there is no more input left to read, EOF is returned.
+// If there is no more input left to read, EOF is returned, or
+// ErrMoreInput with WithIncremental. An invalid byte is returned as
+// utf8.RuneError of width 1, see also WithInvalidUTF8Policy.
 func (l *Lexer) Next() rune {
-	if int(l.pos) >= len(l.input) {
+	if (l.slow || l.pos >= len(l.input)) && l.atEnd() {
 		l.width = 0
-		return EOF
+		return l.endRune()
+	}
+	if c := l.input[l.pos]; c < utf8.RuneSelf {
+		// Fast path for ASCII, which needs no decoding.
+		l.width = 1
+		l.pos++
+		return rune(c)
+	}
+	for !utf8.FullRuneInString(l.input[l.pos:]) && l.fill() {
 	}
 	r, w := utf8.DecodeRuneInString(l.input[l.pos:])
+	if r == utf8.RuneError && w == 1 && l.utf8Policy == InvalidUTF8Error {
+		l.invalidUTF8(l.pos)
+		l.width = 0
+		return EOF
+	}
 	l.width = w
 	l.pos += l.width
 	return r
@@ -198,22 +481,188 @@ func (l *Lexer) Peek() rune {
 
 // Emit passes a token back to the client.
 func (l *Lexer) Emit(t Type) {
-	l.tokens <- Token{t, l.base, l.input[l.base:l.pos]}
+	l.check("Emit")
+	raw := l.input[l.base:l.pos]
+	l.emit(Token{Type: t, Pos: l.base, End: l.pos, Value: raw, Raw: raw, pb: l.pb})
+	l.base = l.pos
+}
+
+// EmitWith passes a token back to the client with data attached,
+// so that the parser does not need to parse the value again:
+//
+//	n, err := strconv.ParseInt(l.Value(), 0, 64)
+//	if err != nil {
+//	    return l.Errorf("invalid integer: %v", err)
+//	}
+//	l.EmitWith(TypeInt, n)
+func (l *Lexer) EmitWith(t Type, data interface{}) {
+	l.check("EmitWith")
+	raw := l.input[l.base:l.pos]
+	l.emit(Token{Type: t, Pos: l.base, End: l.pos, Value: raw, Raw: raw, Data: data, pb: l.pb})
 	l.base = l.pos
 }
 
+// EmitNonEmpty passes a token back to the client if there is pending
+// input, and returns whether it did so.
+func (l *Lexer) EmitNonEmpty(t Type) bool {
+	if l.pos <= l.base {
+		return false
+	}
+	l.Emit(t)
+	return true
+}
+
+// EmitMapped passes a token back to the client, with its value set to
+// the result of mapFn applied to the pending input. This is useful for
+// lowercasing, decoding escape sequences, or trimming quotes.
+// The original input is still available in the Raw field of the token.
+func (l *Lexer) EmitMapped(t Type, mapFn func(string) string) {
+	l.check("EmitMapped")
+	raw := l.input[l.base:l.pos]
+	l.emit(Token{Type: t, Pos: l.base, End: l.pos, Value: mapFn(raw), Raw: raw, pb: l.pb})
+	l.base = l.pos
+}
+
+// EmitAt passes a synthesized token back to the client, positioned at
+// pos with the given value, such as a DEDENT token inserted for a change
+// in indentation. The token has no extent in the input: End equals Pos
+// and Raw is empty. The pending input is not affected.
+func (l *Lexer) EmitAt(t Type, pos int, value string) {
+	l.emit(Token{Type: t, Pos: pos, End: pos, Value: value, pb: l.pb})
+}
+
+// emit sends the token t to the client.
+func (l *Lexer) emit(t Token) {
+	if l.halted {
+		return
+	}
+	if l.strict && t.Type == TypeEOF && !l.nested && l.base < len(l.input) {
+		l.checkDropped()
+		return
+	}
+	if l.wsEmit && t.Pos > l.wsEnd && t.Pos <= len(l.input) {
+		l.emitGap(t.Pos)
+	}
+	if t.End > l.wsEnd && !l.isWarning(t) {
+		l.wsEnd = t.End
+	}
+	if l.maxTokenLen > 0 && t.End-t.Pos > l.maxTokenLen && t.Type != TypeError {
+		l.haltTooLong()
+		return
+	}
+	if l.skip[t.Type] && t.Type != TypeError {
+		return
+	}
+	if l.nested && t.Type == TypeEOF {
+		return
+	}
+	if t.Type == TypeEOF || t.Type == TypeError {
+		l.ended = true
+	}
+	if t.Type == TypeError && l.err == nil {
+		l.err = l.newError(t)
+	}
+	if l.metrics != nil {
+		l.metrics.TokenEmitted(t.Type, t.End-t.Pos)
+	}
+	if l.refSink == nil {
+		l.transformValue(&t)
+	}
+	if t.Origin == nil {
+		t.Origin = l.origin
+	}
+	l.deliver(t)
+	if l.warnInvisible && t.Type != l.invisible && t.Type != TypeError && !l.isWarning(t) {
+		for _, d := range l.invisibleWarnings(t) {
+			l.deliver(d)
+		}
+	}
+}
+
+// transformValue applies the options that change the values of tokens
+// to t.
+func (l *Lexer) transformValue(t *Token) {
+	if l.utf8Policy == InvalidUTF8Replace && t.Type != TypeError {
+		t.Value = replaceInvalidUTF8(t.Value)
+	}
+	if l.foldTypes[t.Type] {
+		t.Value = l.fold.String(t.Value)
+	}
+	if l.copyValues {
+		copyValues(t)
+	}
+	if l.interned != nil {
+		l.intern(t)
+	}
+}
+
+// deliver passes t on for coalescing or sends it to the client.
+func (l *Lexer) deliver(t Token) {
+	if len(l.emitHooks) > 0 {
+		t = l.runEmitHooks(t)
+	}
+	if l.coalesce != nil {
+		l.coalesceToken(t)
+		return
+	}
+	l.send(t)
+}
+
+// runEmitHooks returns t modified by the emit hooks. It is separate from
+// deliver, so that t only escapes to the heap if there are hooks.
+func (l *Lexer) runEmitHooks(t Token) Token {
+	for _, hook := range l.emitHooks {
+		hook(&t)
+	}
+	return t
+}
+
+// send sends the token t to the client.
+func (l *Lexer) send(t Token) {
+	if l.trace != nil {
+		fmt.Fprintf(l.trace, "lex: emit %d %q at %d\n", t.Type, t.Value, t.Pos)
+	}
+	if l.refSink != nil {
+		if !l.refSink(t.Ref()) {
+			l.stop()
+		}
+		return
+	}
+	if l.copyValues && t.pb != nil {
+		t.pb = l.detach(t.pb, t.Pos)
+	}
+	if l.sink != nil {
+		if !l.sink(t) {
+			l.stop()
+		}
+		return
+	}
+	if l.budget != nil && t.Type != TypeError && (l.halted || !l.reserve(t)) {
+		return
+	}
+	l.tokens <- t
+}
+
 // Ignore skips over the pending input before this point.
 func (l *Lexer) Ignore() {
 	l.base = l.pos
 }
 
 // Backup steps back one rune. Can only be called once per call of Next.
+//
+// Backup never steps back before the start of the pending token, so
+// calling it after Emit or Ignore has no effect; with Validate, it panics.
 func (l *Lexer) Backup() {
 	l.pos -= l.width
+	if l.pos < l.base {
+		l.check("Backup")
+		l.pos = l.base
+	}
 }
 
 // Consume tries to consume exactly the string s.
 func (l *Lexer) Consume(s string) bool {
+	l.autoSkip()
 	ok := l.HasPrefix(s)
 	if ok {
 		l.pos += len(s)
@@ -221,8 +670,21 @@ func (l *Lexer) Consume(s string) bool {
 	return ok
 }
 
+// ConsumeAny tries to consume each of the options in order, and returns
+// the first one that was consumed. To match the longest option, order
+// the options from longest to shortest.
+func (l *Lexer) ConsumeAny(options ...string) (string, bool) {
+	for _, s := range options {
+		if l.Consume(s) {
+			return s, true
+		}
+	}
+	return "", false
+}
+
 // Accept consumes the next rune if it is from the valid set.
 func (l *Lexer) Accept(valid string) bool {
+	l.autoSkip()
 	if strings.IndexRune(valid, l.Next()) >= 0 {
 		return true
 	}
@@ -233,6 +695,7 @@ func (l *Lexer) Accept(valid string) bool {
 // AcceptRun consumes a run of runes from the valid set.
 // The number of bytes advanced is returned.
 func (l *Lexer) AcceptRun(valid string) int {
+	l.autoSkip()
 	var n int
 	for strings.IndexRune(valid, l.Next()) >= 0 {
 		n += l.width
@@ -243,6 +706,7 @@ func (l *Lexer) AcceptRun(valid string) int {
 
 // AcceptFunc consumes the next rune if f returns true.
 func (l *Lexer) AcceptFunc(f func(r rune) bool) bool {
+	l.autoSkip()
 	if f(l.Next()) {
 		return true
 	}
@@ -253,6 +717,7 @@ func (l *Lexer) AcceptFunc(f func(r rune) bool) bool {
 // AcceptFunc consumes a run of runes as long as f returns true.
 // The number of bytes advanced is returned.
 func (l *Lexer) AcceptFuncRun(f func(r rune) bool) int {
+	l.autoSkip()
 	var n int
 	for f(l.Next()) {
 		n += l.width
@@ -261,9 +726,34 @@ func (l *Lexer) AcceptFuncRun(f func(r rune) bool) int {
 	return n
 }
 
+// AcceptRange consumes the next rune if it is in the range lo to hi,
+// inclusive.
+func (l *Lexer) AcceptRange(lo, hi rune) bool {
+	l.autoSkip()
+	if r := l.Next(); r >= 0 && r >= lo && r <= hi {
+		return true
+	}
+	l.Backup()
+	return false
+}
+
+// AcceptRangeRun consumes a run of runes in the range lo to hi,
+// inclusive. The number of bytes advanced is returned.
+func (l *Lexer) AcceptRangeRun(lo, hi rune) int {
+	l.autoSkip()
+	var n int
+	for r := l.Next(); r >= 0 && r >= lo && r <= hi; r = l.Next() {
+		n += l.width
+	}
+	l.Backup()
+	return n
+}
+
 // AcceptBut consumes a rune if it is not from the invalid set.
+// The end of the input is never accepted, as it is not a rune.
 func (l *Lexer) AcceptBut(invalid string) bool {
-	if strings.IndexRune(invalid, l.Next()) < 0 {
+	l.autoSkip()
+	if r := l.Next(); r >= 0 && strings.IndexRune(invalid, r) < 0 {
 		return true
 	}
 	l.Backup()
@@ -271,10 +761,13 @@ func (l *Lexer) AcceptBut(invalid string) bool {
 }
 
 // AcceptButRun consumes runes as long as they are not in the invalid set.
-// The number of bytes advanced is returned.
+// It stops at the end of the input, so that AcceptButRun(Endline) consumes
+// the rest of a line even if it is the last one. The number of bytes
+// advanced is returned.
 func (l *Lexer) AcceptButRun(invalid string) int {
+	l.autoSkip()
 	var n int
-	for strings.IndexRune(invalid, l.Next()) < 0 {
+	for r := l.Next(); r >= 0 && strings.IndexRune(invalid, r) < 0; r = l.Next() {
 		n += l.width
 	}
 	l.Backup()
@@ -284,18 +777,43 @@ func (l *Lexer) AcceptButRun(invalid string) int {
 // HasPrefix returns true if the input from the current position
 // has the prefix s. It does not consume the prefix.
 func (l *Lexer) HasPrefix(s string) bool {
-	return strings.HasPrefix(l.input[l.pos:], s)
+	return l.HasPrefixAfter(0, s)
 }
 
 // HasPrefixAfter returns true if the input from the current position
 // plus after bytes has the prefix s. It does not consume the prefix.
 func (l *Lexer) HasPrefixAfter(after int, s string) bool {
+	for len(l.input)-l.pos-after < len(s) && l.fill() {
+	}
 	return strings.HasPrefix(l.input[l.pos+after:], s)
 }
 
 // Errorf returns an error token and terminates the scan by passing
 // back a nil pointer that will be the next state, terminating l.NextToken.
 func (l *Lexer) Errorf(format string, args ...interface{}) StateFn {
-	l.tokens <- Token{TypeError, l.base, fmt.Sprintf(format, args...)}
+	l.emit(Token{Type: TypeError, Pos: l.base, End: l.pos, Value: fmt.Sprintf(format, args...), pb: l.pb})
 	return nil
 }
+
+// halt emits an error token and stops lexing: from then on, Next returns
+// EOF, no more tokens are emitted, and Run returns as soon as the
+// current state function does.
+func (l *Lexer) halt(format string, args ...interface{}) {
+	l.Errorf(format, args...)
+	l.stop()
+}
+
+// stop stops lexing without an error, see halt.
+func (l *Lexer) stop() {
+	l.halted, l.slow = true, true
+}
+
+// setSlow sets whether Next must call atEnd before every rune, because
+// lexing was stopped or may be stopped by the options of l.
+func (l *Lexer) setSlow() {
+	l.slow = l.halted || l.ctx != nil || !l.deadline.IsZero() || l.maxTokenLen > 0
+}
+
+func (l *Lexer) haltTooLong() {
+	l.halt("token exceeds maximum length of %d bytes", l.maxTokenLen)
+}
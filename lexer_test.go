@@ -0,0 +1,307 @@
+// Copyright (c) 2015, Ben Morgan. All rights reserved.
+// Use of this source code is governed by an MIT license
+// that can be found in the LICENSE file.
+
+package lex_test
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"testing"
+	"unicode"
+
+	"github.com/goulash/lex"
+)
+
+const (
+	typeWord lex.Type = (1 + lex.TypeEOF) + iota
+	typeSpace
+	typeOther
+)
+
+// lexWords emits runs of letters as typeWord, runs of spaces as
+// typeSpace, and any other rune as typeOther.
+func lexWords(l *lex.Lexer) lex.StateFn {
+	for {
+		switch r := l.Peek(); {
+		case r < 0:
+			return nil
+		case unicode.IsLetter(r):
+			l.AcceptFuncRun(unicode.IsLetter)
+			l.Emit(typeWord)
+		case r == ' ':
+			l.AcceptRun(" ")
+			l.Emit(typeSpace)
+		default:
+			l.Next()
+			l.Emit(typeOther)
+		}
+	}
+}
+
+// values returns the values of toks.
+func values(toks []lex.Token) []string {
+	vs := make([]string, len(toks))
+	for i, t := range toks {
+		vs[i] = t.Value
+	}
+	return vs
+}
+
+func TestResetAfterDrain(t *testing.T) {
+	l := lex.Lex("a", "one two", lexWords)
+	for i := 0; i < 100; i++ {
+		l.Drain()
+		l.Reset("a", "three four")
+		go l.Run(lexWords)
+	}
+	toks := lex.Collect(l)
+	if len(toks) != 4 || toks[2].Value != "four" {
+		t.Errorf("got %q after resets", values(toks))
+	}
+	<-l.Done()
+}
+
+func TestAcceptButAtEOF(t *testing.T) {
+	var n int
+	var ok bool
+	l := lex.Lex("a", "ab\ncd", func(l *lex.Lexer) lex.StateFn {
+		l.AcceptButRun("\n")
+		l.Next()
+		n = l.AcceptButRun("\n")
+		ok = l.AcceptBut("\n")
+		l.Emit(typeWord)
+		return nil
+	})
+	toks := lex.Collect(l)
+	if n != 2 || ok || toks[0].Value != "ab\ncd" {
+		t.Errorf("got %d, %t, %q; want 2, false, the whole input", n, ok, values(toks))
+	}
+}
+
+func TestEmitMapped(t *testing.T) {
+	l := lex.Lex("a", `"Hello" x`, func(l *lex.Lexer) lex.StateFn {
+		l.Next()
+		l.AcceptFuncRun(unicode.IsLetter)
+		l.Next()
+		l.EmitMapped(typeWord, func(s string) string { return strings.ToLower(s[1 : len(s)-1]) })
+		l.AcceptRun(" ")
+		l.EmitMapped(typeSpace, func(string) string { return "" })
+		return lexWords
+	})
+	toks := lex.Collect(l)
+	if toks[0].Value != "hello" || toks[0].Raw != `"Hello"` || toks[0].Pos != 0 || toks[0].End != 7 {
+		t.Errorf("got %+v, want value hello with raw \"Hello\" at 0-7", toks[0])
+	}
+	if toks[1].Value != "" || toks[1].Raw != " " {
+		t.Errorf("got %+v, want empty value with raw space", toks[1])
+	}
+	if toks[2].Value != "x" || toks[2].Raw != "x" {
+		t.Errorf("got %+v, want x", toks[2])
+	}
+}
+
+func TestTokenEnd(t *testing.T) {
+	const input = "ab, cd\tef"
+	toks := lex.Collect(lex.Lex("a", input, lexWords))
+	pos := 0
+	for _, tok := range toks {
+		if tok.Pos != pos || input[tok.Pos:tok.End] != tok.Value {
+			t.Errorf("%v spans %d-%d, want it to start at %d and span its value", tok, tok.Pos, tok.End, pos)
+		}
+		pos = tok.End
+	}
+	if eof := toks[len(toks)-1]; eof.Type != lex.TypeEOF || eof.Pos != len(input) || eof.End != len(input) {
+		t.Errorf("got %v at %d-%d, want EOF at the end", eof, eof.Pos, eof.End)
+	}
+}
+
+func TestReset(t *testing.T) {
+	optSets := [][]lex.Option{
+		nil,
+		{lex.WithCoalesce(typeSpace, typeOther), lex.WithInterning()},
+		{lex.WithNormalizeNewlines(), lex.WithStripBOM(), lex.WithCopyValues()},
+		{lex.WithMemoryBudget(1 << 10), lex.WithEmitWhitespace(typeSpace, typeSpace)},
+		{lex.WithMaxTokenLength(3)},
+	}
+	inputs := []string{"\ufeffab  cd!!\r\n", "abcdef gh", "x,,y\r\nz"}
+	for i, opts := range optSets {
+		l := lex.New("a", "", opts...)
+		for _, in := range inputs {
+			want := lex.Collect(lex.Lex("a", in, lexWords, opts...))
+			l.Reset("a", in)
+			go l.Run(lexWords)
+			got := lex.Collect(l)
+			if strings.Join(values(got), "|") != strings.Join(values(want), "|") || len(got) != len(want) {
+				t.Errorf("options %d, input %q: got %q, want %q", i, in, values(got), values(want))
+				continue
+			}
+			for j := range got {
+				if got[j].Pos != want[j].Pos || got[j].Type != want[j].Type {
+					t.Errorf("options %d, input %q: got %v at %d, want %v at %d", i, in, got[j], got[j].Pos, want[j], want[j].Pos)
+				}
+			}
+		}
+	}
+}
+
+func TestAutoEOF(t *testing.T) {
+	explicit := func(l *lex.Lexer) lex.StateFn {
+		lexWords(l)
+		l.Emit(lex.TypeEOF)
+		return nil
+	}
+	tests := []struct {
+		sf   lex.StateFn
+		opts []lex.Option
+		want []lex.Type
+	}{
+		{lexWords, nil, []lex.Type{typeWord, lex.TypeEOF}},
+		{explicit, nil, []lex.Type{typeWord, lex.TypeEOF}},
+		{lexWords, []lex.Option{lex.WithAutoEOF(false)}, []lex.Type{typeWord}},
+		{explicit, []lex.Option{lex.WithAutoEOF(false)}, []lex.Type{typeWord, lex.TypeEOF}},
+	}
+	for i, tt := range tests {
+		toks := lex.Collect(lex.Lex("a", "ab", tt.sf, tt.opts...))
+		var got []lex.Type
+		for _, tok := range toks {
+			got = append(got, tok.Type)
+		}
+		if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+			t.Errorf("%d: got types %v, want %v", i, got, tt.want)
+		}
+	}
+}
+
+func TestRunPanic(t *testing.T) {
+	l := lex.Lex("a", "ab cd", lex.Named("boom", func(l *lex.Lexer) lex.StateFn {
+		l.AcceptRun("ab")
+		l.Emit(typeWord)
+		l.Next()
+		panic("boom")
+	}))
+	toks := lex.Collect(l)
+	<-l.Done()
+	last := toks[len(toks)-1]
+	if len(toks) != 2 || last.Type != lex.TypeError || last.Value != "panic in state boom: boom" || last.Pos != 3 {
+		t.Errorf("got %v at %d, want the panic at 3", toks, last.Pos)
+	}
+	if _, ok := last.Data.(lex.StateSnapshot); !ok {
+		t.Errorf("got data %T, want a StateSnapshot", last.Data)
+	}
+	if err := l.Err(); err == nil || !strings.Contains(err.Error(), "boom") {
+		t.Errorf("got error %v, want the panic", err)
+	}
+}
+
+func TestBaseRemaining(t *testing.T) {
+	var got []string
+	lex.Collect(lex.Lex("a", "ab cd", func(l *lex.Lexer) lex.StateFn {
+		for l.Peek() >= 0 {
+			l.AcceptRun("abcd")
+			got = append(got, fmt.Sprintf("%d/%d", l.Base(), l.Remaining()))
+			l.Ignore()
+			l.Next()
+		}
+		got = append(got, fmt.Sprintf("%d/%d", l.Base(), l.Remaining()))
+		return nil
+	}))
+	// The space is pending with cd, so the second token starts at 2.
+	if s := strings.Join(got, " "); s != "0/3 2/0 5/0" {
+		t.Errorf("got %s, want 0/3 2/0 5/0", s)
+	}
+}
+
+func TestRemainingPushed(t *testing.T) {
+	var got int
+	lex.Collect(lex.Lex("a", "outer", func(l *lex.Lexer) lex.StateFn {
+		l.Next()
+		l.Ignore()
+		l.PushInput("b", "in")
+		got = l.Remaining()
+		return nil
+	}))
+	if got != 2 {
+		t.Errorf("got %d remaining, want 2 of the pushed input", got)
+	}
+}
+
+func TestAcceptRange(t *testing.T) {
+	var got []string
+	lex.Collect(lex.Lex("a", "0129αβγx", func(l *lex.Lexer) lex.StateFn {
+		got = append(got,
+			fmt.Sprint(l.AcceptRange('1', '9')),
+			fmt.Sprint(l.AcceptRange('0', '0')),
+			fmt.Sprint(l.AcceptRangeRun('0', '9')),
+			fmt.Sprint(l.AcceptRangeRun('α', 'ω')),
+			fmt.Sprint(l.AcceptRangeRun('y', 'a')),
+			fmt.Sprint(l.AcceptRange('x', 'x')),
+			fmt.Sprint(l.AcceptRange(0, unicode.MaxRune)),
+			fmt.Sprint(l.AcceptRangeRun(0, unicode.MaxRune)),
+			fmt.Sprint(l.AcceptRange(lex.ErrMoreInput, 'z')),
+			fmt.Sprint(l.AcceptRangeRun(lex.ErrMoreInput, 'z')),
+		)
+		return nil
+	}))
+	if s := strings.Join(got, " "); s != "false true 3 6 0 true false 0 false 0" {
+		t.Errorf("got %s, want false true 3 6 0 true false 0 false 0", s)
+	}
+}
+
+func TestEmitNonEmpty(t *testing.T) {
+	var emitted []bool
+	toks := lex.Collect(lex.Lex("a", "ab", func(l *lex.Lexer) lex.StateFn {
+		emitted = append(emitted, l.EmitNonEmpty(typeOther))
+		l.AcceptRun("ab")
+		emitted = append(emitted, l.EmitNonEmpty(typeWord), l.EmitNonEmpty(typeWord))
+		return nil
+	}))
+	if fmt.Sprint(emitted) != "[false true false]" {
+		t.Errorf("got %v, want [false true false]", emitted)
+	}
+	if len(toks) != 2 || toks[0].Type != typeWord || toks[0].Value != "ab" {
+		t.Errorf("got %v, want ab and EOF", toks)
+	}
+}
+
+func TestEmitWith(t *testing.T) {
+	sf := func(l *lex.Lexer) lex.StateFn {
+		for l.Peek() >= 0 {
+			if l.AcceptRun("0123456789") > 0 {
+				n, _ := strconv.Atoi(l.Value())
+				l.EmitWith(typeWord, n)
+				continue
+			}
+			l.Next()
+			l.Emit(typeOther)
+		}
+		return nil
+	}
+	r := lex.NewReader(lex.Lex("a", "12+345", sf))
+	var got []interface{}
+	for tok := range r.All() {
+		got = append(got, tok.Data)
+	}
+	if fmt.Sprint(got) != "[12 <nil> 345 <nil>]" {
+		t.Errorf("got data %v, want the parsed numbers", got)
+	}
+}
+
+func TestConsumeAny(t *testing.T) {
+	ops := []string{"<<=", "<<", "<=", "<"}
+	var got []string
+	lex.Collect(lex.Lex("a", "<<=<<<=< x", func(l *lex.Lexer) lex.StateFn {
+		for {
+			s, ok := l.ConsumeAny(ops...)
+			if !ok {
+				got = append(got, fmt.Sprintf("%q", s))
+				return nil
+			}
+			got = append(got, s)
+		}
+	}))
+	if s := strings.Join(got, " "); s != `<<= << <= < ""` {
+		t.Errorf("got %s", s)
+	}
+}
@@ -0,0 +1,151 @@
+// Copyright (c) 2015, Ben Morgan. All rights reserved.
+// Use of this source code is governed by an MIT license
+// that can be found in the LICENSE file.
+
+package lexgen
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"io"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Generate writes the Go source of the lexer described by s to w.
+//
+// The generated code declares a token type constant for every type of s,
+// continuing after lex.TypeEOF, and a state function for every state.
+// The entry state function is named s.Func.
+func Generate(w io.Writer, s *Spec) error {
+	var g generator
+	g.gen(s)
+	src, err := format.Source(g.Bytes())
+	if err != nil {
+		return fmt.Errorf("lexgen: formatting generated code: %v", err)
+	}
+	_, err = w.Write(src)
+	return err
+}
+
+type generator struct {
+	bytes.Buffer
+}
+
+func (g *generator) printf(format string, args ...interface{}) {
+	fmt.Fprintf(g, format, args...)
+}
+
+func (g *generator) gen(s *Spec) {
+	g.printf("// Code generated by lexgen from %s; DO NOT EDIT.\n\n", s.Name)
+	g.printf("package %s\n\n", s.Package)
+	g.printf("import (\n")
+	if s.hasPatterns() {
+		g.printf("\t\"regexp\"\n")
+	}
+	if s.hasLiterals() {
+		g.printf("\t\"strings\"\n")
+	}
+	g.printf("\n\t\"github.com/goulash/lex\"\n)\n\n")
+
+	g.printf("const (\n")
+	for i, name := range s.Types() {
+		if i == 0 {
+			g.printf("\t%s%s lex.Type = (1 + lex.TypeEOF) + iota\n", s.Prefix, name)
+		} else {
+			g.printf("\t%s%s\n", s.Prefix, name)
+		}
+	}
+	g.printf(")\n\n")
+
+	if s.hasPatterns() {
+		g.printf("var (\n")
+		for _, st := range s.States {
+			for i, r := range st.Rules {
+				if r.Pattern != "" {
+					g.printf("\t%s = regexp.MustCompile(%s)\n", patternVar(st, i), quote("^(?:"+r.Pattern+")"))
+				}
+			}
+		}
+		g.printf(")\n\n")
+	}
+
+	g.printf("// %s is the state function for lexing input from the start.\n", s.Func)
+	g.printf("func %s(l *lex.Lexer) lex.StateFn {\n\treturn %s(l)\n}\n", s.Func, stateFunc(s.States[0]))
+	for _, st := range s.States {
+		g.genState(s, st)
+	}
+}
+
+func (g *generator) genState(s *Spec, st *State) {
+	g.printf("\nfunc %s(l *lex.Lexer) lex.StateFn {\n", stateFunc(st))
+	g.printf("\tfor {\n")
+	g.printf("\t\tr := l.Peek()\n\t\tif r < 0 {\n\t\t\tl.Emit(lex.TypeEOF)\n\t\t\treturn nil\n\t\t}\n")
+	g.printf("\t\tin := l.Input(0)\n")
+	g.printf("\t\tn, rule := 0, -1\n")
+	for i, r := range st.Rules {
+		if r.Pattern != "" {
+			g.printf("\t\tif m := %s.FindStringIndex(in); m != nil && m[1] > n {\n", patternVar(st, i))
+			g.printf("\t\t\tn, rule = m[1], %d\n\t\t}\n", i)
+		} else {
+			g.printf("\t\tif %d > n && strings.HasPrefix(in, %s) {\n", len(r.Literal), quote(r.Literal))
+			g.printf("\t\t\tn, rule = %d, %d\n\t\t}\n", len(r.Literal), i)
+		}
+	}
+	g.printf("\t\tif rule < 0 {\n\t\t\treturn l.Errorf(\"unexpected %%q\", r)\n\t\t}\n")
+	g.printf("\t\tl.Take(n)\n")
+	g.printf("\t\tswitch rule {\n")
+	for i, r := range st.Rules {
+		g.printf("\t\tcase %d:\n", i)
+		if r.Skip() {
+			g.printf("\t\t\tl.Ignore()\n")
+		} else {
+			g.printf("\t\t\tl.Emit(%s%s)\n", s.Prefix, r.Type)
+		}
+		if r.Next != "" && r.Next != st.Name {
+			g.printf("\t\t\treturn %s\n", stateFunc(s.state(r.Next)))
+		}
+	}
+	g.printf("\t\t}\n\t}\n}\n")
+}
+
+func (s *Spec) hasPatterns() bool {
+	for _, st := range s.States {
+		for _, r := range st.Rules {
+			if r.Pattern != "" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (s *Spec) hasLiterals() bool {
+	for _, st := range s.States {
+		for _, r := range st.Rules {
+			if r.Literal != "" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func stateFunc(st *State) string {
+	r, n := utf8.DecodeRuneInString(st.Name)
+	return "lex" + string(unicode.ToUpper(r)) + st.Name[n:]
+}
+
+func patternVar(st *State, i int) string {
+	return fmt.Sprintf("re%s%d", strings.TrimPrefix(stateFunc(st), "lex"), i)
+}
+
+// quote returns s as a raw string literal if possible.
+func quote(s string) string {
+	if !strings.ContainsAny(s, "`\r") && utf8.ValidString(s) {
+		return "`" + s + "`"
+	}
+	return fmt.Sprintf("%q", s)
+}
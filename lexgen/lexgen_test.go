@@ -0,0 +1,189 @@
+// Copyright (c) 2015, Ben Morgan. All rights reserved.
+// Use of this source code is governed by an MIT license
+// that can be found in the LICENSE file.
+
+package lexgen
+
+import (
+	"bytes"
+	"errors"
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/goulash/lex"
+)
+
+const calc = `package = calc
+func = Lex
+prefix = Type
+
+[main]
+_ = /[ \t\r\n]+/
+Number = /[0-9]+/
+Plus = "+"
+Quote>string = '"'
+
+[string]
+Text = /[^"]+/
+Quote>main = '"'
+`
+
+func TestParse(t *testing.T) {
+	s, err := Parse("calc.lex", calc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &Spec{
+		Name:    "calc.lex",
+		Package: "calc",
+		Func:    "Lex",
+		Prefix:  "Type",
+		States: []*State{
+			{Name: "main", Rules: []Rule{
+				{Type: "_", Pattern: `[ \t\r\n]+`},
+				{Type: "Number", Pattern: "[0-9]+"},
+				{Type: "Plus", Literal: "+"},
+				{Type: "Quote", Literal: `"`, Next: "string"},
+			}},
+			{Name: "string", Rules: []Rule{
+				{Type: "Text", Pattern: `[^"]+`},
+				{Type: "Quote", Literal: `"`, Next: "main"},
+			}},
+		},
+	}
+	if !reflect.DeepEqual(s, want) {
+		t.Errorf("Parse = %+v, want %+v", s, want)
+	}
+	if got, want := s.Types(), []string{"Number", "Plus", "Quote", "Text"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Types = %q, want %q", got, want)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		src, err string
+	}{
+		{"[main]\nA = 'a'\n", "missing package"},
+		{"package = p\n", "no states"},
+		{"package = p\nfoo = bar\n[main]\nA = 'a'\n", `unknown option "foo"`},
+		{"package = 1p\n[main]\nA = 'a'\n", `package: invalid identifier "1p"`},
+		{"package = p\n[main]\nA = 'a'\n[main]\nB = 'b'\n", `duplicate state "main"`},
+		{"package = p\n[a b]\nA = 'a'\n", `invalid state name "a b"`},
+		{"package = p\n[main]\n1A = 'a'\n", `invalid type name "1A"`},
+		{"package = p\n[main]\nA> = 'a'\n", `invalid state name ""`},
+		{"package = p\n[main]\nA = ''\n", "empty literal for A"},
+		{"package = p\n[main]\nA = //\n", "empty regexp for A"},
+		{"package = p\n[main]\nA = a\n", "must be a quoted literal or a /regexp/"},
+		{"package = p\n[main]\nA = /(/\n", "missing closing )"},
+		{"package = p\n[main]\nA>other = 'a'\n", `state main: rule A: unknown state "other"`},
+		{"package = p\nprefix = lex\n[main]\nMain = 'a'\n", "generated name lexMain is declared twice"},
+		{"package = p\nprefix = ni\n[main]\nl = 'a'\n", "generated name nil"},
+		{"package = p\n[main]\nA = /a/\nB = /b/\nC = /c/\nD = /d/\nE = /e/\nF = /f/\nG = /g/\nH = /h/\nI = /i/\nJ = /j/\nK = /k/\n[main1]\nA = /a/\n", "generated name reMain10"},
+	}
+	for _, tt := range tests {
+		_, err := Parse("test.lex", tt.src)
+		var e *lex.Error
+		if !errors.As(err, &e) {
+			t.Errorf("Parse(%q) error = %v, want *lex.Error", tt.src, err)
+			continue
+		}
+		if e.File != "test.lex" || !strings.Contains(e.Msg, tt.err) {
+			t.Errorf("Parse(%q) error = %v, want %q", tt.src, err, tt.err)
+		}
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	tests := []struct {
+		name, src string
+		imports   []string
+	}{
+		{"calc", calc, []string{"regexp", "strings", "github.com/goulash/lex"}},
+		{"literals", "package = p\n[main]\nA = 'a'\n_ = ' '\n", []string{"strings", "github.com/goulash/lex"}},
+		{"patterns", "package = p\nfunc = lexAll\n[main]\nA = /a+/\n_ = / +/\n", []string{"regexp", "github.com/goulash/lex"}},
+	}
+	for _, tt := range tests {
+		s, err := Parse(tt.name+".lex", tt.src)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var buf bytes.Buffer
+		if err := Generate(&buf, s); err != nil {
+			t.Fatal(err)
+		}
+		src := buf.String()
+		if !strings.HasPrefix(src, "// Code generated by lexgen from "+tt.name+".lex; DO NOT EDIT.\n") {
+			t.Errorf("%s: missing generated header:\n%s", tt.name, src)
+		}
+		imports := typeCheck(t, tt.name, src)
+		if !reflect.DeepEqual(imports, tt.imports) {
+			t.Errorf("%s: imports = %q, want %q", tt.name, imports, tt.imports)
+		}
+	}
+}
+
+func TestGenerateTypes(t *testing.T) {
+	s, err := Parse("calc.lex", calc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := Generate(&buf, s); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"TypeNumber lex.Type = (1 + lex.TypeEOF) + iota",
+		"func Lex(l *lex.Lexer) lex.StateFn {\n\treturn lexMain(l)\n}",
+		"func lexString(l *lex.Lexer) lex.StateFn {",
+		"reMain1   = regexp.MustCompile(`^(?:[0-9]+)`)",
+		"return lexString",
+		"return lexMain",
+	} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("generated code does not contain %q:\n%s", want, buf.String())
+		}
+	}
+}
+
+// typeCheck type-checks the generated source src and returns the paths
+// of its imports.
+func typeCheck(t *testing.T, name, src string) []string {
+	t.Helper()
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, name+".go", src, 0)
+	if err != nil {
+		t.Fatalf("%s: %v\n%s", name, err, src)
+	}
+	dir, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	imp := importer.ForCompiler(fset, "source", nil).(types.ImporterFrom)
+	conf := types.Config{Importer: importerFrom{imp, dir}}
+	if _, err := conf.Check(f.Name.Name, fset, []*ast.File{f}, nil); err != nil {
+		t.Errorf("%s: %v\n%s", name, err, src)
+	}
+	var imports []string
+	for _, spec := range f.Imports {
+		imports = append(imports, strings.Trim(spec.Path.Value, `"`))
+	}
+	return imports
+}
+
+// importerFrom resolves imports relative to dir, so that the module of
+// the package under test is found.
+type importerFrom struct {
+	types.ImporterFrom
+	dir string
+}
+
+func (imp importerFrom) Import(path string) (*types.Package, error) {
+	return imp.ImportFrom(path, imp.dir, 0)
+}
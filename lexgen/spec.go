@@ -0,0 +1,245 @@
+// Copyright (c) 2015, Ben Morgan. All rights reserved.
+// Use of this source code is governed by an MIT license
+// that can be found in the LICENSE file.
+
+// Package lexgen generates lexers from a declarative token spec.
+//
+// A spec is written in the TOML-style format of package lexini.
+// Keys before the first section configure the generated code, and
+// each section is a state of the lexer, the first being the start state:
+//
+//	package = calc   # package of the generated file
+//	func = Lex       # name of the entry state function
+//	prefix = Type    # prefix of the token type constants
+//
+//	[main]
+//	_ = /[ \t\r\n]+/        # _ skips the match
+//	Number = /[0-9]+/       # /.../ is a regular expression
+//	Plus = "+"              # quoted values are literals
+//	Quote>string = '"'      # >state switches state after the token
+//
+//	[string]
+//	Text = /[^"]+/
+//	Quote>main = '"'
+//
+// In every state, the longest match wins; of several matches of the
+// same length, the rule listed first wins, so keywords should be listed
+// before identifiers. TypeEOF is emitted at the end of the input.
+//
+// Since the value of a key ends before a comment, a regular expression
+// may not contain a space followed by # or ;.
+package lexgen
+
+import (
+	"fmt"
+	"go/token"
+	"regexp"
+	"strings"
+
+	"github.com/goulash/lex"
+	"github.com/goulash/lex/lexini"
+)
+
+// A Spec is a parsed lexer spec.
+type Spec struct {
+	Name    string // name of the spec file
+	Package string
+	Func    string
+	Prefix  string
+	States  []*State
+}
+
+// A State is a state of the lexer and the rules matched in it.
+type State struct {
+	Name  string
+	Rules []Rule
+}
+
+// A Rule matches a token in a state.
+type Rule struct {
+	Type    string // name of the token type without prefix, or _ to skip
+	Literal string // literal text to match, if Pattern is empty
+	Pattern string // regular expression to match
+	Next    string // state to switch to after the token, if not empty
+}
+
+// Skip reports whether the text matched by r is ignored.
+func (r Rule) Skip() bool { return r.Type == "_" }
+
+// Types returns the names of the token types of s in order of their
+// first appearance, without prefix.
+func (s *Spec) Types() []string {
+	var types []string
+	seen := make(map[string]bool)
+	for _, st := range s.States {
+		for _, r := range st.Rules {
+			if !r.Skip() && !seen[r.Type] {
+				seen[r.Type] = true
+				types = append(types, r.Type)
+			}
+		}
+	}
+	return types
+}
+
+// Parse parses the spec src, which is read from the file name.
+// Errors are returned as *lex.Error.
+func Parse(name, src string) (*Spec, error) {
+	rec := lex.Record(lex.Lex(name, src, lexini.Lex))
+	r := rec.Reader()
+	errorf := func(t lex.Token, format string, args ...interface{}) error {
+		file, line, col := r.PosOf(t)
+		return &lex.Error{File: file, Line: line, Col: col, Msg: fmt.Sprintf(format, args...)}
+	}
+
+	s := &Spec{Name: name, Func: "Lex", Prefix: "Type"}
+	var st *State
+	for {
+		t := r.Next()
+		switch t.Type {
+		case lex.TypeEOF:
+			return s, s.check(rec, errorf)
+		case lex.TypeError:
+			return nil, errorf(t, "%s", t.Value)
+		case lexini.TypeComment:
+		case lexini.TypeSection:
+			if !token.IsIdentifier(t.Value) {
+				return nil, errorf(t, "invalid state name %q", t.Value)
+			}
+			if s.state(t.Value) != nil {
+				return nil, errorf(t, "duplicate state %q", t.Value)
+			}
+			st = &State{Name: t.Value}
+			s.States = append(s.States, st)
+		case lexini.TypeKey:
+			r.Next() // assignment
+			v, ok := r.Accept(lexini.TypeValue, lexini.TypeString)
+			if !ok {
+				return nil, errorf(t, "missing value for %q", t.Value)
+			}
+			var err error
+			if st == nil {
+				err = s.set(t.Value, v.Value)
+			} else {
+				err = st.add(t.Value, v)
+			}
+			if err != nil {
+				return nil, errorf(t, "%v", err)
+			}
+		default:
+			return nil, errorf(t, "unexpected %v", t)
+		}
+	}
+}
+
+func (s *Spec) set(key, value string) error {
+	switch key {
+	case "package":
+		s.Package = value
+	case "func":
+		s.Func = value
+	case "prefix":
+		s.Prefix = value
+	default:
+		return fmt.Errorf("unknown option %q", key)
+	}
+	if !token.IsIdentifier(value) {
+		return fmt.Errorf("%s: invalid identifier %q", key, value)
+	}
+	return nil
+}
+
+func (st *State) add(key string, v lex.Token) error {
+	var r Rule
+	var next bool
+	r.Type, r.Next, next = strings.Cut(key, ">")
+	if r.Type != "_" && !token.IsIdentifier(r.Type) {
+		return fmt.Errorf("invalid type name %q", r.Type)
+	}
+	if next && !token.IsIdentifier(r.Next) {
+		return fmt.Errorf("invalid state name %q", r.Next)
+	}
+	if v.Type == lexini.TypeString {
+		if v.Value == "" {
+			return fmt.Errorf("empty literal for %s", r.Type)
+		}
+		r.Literal = v.Value
+	} else {
+		p := v.Value
+		if len(p) < 2 || p[0] != '/' || p[len(p)-1] != '/' {
+			return fmt.Errorf("value of %s must be a quoted literal or a /regexp/", r.Type)
+		}
+		r.Pattern = p[1 : len(p)-1]
+		if r.Pattern == "" {
+			return fmt.Errorf("empty regexp for %s", r.Type)
+		}
+		if _, err := regexp.Compile(r.Pattern); err != nil {
+			return err
+		}
+	}
+	st.Rules = append(st.Rules, r)
+	return nil
+}
+
+func (s *Spec) state(name string) *State {
+	for _, st := range s.States {
+		if st.Name == name {
+			return st
+		}
+	}
+	return nil
+}
+
+// check checks the references between states once s is complete.
+func (s *Spec) check(rec *lex.Recording, errorf func(lex.Token, string, ...interface{}) error) error {
+	eof := rec.Tokens[len(rec.Tokens)-1]
+	if s.Package == "" {
+		return errorf(eof, "missing package")
+	}
+	if len(s.States) == 0 {
+		return errorf(eof, "no states")
+	}
+	for _, st := range s.States {
+		for _, r := range st.Rules {
+			if r.Next != "" && s.state(r.Next) == nil {
+				return errorf(eof, "state %s: rule %s: unknown state %q", st.Name, r.Type, r.Next)
+			}
+		}
+	}
+	if name := s.conflict(); name != "" {
+		return errorf(eof, "generated name %s is declared twice or reserved", name)
+	}
+	return nil
+}
+
+// reserved are the names that the generated code uses besides its own
+// declarations, either at package level or within the state functions.
+var reserved = []string{"lex", "regexp", "strings", "nil", "init", "l", "r", "in", "n", "m", "rule"}
+
+// conflict returns a name declared by the generated code that is declared
+// twice or is reserved, or the empty string if there is none.
+func (s *Spec) conflict() string {
+	seen := make(map[string]bool)
+	for _, name := range reserved {
+		seen[name] = true
+	}
+	names := []string{s.Func}
+	for _, t := range s.Types() {
+		names = append(names, s.Prefix+t)
+	}
+	for _, st := range s.States {
+		names = append(names, stateFunc(st))
+		for i, r := range st.Rules {
+			if r.Pattern != "" {
+				names = append(names, patternVar(st, i))
+			}
+		}
+	}
+	for _, name := range names {
+		if seen[name] {
+			return name
+		}
+		seen[name] = true
+	}
+	return ""
+}
@@ -0,0 +1,169 @@
+// Copyright (c) 2015, Ben Morgan. All rights reserved.
+// Use of this source code is governed by an MIT license
+// that can be found in the LICENSE file.
+
+// Package lexini provides a lexer for INI and simple TOML-style
+// configuration files:
+//
+//	# comment
+//	[section]
+//	key = value
+//	name = "quoted value"
+//
+// The lexer switches between a line mode, in which section headers,
+// keys, and comments are recognized, and a value mode, which is entered
+// after the assignment operator and lasts until the end of the line.
+// Whitespace is not emitted.
+package lexini
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/goulash/lex"
+)
+
+const (
+	TypeSection lex.Type = (1 + lex.TypeEOF) + iota // section name, without brackets
+	TypeKey                                         // key of a key-value pair
+	TypeAssign                                      // = or :
+	TypeValue                                       // bare value, without surrounding whitespace
+	TypeString                                      // quoted value, unquoted
+	TypeComment                                     // comment, including the leading ; or #
+)
+
+const (
+	commentStart = ";#"
+	assignOps    = "=:"
+)
+
+// Lex is the state function for lexing a configuration file.
+func Lex(l *lex.Lexer) lex.StateFn {
+	return lexLine(l)
+}
+
+// lexLine lexes the start of a line.
+func lexLine(l *lex.Lexer) lex.StateFn {
+	skipSpace(l, lex.Space+lex.Endline)
+	switch r := l.Peek(); {
+	case r < 0:
+		l.Emit(lex.TypeEOF)
+		return nil
+	case strings.ContainsRune(commentStart, r):
+		return lexComment(lexLine)
+	case r == '[':
+		return lexSection
+	default:
+		return lexKey
+	}
+}
+
+func lexSection(l *lex.Lexer) lex.StateFn {
+	l.Next()
+	l.Ignore()
+	l.AcceptButRun("]" + lex.Endline)
+	if !l.HasPrefix("]") {
+		return l.Errorf("unterminated section header")
+	}
+	l.EmitMapped(TypeSection, strings.TrimSpace)
+	l.Consume("]")
+	l.Ignore()
+	return lexLineEnd
+}
+
+func lexKey(l *lex.Lexer) lex.StateFn {
+	if r := l.Peek(); lex.IsQuote(r) {
+		if !scanQuoted(l, r) {
+			return l.Errorf("unterminated quoted key")
+		}
+		l.EmitMapped(TypeKey, unquote)
+	} else {
+		if l.AcceptButRun(assignOps+commentStart+lex.Space+lex.Endline) == 0 {
+			return l.Errorf("expected key, got %q", l.Peek())
+		}
+		l.Emit(TypeKey)
+	}
+	skipSpace(l, lex.Space)
+	if !l.Accept(assignOps) {
+		return l.Errorf("expected = after key")
+	}
+	l.Emit(TypeAssign)
+	return lexValue
+}
+
+// lexValue lexes the value of a key-value pair.
+func lexValue(l *lex.Lexer) lex.StateFn {
+	skipSpace(l, lex.Space)
+	r := l.Peek()
+	if lex.IsQuote(r) {
+		if !scanQuoted(l, r) {
+			return l.Errorf("unterminated quoted value")
+		}
+		l.EmitMapped(TypeString, unquote)
+		return lexLineEnd
+	}
+	for !l.HasPrefix(" ;") && !l.HasPrefix(" #") && l.AcceptBut(lex.Endline) {
+	}
+	if l.Len() > 0 {
+		value := strings.TrimRight(l.Value(), lex.Space)
+		l.Dec(l.Len() - len(value))
+		l.Emit(TypeValue)
+	}
+	return lexLineEnd
+}
+
+// lexLineEnd lexes what remains of a line after a section or value,
+// which may only be a comment.
+func lexLineEnd(l *lex.Lexer) lex.StateFn {
+	skipSpace(l, lex.Space)
+	switch r := l.Peek(); {
+	case r < 0 || lex.IsEndline(r):
+		return lexLine
+	case strings.ContainsRune(commentStart, r):
+		return lexComment(lexLine)
+	default:
+		return l.Errorf("unexpected %q at end of line", r)
+	}
+}
+
+// lexComment returns a state function that lexes a comment up to the
+// end of the line and continues with next.
+func lexComment(next lex.StateFn) lex.StateFn {
+	return func(l *lex.Lexer) lex.StateFn {
+		l.AcceptButRun(lex.Endline)
+		l.Emit(TypeComment)
+		return next
+	}
+}
+
+func skipSpace(l *lex.Lexer, space string) {
+	l.AcceptRun(space)
+	l.Ignore()
+}
+
+// scanQuoted consumes a string quoted by q, in which a backslash escapes
+// the following rune if q is a double quote.
+func scanQuoted(l *lex.Lexer, q rune) bool {
+	l.Next()
+	for {
+		switch r := l.Next(); {
+		case r == q:
+			return true
+		case r == '\\' && q == '"':
+			l.AcceptBut(lex.Endline)
+		case r < 0 || lex.IsEndline(r):
+			return false
+		}
+	}
+}
+
+// unquote removes the quotes of s, decoding escape sequences if s is
+// double-quoted. Invalid escape sequences are kept as they are.
+func unquote(s string) string {
+	if s[0] == '"' {
+		if u, err := strconv.Unquote(s); err == nil {
+			return u
+		}
+	}
+	return s[1 : len(s)-1]
+}
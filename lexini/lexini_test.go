@@ -0,0 +1,36 @@
+// Copyright (c) 2015, Ben Morgan. All rights reserved.
+// Use of this source code is governed by an MIT license
+// that can be found in the LICENSE file.
+
+package lexini
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/goulash/lex"
+)
+
+func TestLex(t *testing.T) {
+	tests := []struct {
+		input, want string
+	}{
+		{"# comment\n[ section ]  ; note\nkey = some value  \nname: \"a\\tb\" # c\n'quoted key'=\n",
+			`7:"# comment" 2:"section" 7:"; note" 3:"key" 4:"=" 5:"some value" 3:"name" 4:":" 6:"a\tb" 7:"# c" 3:"quoted key" 4:"=" 1:""`},
+		{"url = http://x/#y ;z", `3:"url" 4:"=" 5:"http://x/#y" 7:";z" 1:""`},
+		{"[open\n", `0:"unterminated section header"`},
+		{"key value\n", `3:"key" 0:"expected = after key"`},
+		{"k = \"open\n", `3:"k" 4:"=" 0:"unterminated quoted value"`},
+		{"[s] x\n", `2:"s" 0:"unexpected 'x' at end of line"`},
+	}
+	for _, tt := range tests {
+		var got []string
+		for _, tok := range lex.Collect(lex.Lex("f", tt.input, Lex)) {
+			got = append(got, fmt.Sprintf("%d:%q", tok.Type, tok.Value))
+		}
+		if s := strings.Join(got, " "); s != tt.want {
+			t.Errorf("%q:\ngot  %s\nwant %s", tt.input, s, tt.want)
+		}
+	}
+}
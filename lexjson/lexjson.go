@@ -0,0 +1,219 @@
+// Copyright (c) 2015, Ben Morgan. All rights reserved.
+// Use of this source code is governed by an MIT license
+// that can be found in the LICENSE file.
+
+// Package lexjson provides a lexer for JSON as specified by RFC 8259.
+//
+// Strings are emitted with their value decoded, including \u escapes
+// and surrogate pairs; the raw text is available in Token.Raw.
+// Numbers are emitted as they appear in the input. Whitespace is not
+// emitted. Input that is not valid JSON at the token level, such as
+// an unescaped control character in a string or a number with a
+// leading zero, results in an error token.
+package lexjson
+
+import (
+	"strconv"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"github.com/goulash/lex"
+)
+
+const (
+	TypeLBrace   lex.Type = (1 + lex.TypeEOF) + iota // {
+	TypeRBrace                                       // }
+	TypeLBracket                                     // [
+	TypeRBracket                                     // ]
+	TypeColon                                        // :
+	TypeComma                                        // ,
+	TypeString                                       // string, decoded
+	TypeNumber                                       // number
+	TypeTrue                                         // true
+	TypeFalse                                        // false
+	TypeNull                                         // null
+)
+
+// whitespace contains the insignificant whitespace of JSON.
+const whitespace = " \t\r\n"
+
+var punctuation = map[rune]lex.Type{
+	'{': TypeLBrace,
+	'}': TypeRBrace,
+	'[': TypeLBracket,
+	']': TypeRBracket,
+	':': TypeColon,
+	',': TypeComma,
+}
+
+var literals = []struct {
+	word string
+	typ  lex.Type
+}{
+	{"true", TypeTrue},
+	{"false", TypeFalse},
+	{"null", TypeNull},
+}
+
+// Lex is the state function for lexing JSON.
+func Lex(l *lex.Lexer) lex.StateFn {
+	for {
+		l.AcceptRun(whitespace)
+		l.Ignore()
+		r := l.Peek()
+		if t, ok := punctuation[r]; ok {
+			l.Next()
+			l.Emit(t)
+			continue
+		}
+		switch {
+		case r < 0:
+			l.Emit(lex.TypeEOF)
+			return nil
+		case r == '"':
+			return lexString
+		case r == '-' || isDigit(r):
+			return lexNumber
+		}
+		for _, lit := range literals {
+			if l.Consume(lit.word) {
+				if !l.AcceptFunc(lex.IsAlphaNumeric) {
+					l.Emit(lit.typ)
+					return Lex
+				}
+				break
+			}
+		}
+		l.AcceptFuncRun(lex.IsAlphaNumeric)
+		if l.Len() == 0 {
+			l.Next()
+		}
+		return l.Errorf("invalid character %q", l.Value())
+	}
+}
+
+func lexString(l *lex.Lexer) lex.StateFn {
+	l.Next()
+	for {
+		switch r := l.Next(); {
+		case r == '"':
+			l.EmitMapped(TypeString, decodeString)
+			return Lex
+		case r == '\\':
+			if !scanEscape(l) {
+				return l.Errorf("invalid escape sequence in string")
+			}
+		case r < 0:
+			return l.Errorf("unterminated string")
+		case r < 0x20:
+			return l.Errorf("invalid control character %q in string", r)
+		}
+	}
+}
+
+// scanEscape consumes an escape sequence following a backslash.
+func scanEscape(l *lex.Lexer) bool {
+	if l.Accept(`"\/bfnrt`) {
+		return true
+	}
+	if !l.Accept("u") {
+		return false
+	}
+	for i := 0; i < 4; i++ {
+		if !l.Accept("0123456789abcdefABCDEF") {
+			return false
+		}
+	}
+	return true
+}
+
+func lexNumber(l *lex.Lexer) lex.StateFn {
+	l.Accept("-")
+	if !l.Accept("0") && l.AcceptFuncRun(isDigit) == 0 {
+		return l.Errorf("invalid number %q", l.Value())
+	}
+	if l.Accept(".") && l.AcceptFuncRun(isDigit) == 0 {
+		return l.Errorf("missing digits after decimal point in %q", l.Value())
+	}
+	if l.Accept("eE") {
+		l.Accept("+-")
+		if l.AcceptFuncRun(isDigit) == 0 {
+			return l.Errorf("missing digits in exponent of %q", l.Value())
+		}
+	}
+	if r := l.Peek(); isDigit(r) || r == '.' || lex.IsAlphaNumeric(r) {
+		l.Next()
+		return l.Errorf("invalid number %q", l.Value())
+	}
+	l.Emit(TypeNumber)
+	return Lex
+}
+
+func isDigit(r rune) bool {
+	return r >= '0' && r <= '9'
+}
+
+// decodeString decodes a string literal that has been validated by
+// lexString. Unpaired surrogates and invalid UTF-8 are replaced by
+// U+FFFD.
+func decodeString(s string) string {
+	s = s[1 : len(s)-1]
+	if !strings.ContainsRune(s, '\\') && utf8.ValidString(s) {
+		return s
+	}
+	var b strings.Builder
+	b.Grow(len(s))
+	for i := 0; i < len(s); {
+		c := s[i]
+		if c >= utf8.RuneSelf {
+			r, n := utf8.DecodeRuneInString(s[i:])
+			b.WriteRune(r)
+			i += n
+			continue
+		}
+		if c != '\\' {
+			b.WriteByte(c)
+			i++
+			continue
+		}
+		c = s[i+1]
+		i += 2
+		switch c {
+		case 'b':
+			b.WriteByte('\b')
+		case 'f':
+			b.WriteByte('\f')
+		case 'n':
+			b.WriteByte('\n')
+		case 'r':
+			b.WriteByte('\r')
+		case 't':
+			b.WriteByte('\t')
+		case 'u':
+			r := hex4(s[i:])
+			i += 4
+			if utf16.IsSurrogate(r) {
+				r2 := utf8.RuneError
+				if strings.HasPrefix(s[i:], `\u`) {
+					r2 = hex4(s[i+2:])
+				}
+				if d := utf16.DecodeRune(r, r2); d != utf8.RuneError {
+					r = d
+					i += 6
+				} else {
+					r = utf8.RuneError
+				}
+			}
+			b.WriteRune(r)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+func hex4(s string) rune {
+	n, _ := strconv.ParseUint(s[:4], 16, 32)
+	return rune(n)
+}
@@ -0,0 +1,72 @@
+// Copyright (c) 2015, Ben Morgan. All rights reserved.
+// Use of this source code is governed by an MIT license
+// that can be found in the LICENSE file.
+
+package lexjson
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/goulash/lex"
+)
+
+func describe(input string) string {
+	var got []string
+	for _, t := range lex.Collect(lex.Lex("f", input, Lex)) {
+		got = append(got, fmt.Sprintf("%d:%q", t.Type, t.Value))
+	}
+	return strings.Join(got, " ")
+}
+
+func TestLex(t *testing.T) {
+	tests := []struct {
+		input, want string
+	}{
+		{` {"a": [1, -2.5e+3, true, null]} `, `2:"{" 8:"a" 6:":" 4:"[" 9:"1" 7:"," 9:"-2.5e+3" 7:"," 10:"true" 7:"," 12:"null" 5:"]" 3:"}" 1:""`},
+		{`"\u00e9\ud83d\ude00\n\/"`, `8:"é😀\n/" 1:""`},
+		{`"\ud83d"`, "8:\"\uFFFD\" 1:\"\""},
+		{`"open`, `0:"unterminated string"`},
+		{"\"a\tb\"", `0:"invalid control character '\\t' in string"`},
+		{`"\x"`, `0:"invalid escape sequence in string"`},
+		{`01`, `0:"invalid number \"01\""`},
+		{`1.`, `0:"missing digits after decimal point in \"1.\""`},
+		{`1e`, `0:"missing digits in exponent of \"1e\""`},
+		{`-`, `0:"invalid number \"-\""`},
+		{`truex`, `0:"invalid character \"truex\""`},
+		{`@`, `0:"invalid character \"@\""`},
+	}
+	for _, tt := range tests {
+		if got := describe(tt.input); got != tt.want {
+			t.Errorf("%s:\ngot  %s\nwant %s", tt.input, got, tt.want)
+		}
+	}
+}
+
+// FuzzLex checks that every string and number accepted by the lexer is
+// valid JSON that decodes to the same value.
+func FuzzLex(f *testing.F) {
+	f.Add(`{"a": [1, -2.5e+3, "\u00e9\ud83d\ude00"]}`)
+	f.Add(`"\ud800\u0041"`)
+	f.Fuzz(func(t *testing.T, input string) {
+		for _, tok := range lex.Collect(lex.Lex("f", input, Lex)) {
+			switch tok.Type {
+			case TypeString:
+				var s string
+				if err := json.Unmarshal([]byte(tok.Raw), &s); err != nil {
+					t.Fatalf("%q: %v", tok.Raw, err)
+				}
+				if s != tok.Value {
+					t.Fatalf("%q: got %q, want %q", tok.Raw, tok.Value, s)
+				}
+			case TypeNumber:
+				var n json.Number
+				if err := json.Unmarshal([]byte(tok.Value), &n); err != nil {
+					t.Fatalf("%q: %v", tok.Value, err)
+				}
+			}
+		}
+	})
+}
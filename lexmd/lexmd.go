@@ -0,0 +1,288 @@
+// Copyright (c) 2015, Ben Morgan. All rights reserved.
+// Use of this source code is governed by an MIT license
+// that can be found in the LICENSE file.
+
+// Package lexmd provides a lexer for the common subset of Markdown.
+//
+// The lexer works in two modes. In block mode, it recognizes the
+// structure of lines: headings, code fences, list items, block quotes,
+// thematic breaks, and the start of paragraphs. The text of headings,
+// list items, and paragraphs is then lexed in inline mode, which emits
+// text, emphasis delimiters, code spans, and links up to the end of the
+// line, where block mode is resumed from the mode stack of the lexer,
+// see Lexer.PushState:
+//
+//	# Title
+//	Some *emphasized* text with `code`
+//	and a [link](https://example.com).
+//
+// is lexed as a TypeHeading "#", TypeText "Title", TypeNewline, a
+// TypeParagraph marker, and the inline tokens of both lines.
+//
+// Emphasis delimiters are emitted as they are; matching them is left to
+// the parser. Setext headings, indented code blocks, HTML, and reference
+// links are not recognized, and their text is lexed as paragraphs.
+package lexmd
+
+import (
+	"strings"
+
+	"github.com/goulash/lex"
+)
+
+const (
+	TypeHeading   lex.Type = (1 + lex.TypeEOF) + iota // ATX heading marker, such as ##
+	TypeFence                                         // code fence, with its info string
+	TypeCode                                          // contents of a fenced code block
+	TypeListItem                                      // list item marker, such as - or 1.
+	TypeQuote                                         // block quote marker >
+	TypeBreak                                         // thematic break, such as ***
+	TypeParagraph                                     // start of a paragraph, without extent
+	TypeNewline                                       // line ending
+	TypeText                                          // inline text, with escapes decoded
+	TypeEmphasis                                      // run of * or _
+	TypeCodeSpan                                      // code span, without backticks
+	TypeLinkOpen                                      // [ starting a link text
+	TypeLinkClose                                     // ] ending a link text
+	TypeURL                                           // destination of a link, without parentheses
+)
+
+// inlineSpecial are the runes that end a run of inline text.
+const inlineSpecial = "\\`*_[]\r\n"
+
+// Lex is the state function for lexing a Markdown document.
+func Lex(l *lex.Lexer) lex.StateFn {
+	lx := &lexer{}
+	return lx.lexLine
+}
+
+type lexer struct {
+	para   bool // the previous line belongs to a paragraph
+	quotes int  // number of block quote markers on the line
+	prev   int  // number of block quote markers on the previous line
+}
+
+// lexLine lexes the start of a line.
+func (lx *lexer) lexLine(l *lex.Lexer) lex.StateFn {
+	lx.prev, lx.quotes = lx.quotes, 0
+	return lx.lexBlock
+}
+
+// lexBlock lexes the start of a line, or what follows a block quote
+// or list item marker.
+func (lx *lexer) lexBlock(l *lex.Lexer) lex.StateFn {
+	skipIndent(l)
+	switch r := l.Peek(); {
+	case r < 0:
+		l.Emit(lex.TypeEOF)
+		return nil
+	case lex.IsEndline(r):
+		lx.para = false
+		lexNewline(l)
+		return lx.lexLine
+	case r == '>':
+		l.Next()
+		lx.quotes++
+		l.Emit(TypeQuote)
+		l.Accept(" ")
+		l.Ignore()
+		return lx.lexBlock
+	case r == '#':
+		if n := l.AcceptRun("#"); n <= 6 && atBlank(l) {
+			lx.para = false
+			l.Emit(TypeHeading)
+			l.AcceptRun(lex.Space)
+			l.Ignore()
+			l.PushState(lx.lexLine)
+			return lexInline
+		}
+		l.Dec(l.Len())
+	case r == '`' || r == '~':
+		if n := l.AcceptRun(string(r)); n >= 3 {
+			lx.para = false
+			return lx.lexFence(strings.Repeat(string(r), n))
+		}
+		l.Dec(l.Len())
+	case strings.ContainsRune("-*_", r) && isBreak(l, r):
+		lx.para = false
+		l.AcceptButRun(lex.Endline)
+		l.Emit(TypeBreak)
+		lexNewline(l)
+		return lx.lexLine
+	case strings.ContainsRune("-*+", r):
+		l.Next()
+		if atBlank(l) {
+			return lx.lexListItem
+		}
+		l.Dec(l.Len())
+	case '0' <= r && r <= '9':
+		if n := l.AcceptRun("0123456789"); n <= 9 && l.Accept(".)") && atBlank(l) {
+			return lx.lexListItem
+		}
+		l.Dec(l.Len())
+	}
+	if !lx.para || lx.quotes != lx.prev {
+		lx.para = true
+		l.EmitAt(TypeParagraph, l.Pos(), "")
+	}
+	l.PushState(lx.lexLine)
+	return lexInline
+}
+
+// lexListItem emits the pending list item marker and continues with the
+// contents of the item in block mode.
+func (lx *lexer) lexListItem(l *lex.Lexer) lex.StateFn {
+	lx.para = false
+	l.Emit(TypeListItem)
+	l.AcceptRun(lex.Space)
+	l.Ignore()
+	return lx.lexBlock
+}
+
+// lexFence returns a state function that lexes a fenced code block
+// opened by fence, which is pending.
+func (lx *lexer) lexFence(fence string) lex.StateFn {
+	return func(l *lex.Lexer) lex.StateFn {
+		l.AcceptButRun(lex.Endline)
+		l.EmitMapped(TypeFence, strings.TrimSpace)
+		if lexNewline(l) {
+			for !closesFence(l, fence) && l.Peek() >= 0 {
+				l.AcceptButRun(lex.Endline)
+				l.Consume("\r")
+				l.Accept("\n")
+			}
+			l.EmitNonEmpty(TypeCode)
+			if l.Peek() >= 0 {
+				skipIndent(l)
+				l.AcceptRun(fence[:1])
+				l.AcceptRun(lex.Space)
+				l.Emit(TypeFence)
+				lexNewline(l)
+			}
+		}
+		return lx.lexLine
+	}
+}
+
+// lexInline lexes inline content up to the end of the line, and then
+// returns to the state on top of the mode stack.
+func lexInline(l *lex.Lexer) lex.StateFn {
+	for {
+		switch r := l.Peek(); {
+		case r < 0:
+			return l.PopState()
+		case lex.IsEndline(r):
+			lexNewline(l)
+			return l.PopState()
+		case r == '\\':
+			l.Next()
+			if r := l.Peek(); r >= 0 && !lex.IsEndline(r) && !lex.IsAlphaNumeric(r) && r != ' ' {
+				l.Next()
+				l.EmitMapped(TypeText, func(s string) string { return s[1:] })
+			} else {
+				l.Emit(TypeText)
+			}
+		case r == '`':
+			n := l.AcceptRun("`")
+			if !scanCodeSpan(l, n) {
+				l.Emit(TypeText)
+				break
+			}
+			l.EmitMapped(TypeCodeSpan, func(s string) string {
+				code := s[n : len(s)-n]
+				if len(code) > 2 && code[0] == ' ' && code[len(code)-1] == ' ' {
+					code = code[1 : len(code)-1]
+				}
+				return code
+			})
+		case r == '*' || r == '_':
+			l.AcceptRun(string(r))
+			l.Emit(TypeEmphasis)
+		case r == '[':
+			l.Next()
+			l.Emit(TypeLinkOpen)
+		case r == ']':
+			l.Next()
+			l.Emit(TypeLinkClose)
+			if l.HasPrefix("(") {
+				l.AcceptButRun(")" + lex.Endline)
+				if l.Accept(")") {
+					l.EmitMapped(TypeURL, func(s string) string { return strings.TrimSpace(s[1 : len(s)-1]) })
+				} else {
+					l.Emit(TypeText)
+				}
+			}
+		default:
+			l.AcceptButRun(inlineSpecial)
+			l.Emit(TypeText)
+		}
+	}
+}
+
+// scanCodeSpan consumes the rest of a code span opened by n backticks,
+// which must be closed by n backticks on the same line. If it is not
+// closed, only the opening backticks remain consumed.
+func scanCodeSpan(l *lex.Lexer, n int) bool {
+	start := l.Len()
+	for {
+		l.AcceptButRun("`" + lex.Endline)
+		if r := l.Peek(); r != '`' {
+			l.Dec(l.Len() - start)
+			return false
+		}
+		if l.AcceptRun("`") == n {
+			return true
+		}
+	}
+}
+
+// closesFence reports whether the line at the current position closes
+// the code block opened by fence, without consuming it.
+func closesFence(l *lex.Lexer, fence string) bool {
+	i := 0
+	for i < 3 && l.HasPrefixAfter(i, " ") {
+		i++
+	}
+	if !l.HasPrefixAfter(i, fence) {
+		return false
+	}
+	for i += len(fence); l.HasPrefixAfter(i, fence[:1]) || l.HasPrefixAfter(i, " ") || l.HasPrefixAfter(i, "\t"); i++ {
+	}
+	return l.HasPrefixAfter(i, "\n") || l.HasPrefixAfter(i, "\r") || l.Remaining() == i
+}
+
+// lexNewline emits the line ending at the current position, and reports
+// whether there was one.
+func lexNewline(l *lex.Lexer) bool {
+	l.Consume("\r")
+	l.Accept("\n")
+	return l.EmitNonEmpty(TypeNewline)
+}
+
+// skipIndent ignores up to three spaces of indentation.
+func skipIndent(l *lex.Lexer) {
+	for i := 0; i < 3 && l.Accept(" "); i++ {
+	}
+	l.Ignore()
+}
+
+// atBlank reports whether the next rune is a space or ends the line.
+func atBlank(l *lex.Lexer) bool {
+	r := l.Peek()
+	return r < 0 || lex.IsSpace(r) || lex.IsEndline(r)
+}
+
+// isBreak reports whether the line at the current position is a
+// thematic break made of r, such as * * * or ---.
+func isBreak(l *lex.Lexer, r rune) bool {
+	n := 0
+	for i := 0; ; i++ {
+		switch {
+		case l.HasPrefixAfter(i, string(r)):
+			n++
+		case l.HasPrefixAfter(i, " "), l.HasPrefixAfter(i, "\t"):
+		default:
+			return n >= 3
+		}
+	}
+}
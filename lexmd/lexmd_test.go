@@ -0,0 +1,51 @@
+// Copyright (c) 2015, Ben Morgan. All rights reserved.
+// Use of this source code is governed by an MIT license
+// that can be found in the LICENSE file.
+
+package lexmd
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/goulash/lex"
+)
+
+func TestLex(t *testing.T) {
+	tests := []struct {
+		input, want string
+	}{
+		{"# Title\nSome *emphasized* text with `code`\nand a [link](https://example.com).", `2:"#" 10:"Title" 9:"\n" 8:"" 10:"Some " 11:"*" 10:"emphasized" 11:"*" 10:" text with " 12:"code" 9:"\n" 10:"and a " 13:"[" 10:"link" 14:"]" 15:"https://example.com" 10:"." 1:""`},
+		{"```go\nx := 1\n\n```\nafter", "3:\"```go\" 9:\"\\n\" 4:\"x := 1\\n\\n\" 3:\"```\" 9:\"\\n\" 8:\"\" 10:\"after\" 1:\"\""},
+		{"~~~\ncode", `3:"~~~" 9:"\n" 4:"code" 1:""`},
+		{"- a\n2. b\n  * c", `5:"-" 8:"" 10:"a" 9:"\n" 5:"2." 8:"" 10:"b" 9:"\n" 5:"*" 8:"" 10:"c" 1:""`},
+		{"> quote\n> more\n\n***\n- - -", `6:">" 8:"" 10:"quote" 9:"\n" 6:">" 10:"more" 9:"\n" 9:"\n" 7:"***" 9:"\n" 7:"- - -" 1:""`},
+		{"####### no\n#no", `8:"" 10:"####### no" 9:"\n" 10:"#no" 1:""`},
+		{`\*a\\ \b`, `8:"" 10:"*" 10:"a" 10:"\\" 10:" " 10:"\\" 10:"b" 1:""`},
+		{"`` a`b `` `open", "8:\"\" 12:\"a`b\" 10:\" \" 10:\"`\" 10:\"open\" 1:\"\""},
+		{"[a] (b) [c](d", `8:"" 13:"[" 10:"a" 14:"]" 10:" (b) " 13:"[" 10:"c" 14:"]" 10:"(d" 1:""`},
+	}
+	for _, tt := range tests {
+		var got []string
+		for _, tok := range lex.Collect(lex.Lex("f", tt.input, Lex)) {
+			got = append(got, fmt.Sprintf("%d:%q", tok.Type, tok.Value))
+		}
+		if s := strings.Join(got, " "); s != tt.want {
+			t.Errorf("%q:\ngot  %s\nwant %s", tt.input, s, tt.want)
+		}
+	}
+}
+
+func FuzzLex(f *testing.F) {
+	for _, input := range []string{"# Title\n> - *a* `b` [c](d)\n\n```x\ny\n```\n", "1) \\_x\r\n---\n"} {
+		f.Add(input)
+	}
+	f.Fuzz(func(t *testing.T, input string) {
+		const typeSpace = TypeURL + 1
+		l := lex.Lex("fuzz", input, Lex, lex.WithEmitWhitespace(typeSpace, typeSpace))
+		if err := lex.Verify(input, lex.Collect(l)); err != nil {
+			t.Fatal(err)
+		}
+	})
+}
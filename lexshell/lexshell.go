@@ -0,0 +1,202 @@
+// Copyright (c) 2015, Ben Morgan. All rights reserved.
+// Use of this source code is governed by an MIT license
+// that can be found in the LICENSE file.
+
+// Package lexshell provides a lexer for POSIX shell-like command lines.
+//
+// A word is emitted as one or more parts: literal parts of type TypeWord,
+// with quotes removed and escapes decoded, and expansion markers of type
+// TypeVar, with the name of the variable as value. Words are separated
+// by TypeSpace tokens, so that
+//
+//	echo "Hello, $USER"!
+//
+// is lexed as the word "echo", a space, and the word made of the parts
+// "Hello, ", $USER, and "!".
+//
+// Control operators such as | and && are emitted as TypeOperator,
+// comments as TypeComment, and line endings as TypeNewline.
+// A backslash followed by a line ending continues the line.
+package lexshell
+
+import (
+	"strings"
+
+	"github.com/goulash/lex"
+)
+
+const (
+	TypeWord     lex.Type = (1 + lex.TypeEOF) + iota // literal part of a word
+	TypeVar                                          // variable expansion, such as $HOME or ${HOME}
+	TypeSpace                                        // blanks between words
+	TypeNewline                                      // line ending
+	TypeOperator                                     // control or redirection operator
+	TypeComment                                      // comment, starting with #
+)
+
+const (
+	blanks        = " \t"
+	operatorRunes = "|&;<>()"
+	specialParams = "?$!#@*-0123456789"
+)
+
+// operators in order of precedence, longer before shorter.
+var operators = []string{
+	"&&", "||", ";;", ">>", "<<", ">&", "<&",
+	"|", "&", ";", "<", ">", "(", ")",
+}
+
+// Lex is the state function for lexing a command line.
+func Lex(l *lex.Lexer) lex.StateFn {
+	lx := &lexer{}
+	return lx.lexStart(l)
+}
+
+type lexer struct {
+	dquote bool // inside double quotes
+}
+
+// lexStart lexes the input between words.
+func (lx *lexer) lexStart(l *lex.Lexer) lex.StateFn {
+	switch r := l.Peek(); {
+	case r < 0:
+		l.Emit(lex.TypeEOF)
+		return nil
+	case lex.IsSpace(r):
+		l.AcceptRun(blanks)
+		l.Emit(TypeSpace)
+	case lex.IsEndline(r):
+		l.Consume("\r")
+		l.Accept("\n")
+		l.Emit(TypeNewline)
+	case r == '#':
+		l.AcceptButRun(lex.Endline)
+		l.Emit(TypeComment)
+	case l.HasPrefix("\\\n"):
+		l.Inc(2)
+		l.Ignore()
+	case strings.ContainsRune(operatorRunes, r):
+		l.ConsumeAny(operators...)
+		l.Emit(TypeOperator)
+	default:
+		return lx.lexWord
+	}
+	return lx.lexStart
+}
+
+// lexWord lexes a literal part of a word, up to the end of the word
+// or the next expansion.
+func (lx *lexer) lexWord(l *lex.Lexer) lex.StateFn {
+	decode := decoder(lx.dquote)
+	for {
+		r := l.Peek()
+		switch {
+		case r < 0:
+			if lx.dquote {
+				return l.Errorf("unterminated double-quoted string")
+			}
+			return lx.endPart(l, decode, lx.lexStart)
+		case r == '$':
+			return lx.endPart(l, decode, lx.lexVar)
+		case r == '\\':
+			l.Next()
+			if l.Next() < 0 {
+				return l.Errorf("backslash at end of input")
+			}
+		case r == '"':
+			l.Next()
+			lx.dquote = !lx.dquote
+		case lx.dquote:
+			l.Next()
+		case r == '\'':
+			l.Next()
+			l.AcceptButRun("'")
+			if !l.Accept("'") {
+				return l.Errorf("unterminated single-quoted string")
+			}
+		case lex.IsSpace(r) || lex.IsEndline(r) || strings.ContainsRune(operatorRunes, r):
+			return lx.endPart(l, decode, lx.lexStart)
+		default:
+			l.Next()
+		}
+	}
+}
+
+// endPart emits the pending literal part of a word, if any,
+// and continues with next.
+func (lx *lexer) endPart(l *lex.Lexer, decode func(string) string, next lex.StateFn) lex.StateFn {
+	if l.Len() > 0 {
+		l.EmitMapped(TypeWord, decode)
+	}
+	return next
+}
+
+// lexVar lexes a variable expansion, such as $HOME, ${HOME}, or $?.
+// A $ that does not start an expansion is a literal part of the word.
+func (lx *lexer) lexVar(l *lex.Lexer) lex.StateFn {
+	l.Next()
+	switch {
+	case l.Accept("{"):
+		l.AcceptButRun("}" + lex.Endline)
+		if !l.Accept("}") {
+			return l.Errorf("unterminated ${")
+		}
+		l.EmitMapped(TypeVar, func(s string) string { return s[2 : len(s)-1] })
+	case l.AcceptFunc(isNameStart):
+		l.AcceptFuncRun(isNameRune)
+		l.EmitMapped(TypeVar, func(s string) string { return s[1:] })
+	case l.Accept(specialParams):
+		// Positional parameters have one digit, so $12 is $1 then 2.
+		l.EmitMapped(TypeVar, func(s string) string { return s[1:] })
+	default:
+		l.Emit(TypeWord)
+	}
+	return lx.lexWord
+}
+
+func isNameStart(r rune) bool {
+	return r == '_' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z')
+}
+
+func isNameRune(r rune) bool {
+	return isNameStart(r) || (r >= '0' && r <= '9')
+}
+
+// decoder returns a function that removes quotes and decodes escapes in
+// a literal part of a word. If dquote is true, the part starts inside
+// double quotes.
+func decoder(dquote bool) func(string) string {
+	return func(s string) string {
+		var b strings.Builder
+		var squote bool
+		dquote := dquote
+		for i := 0; i < len(s); i++ {
+			c := s[i]
+			switch {
+			case squote:
+				if c == '\'' {
+					squote = false
+				} else {
+					b.WriteByte(c)
+				}
+			case c == '"':
+				dquote = !dquote
+			case c == '\'' && !dquote:
+				squote = true
+			case c == '\\' && i+1 < len(s):
+				i++
+				switch e := s[i]; {
+				case e == '\n':
+				case !dquote || strings.IndexByte("$`\"\\", e) >= 0:
+					b.WriteByte(e)
+				default:
+					b.WriteByte(c)
+					b.WriteByte(e)
+				}
+			default:
+				b.WriteByte(c)
+			}
+		}
+		return b.String()
+	}
+}
@@ -0,0 +1,51 @@
+// Copyright (c) 2015, Ben Morgan. All rights reserved.
+// Use of this source code is governed by an MIT license
+// that can be found in the LICENSE file.
+
+package lexshell
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/goulash/lex"
+)
+
+func TestLex(t *testing.T) {
+	tests := []struct {
+		input, want string
+	}{
+		{`echo "Hello, $USER"!`, `2:"echo" 4:" " 2:"Hello, " 3:"USER" 2:"!" 1:""`},
+		{"a && b|c >>f # note\n", `2:"a" 4:" " 6:"&&" 4:" " 2:"b" 6:"|" 2:"c" 4:" " 6:">>" 2:"f" 4:" " 7:"# note" 5:"\n" 1:""`},
+		{`'a $b' "c\$d\e" x\ y`, `2:"a $b" 4:" " 2:"c$d\\e" 4:" " 2:"x y" 1:""`},
+		{"a\\\nb \\\nc", `2:"ab" 4:" " 2:"c" 1:""`},
+		{`${HOME}/x $? $ $1x`, `3:"HOME" 2:"/x" 4:" " 3:"?" 4:" " 2:"$" 4:" " 3:"1" 2:"x" 1:""`},
+		{`$1abc $12 $_a1`, `3:"1" 2:"abc" 4:" " 3:"1" 2:"2" 4:" " 3:"_a1" 1:""`},
+		{`"a$x`, `2:"a" 3:"x" 0:"unterminated double-quoted string"`},
+		{`'a`, `0:"unterminated single-quoted string"`},
+		{`a\`, `0:"backslash at end of input"`},
+		{"${a\n}", `0:"unterminated ${"`},
+	}
+	for _, tt := range tests {
+		var got []string
+		for _, tok := range lex.Collect(lex.Lex("f", tt.input, Lex)) {
+			got = append(got, fmt.Sprintf("%d:%q", tok.Type, tok.Value))
+		}
+		if s := strings.Join(got, " "); s != tt.want {
+			t.Errorf("%q:\ngot  %s\nwant %s", tt.input, s, tt.want)
+		}
+	}
+}
+
+func FuzzLex(f *testing.F) {
+	for _, input := range []string{`echo "Hello, $USER"! | wc -l # c`, `'a' "b\$" ${c}d\` + "\n"} {
+		f.Add(input)
+	}
+	f.Fuzz(func(t *testing.T, input string) {
+		l := lex.Lex("fuzz", input, Lex, lex.WithEmitWhitespace(TypeSpace, TypeNewline))
+		if err := lex.Verify(input, lex.Collect(l)); err != nil {
+			t.Fatal(err)
+		}
+	})
+}
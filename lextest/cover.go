@@ -0,0 +1,86 @@
+// Copyright (c) 2015, Ben Morgan. All rights reserved.
+// Use of this source code is governed by an MIT license
+// that can be found in the LICENSE file.
+
+package lextest
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"sync"
+
+	"github.com/goulash/lex"
+)
+
+// Coverage records which state functions were run by lexers.
+// It is safe for concurrent use.
+type Coverage struct {
+	states []lex.StateFn
+	index  map[uintptr]int // function pointer to index in states
+
+	mu   sync.Mutex
+	hits []int
+}
+
+// CoverStates returns a Coverage for the given states. Lexers are
+// instrumented by passing Coverage.Option to lex.New:
+//
+//	cov := lextest.CoverStates(lexText, lexString)
+//	for _, input := range corpus {
+//	    l := lex.Lex("test", input, lexText, cov.Option())
+//	    l.Drain()
+//	}
+//	if states := cov.Unreached(); len(states) > 0 {
+//	    t.Errorf("states not covered: %v", states)
+//	}
+//
+// States are identified by their function pointer, so they must be
+// top-level functions and not closures or method values. They are
+// reported by lex.StateName, so names given by lex.Named are used.
+func CoverStates(states ...lex.StateFn) *Coverage {
+	c := &Coverage{
+		states: states,
+		index:  make(map[uintptr]int, len(states)),
+		hits:   make([]int, len(states)),
+	}
+	for i, s := range states {
+		c.index[reflect.ValueOf(s).Pointer()] = i
+	}
+	return c
+}
+
+// Option returns the option that instruments a lexer.
+func (c *Coverage) Option() lex.Option {
+	return lex.WithStateHook(func(prev, next lex.StateFn, l *lex.Lexer) {
+		i, ok := c.index[reflect.ValueOf(prev).Pointer()]
+		if !ok {
+			return
+		}
+		c.mu.Lock()
+		c.hits[i]++
+		c.mu.Unlock()
+	})
+}
+
+// Unreached returns the names of the states that were never run.
+func (c *Coverage) Unreached() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var names []string
+	for i, s := range c.states {
+		if c.hits[i] == 0 {
+			names = append(names, lex.StateName(s))
+		}
+	}
+	return names
+}
+
+// Report writes the number of times each state was run to w.
+func (c *Coverage) Report(w io.Writer) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for i, s := range c.states {
+		fmt.Fprintf(w, "%-24s %d\n", lex.StateName(s), c.hits[i])
+	}
+}
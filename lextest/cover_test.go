@@ -0,0 +1,47 @@
+// Copyright (c) 2015, Ben Morgan. All rights reserved.
+// Use of this source code is governed by an MIT license
+// that can be found in the LICENSE file.
+
+package lextest
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/goulash/lex"
+)
+
+func coverText(l *lex.Lexer) lex.StateFn {
+	if l.Consume(`"`) {
+		return coverString
+	}
+	l.AcceptButRun(`"`)
+	l.Emit(lex.TypeEOF + 1)
+	return nil
+}
+
+func coverString(l *lex.Lexer) lex.StateFn {
+	l.AcceptButRun(`"`)
+	l.Consume(`"`)
+	l.Emit(lex.TypeEOF + 2)
+	return nil
+}
+
+func coverUnused(l *lex.Lexer) lex.StateFn { return nil }
+
+func TestCoverStates(t *testing.T) {
+	lex.Named("string", coverString)
+	cov := CoverStates(coverText, coverString, coverUnused)
+	for _, input := range []string{"text", `"string"`} {
+		lex.Lex("test", input, coverText, cov.Option()).Drain()
+	}
+	if got, want := cov.Unreached(), []string{lex.StateName(coverUnused)}; !reflect.DeepEqual(got, want) {
+		t.Errorf("unreached %q, want %q", got, want)
+	}
+	var b strings.Builder
+	cov.Report(&b)
+	if !strings.Contains(b.String(), "string ") || !strings.Contains(b.String(), ".coverText ") {
+		t.Errorf("report does not use the state names:\n%s", b.String())
+	}
+}
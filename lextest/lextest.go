@@ -0,0 +1,28 @@
+// Copyright (c) 2015, Ben Morgan. All rights reserved.
+// Use of this source code is governed by an MIT license
+// that can be found in the LICENSE file.
+
+// Package lextest provides utilities for testing and benchmarking
+// lexers written with package lex.
+package lextest
+
+import (
+	"testing"
+
+	"github.com/goulash/lex"
+)
+
+// BenchmarkLexer lexes input with sf b.N times, reporting the throughput
+// in bytes per second. It can be used in a benchmark function like so:
+//
+//	func BenchmarkLexer(b *testing.B) {
+//	    lextest.BenchmarkLexer(b, sample, lexText)
+//	}
+func BenchmarkLexer(b *testing.B, input string, sf lex.StateFn) {
+	b.SetBytes(int64(len(input)))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		l := lex.Lex("benchmark", input, sf)
+		l.Drain()
+	}
+}
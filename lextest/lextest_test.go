@@ -0,0 +1,84 @@
+// Copyright (c) 2015, Ben Morgan. All rights reserved.
+// Use of this source code is governed by an MIT license
+// that can be found in the LICENSE file.
+
+package lextest
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/goulash/lex"
+	"github.com/goulash/lex/lexcommon"
+)
+
+// sourceASCII is typical source code, and sourceUnicode is the same with
+// non-ASCII identifiers and strings.
+var (
+	sourceASCII = strings.Repeat(`// compute returns the sum of the values.
+func compute(values []float64, scale int) (float64, error) {
+	total := 0.0
+	for i, v := range values {
+		if v < 0 {
+			return 0, fmt.Errorf("negative value %d at %d", v, i)
+		}
+		total += v * 1.5e3 / float64(scale)
+	}
+	return total, nil
+}
+
+`, 200)
+	sourceUnicode = strings.NewReplacer("values", "wärte", "total", "gesamt", "negative", "négatif").Replace(sourceASCII)
+)
+
+func BenchmarkLexcommonASCII(b *testing.B) {
+	BenchmarkLexer(b, sourceASCII, lexcommon.Lex)
+}
+
+func BenchmarkLexcommonUnicode(b *testing.B) {
+	BenchmarkLexer(b, sourceUnicode, lexcommon.Lex)
+}
+
+// benchmarkSink is like BenchmarkLexer, but delivers the tokens with
+// RunSink, which leaves out the channel and measures the state functions.
+func benchmarkSink(b *testing.B, input string, sf lex.StateFn) {
+	b.SetBytes(int64(len(input)))
+	b.ReportAllocs()
+	l := lex.New("benchmark", input)
+	for i := 0; i < b.N; i++ {
+		l.Reset("benchmark", input)
+		l.RunSink(sf, func(lex.Token) bool { return true })
+	}
+}
+
+func BenchmarkSinkASCII(b *testing.B) {
+	benchmarkSink(b, sourceASCII, lexcommon.Lex)
+}
+
+func BenchmarkSinkUnicode(b *testing.B) {
+	benchmarkSink(b, sourceUnicode, lexcommon.Lex)
+}
+
+func BenchmarkNext(b *testing.B) {
+	benchmarkSink(b, sourceASCII, func(l *lex.Lexer) lex.StateFn {
+		for l.Next() >= 0 {
+		}
+		return nil
+	})
+}
+
+func BenchmarkAcceptRun(b *testing.B) {
+	benchmarkSink(b, sourceASCII, func(l *lex.Lexer) lex.StateFn {
+		for l.Next() >= 0 {
+			l.AcceptRun("abcdefghijklmnopqrstuvwxyz")
+		}
+		return nil
+	})
+}
+
+func TestBenchmarkLexer(t *testing.T) {
+	r := testing.Benchmark(func(b *testing.B) { BenchmarkLexer(b, "abc def", lexcommon.Lex) })
+	if r.N == 0 || r.Bytes != 7 {
+		t.Errorf("got %d iterations of %d bytes", r.N, r.Bytes)
+	}
+}
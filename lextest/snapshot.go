@@ -0,0 +1,175 @@
+// Copyright (c) 2015, Ben Morgan. All rights reserved.
+// Use of this source code is governed by an MIT license
+// that can be found in the LICENSE file.
+
+package lextest
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/goulash/lex"
+)
+
+// SnapshotVersion is the version of the snapshot format written by
+// Snapshot.WriteTo. Snapshots of other versions cannot be read.
+const SnapshotVersion = 2
+
+// snapshotHeader is the first line of a snapshot, followed by the version.
+const snapshotHeader = "lex snapshot v"
+
+// A Snapshot is the token stream of an input in a stable text format,
+// which is meant to be committed as a golden file:
+//
+//	lex snapshot v2
+//	input "x := 42\n"
+//	1:1	Ident	"x"
+//	1:3	Define	":="
+//	1:6	Number	"42"
+//	1:8	Newline	"\n"
+//	2:1	EOF	""
+//
+// The tokens are written by lex.DumpTokens with the input and quoted
+// with only ASCII characters, see lex.DumpOptions, so that the format is
+// the same as that of token dumps. Lines end with \n, so that snapshots
+// do not change with the platform or editor settings; lines ending with
+// \r\n are accepted when reading.
+type Snapshot struct {
+	Input  string
+	Tokens string // tokens as written by lex.DumpTokens
+}
+
+// TakeSnapshot lexes input with sf and returns the snapshot of its tokens.
+func TakeSnapshot(input string, sf lex.StateFn, opts ...lex.Option) *Snapshot {
+	toks := lex.Collect(lex.Lex("snapshot", input, sf, opts...))
+	var b strings.Builder
+	lex.DumpTokens(&b, toks, lex.DumpOptions{Input: input, ASCII: true})
+	return &Snapshot{Input: input, Tokens: b.String()}
+}
+
+// WriteTo writes s to w in the snapshot format.
+func (s *Snapshot) WriteTo(w io.Writer) (int64, error) {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "%s%d\n", snapshotHeader, SnapshotVersion)
+	fmt.Fprintf(&b, "input %s\n", strconv.QuoteToASCII(s.Input))
+	b.WriteString(s.Tokens)
+	return b.WriteTo(w)
+}
+
+// String returns s in the snapshot format.
+func (s *Snapshot) String() string {
+	var b strings.Builder
+	s.WriteTo(&b)
+	return b.String()
+}
+
+// ReadSnapshot reads a snapshot written by Snapshot.WriteTo from r.
+func ReadSnapshot(r io.Reader) (*Snapshot, error) {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(nil, 1<<30)
+	n := 0
+	next := func() (string, bool) {
+		if !sc.Scan() {
+			return "", false
+		}
+		n++
+		return strings.TrimSuffix(sc.Text(), "\r"), true
+	}
+	errorf := func(format string, args ...interface{}) error {
+		return fmt.Errorf("lextest: snapshot line %d: %s", n, fmt.Sprintf(format, args...))
+	}
+	line, _ := next()
+	if line != snapshotHeader+strconv.Itoa(SnapshotVersion) {
+		if err := sc.Err(); err != nil {
+			return nil, err
+		}
+		return nil, errorf("not a snapshot of version %d: %q", SnapshotVersion, line)
+	}
+	s := new(Snapshot)
+	line, _ = next()
+	input, ok := strings.CutPrefix(line, "input ")
+	if !ok {
+		return nil, errorf("missing input")
+	}
+	var err error
+	if s.Input, err = strconv.Unquote(input); err != nil {
+		return nil, errorf("invalid input: %v", err)
+	}
+	var b strings.Builder
+	for {
+		line, ok := next()
+		if !ok {
+			break
+		}
+		if strings.Count(line, "\t") < 2 {
+			return nil, errorf("invalid token %q", line)
+		}
+		b.WriteString(line)
+		b.WriteByte('\n')
+	}
+	s.Tokens = b.String()
+	return s, sc.Err()
+}
+
+// LoadSnapshot reads the snapshot in the file path.
+func LoadSnapshot(path string) (*Snapshot, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	s, err := ReadSnapshot(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return s, nil
+}
+
+// StoreSnapshot writes s to the file path.
+func StoreSnapshot(path string, s *Snapshot) error {
+	return os.WriteFile(path, []byte(s.String()), 0o644)
+}
+
+// CheckSnapshot compares got with the snapshot in the file path, and
+// returns an error describing the first difference. If update is true or
+// the file does not exist, got is stored instead. It can be used in a
+// test function with a flag for updating the snapshots like so:
+//
+//	var update = flag.Bool("update", false, "update snapshots")
+//
+//	func TestSnapshots(t *testing.T) {
+//	    got := lextest.TakeSnapshot(input, lexText)
+//	    if err := lextest.CheckSnapshot("testdata/input.snap", got, *update); err != nil {
+//	        t.Error(err)
+//	    }
+//	}
+func CheckSnapshot(path string, got *Snapshot, update bool) error {
+	want, err := LoadSnapshot(path)
+	if update || errors.Is(err, fs.ErrNotExist) {
+		return StoreSnapshot(path, got)
+	}
+	if err != nil {
+		return err
+	}
+	w, g := strings.Split(want.String(), "\n"), strings.Split(got.String(), "\n")
+	for i := 0; i < len(w) || i < len(g); i++ {
+		var wl, gl string
+		if i < len(w) {
+			wl = w[i]
+		}
+		if i < len(g) {
+			gl = g[i]
+		}
+		if wl != gl {
+			return fmt.Errorf("lextest: snapshot %s differs at line %d:\n\twant: %s\n\tgot:  %s", path, i+1, wl, gl)
+		}
+	}
+	return nil
+}
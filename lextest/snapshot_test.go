@@ -0,0 +1,50 @@
+// Copyright (c) 2015, Ben Morgan. All rights reserved.
+// Use of this source code is governed by an MIT license
+// that can be found in the LICENSE file.
+
+package lextest
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/goulash/lex"
+	"github.com/goulash/lex/lexcommon"
+)
+
+func TestSnapshot(t *testing.T) {
+	const input = "x := 42\ns = \"é\"\n"
+	s := TakeSnapshot(input, lexcommon.Lex)
+	var dump strings.Builder
+	lex.DumpTokens(&dump, lex.Collect(lex.Lex("f", input, lexcommon.Lex)), lex.DumpOptions{Input: input, ASCII: true})
+	if s.Tokens != dump.String() {
+		t.Errorf("tokens differ from the dump:\n%s\nwant\n%s", s.Tokens, dump.String())
+	}
+	if strings.Contains(s.String(), "é") {
+		t.Errorf("snapshot is not ASCII:\n%s", s)
+	}
+	for _, text := range []string{s.String(), strings.ReplaceAll(s.String(), "\n", "\r\n")} {
+		r, err := ReadSnapshot(strings.NewReader(text))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if *r != *s {
+			t.Errorf("read %+v, want %+v", r, s)
+		}
+	}
+}
+
+func TestCheckSnapshot(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "input.snap")
+	s := TakeSnapshot("a b", lexcommon.Lex)
+	if err := CheckSnapshot(path, s, false); err != nil {
+		t.Fatalf("storing: %v", err)
+	}
+	if err := CheckSnapshot(path, s, false); err != nil {
+		t.Errorf("same snapshot: %v", err)
+	}
+	if err := CheckSnapshot(path, TakeSnapshot("a c", lexcommon.Lex), false); err == nil {
+		t.Error("different snapshot passed")
+	}
+}
@@ -0,0 +1,115 @@
+// Copyright (c) 2015, Ben Morgan. All rights reserved.
+// Use of this source code is governed by an MIT license
+// that can be found in the LICENSE file.
+
+package lextest
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+	"time"
+
+	"github.com/goulash/lex"
+)
+
+// StressRuns is the number of inputs lexed by Stress.
+var StressRuns = 1000
+
+// StressTimeout is the time a single input may take to lex in Stress.
+var StressTimeout = time.Second
+
+// An InputGenerator generates a random input using rnd.
+type InputGenerator func(rnd *rand.Rand) string
+
+// Fragments returns an InputGenerator that concatenates up to n fragments
+// chosen at random. Choosing fragments of the language, such as keywords,
+// quotes, and escape sequences, generates more interesting inputs than
+// random bytes do.
+func Fragments(n int, frags ...string) InputGenerator {
+	return func(rnd *rand.Rand) string {
+		var b strings.Builder
+		for i := rnd.Intn(n + 1); i > 0; i-- {
+			b.WriteString(frags[rnd.Intn(len(frags))])
+		}
+		return b.String()
+	}
+}
+
+// Stress lexes StressRuns inputs generated by gen with sf and checks that
+// the lexer upholds the invariants of package lex for each of them:
+// it neither panics nor hangs, ends with exactly one TypeEOF or TypeError
+// token, and emits tokens in order within the input, whose raw text is
+// the input they span. It can be used in a test function like so:
+//
+//	func TestStress(t *testing.T) {
+//	    gen := lextest.Fragments(20, "a", "1", " ", "\"", "\\", "\n")
+//	    if err := lextest.Stress(lexText, gen, 1); err != nil {
+//	        t.Fatal(err)
+//	    }
+//	}
+//
+// The inputs only depend on seed. The error for a failing input reports
+// the seed that makes it the first input, to reproduce it quickly.
+//
+// A state function that does not return within StressTimeout is reported
+// as a hang. As it cannot be stopped, its goroutine is left running.
+func Stress(sf lex.StateFn, gen InputGenerator, seed int64) error {
+	for i := 0; i < StressRuns; i++ {
+		s := seed + int64(i)
+		input := gen(rand.New(rand.NewSource(s)))
+		l := lex.Lex("stress", input, sf, lex.WithDeadline(StressTimeout))
+		toks, ok := collectWithin(l, StressTimeout)
+		if !ok {
+			return fmt.Errorf("lextest: seed %d: input %q: lexer did not finish within %v", s, input, StressTimeout)
+		}
+		if err := checkInvariants(input, toks); err != nil {
+			return fmt.Errorf("lextest: seed %d: input %q: %v", s, input, err)
+		}
+	}
+	return nil
+}
+
+// collectWithin collects the tokens of l, and gives up if that takes
+// longer than timeout. WithDeadline is only checked between state
+// functions, so this catches state functions that loop by themselves.
+func collectWithin(l *lex.Lexer, timeout time.Duration) ([]lex.Token, bool) {
+	ch := make(chan []lex.Token, 1)
+	go func() { ch <- lex.Collect(l) }()
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case toks := <-ch:
+		return toks, true
+	case <-timer.C:
+		return nil, false
+	}
+}
+
+// checkInvariants checks the tokens emitted for input.
+func checkInvariants(input string, toks []lex.Token) error {
+	if len(toks) == 0 {
+		return fmt.Errorf("no tokens emitted")
+	}
+	pos := 0
+	for i, t := range toks {
+		switch {
+		case t.Type == lex.TypeError && strings.HasPrefix(t.Value, "panic"):
+			return fmt.Errorf("state function panicked: %s", t.Value)
+		case t.Type == lex.TypeError && strings.HasPrefix(t.Value, "lexing exceeded deadline"):
+			return fmt.Errorf("lexer did not finish within %v", StressTimeout)
+		case (t.Type == lex.TypeEOF || t.Type == lex.TypeError) && i < len(toks)-1:
+			return fmt.Errorf("token %d of %d is %v", i+1, len(toks), t)
+		case t.Type != lex.TypeEOF && t.Type != lex.TypeError && i == len(toks)-1:
+			return fmt.Errorf("last token is %v, not EOF or an error", t)
+		case t.Pos < pos:
+			return fmt.Errorf("%v at offset %d precedes previous token at offset %d", t, t.Pos, pos)
+		case t.End < t.Pos || t.End > len(input):
+			return fmt.Errorf("%v has invalid extent %d to %d", t, t.Pos, t.End)
+		case t.Type != lex.TypeError && t.Raw != input[t.Pos:t.End]:
+			return fmt.Errorf("%v has raw text %q, but spans %q", t, t.Raw, input[t.Pos:t.End])
+		}
+		pos = t.Pos
+	}
+	return nil
+}
@@ -0,0 +1,43 @@
+// Copyright (c) 2015, Ben Morgan. All rights reserved.
+// Use of this source code is governed by an MIT license
+// that can be found in the LICENSE file.
+
+package lextest
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/goulash/lex"
+	"github.com/goulash/lex/lexcommon"
+)
+
+func TestStress(t *testing.T) {
+	gen := Fragments(20, "a", "1", "1.5", " ", "\"", "\\", "\n", "//", "/*", "*/", "'")
+	if err := Stress(lexcommon.Lex, gen, 1); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestStressHang(t *testing.T) {
+	defer func(runs int, timeout time.Duration) {
+		StressRuns, StressTimeout = runs, timeout
+	}(StressRuns, StressTimeout)
+	StressRuns, StressTimeout = 10, 50*time.Millisecond
+
+	release := make(chan struct{})
+	defer close(release)
+	hang := func(l *lex.Lexer) lex.StateFn {
+		if l.Consume("x") {
+			<-release // never returns while Stress runs
+		}
+		l.AcceptButRun("")
+		l.Emit(lex.TypeEOF + 1)
+		return nil
+	}
+	err := Stress(hang, Fragments(3, "a", "x"), 1)
+	if err == nil || !strings.Contains(err.Error(), "did not finish") {
+		t.Errorf("got %v, want a hang", err)
+	}
+}
@@ -0,0 +1,223 @@
+// Copyright (c) 2015, Ben Morgan. All rights reserved.
+// Use of this source code is governed by an MIT license
+// that can be found in the LICENSE file.
+
+package lextest
+
+import (
+	"fmt"
+	"strings"
+	"text/template/parse"
+
+	"github.com/goulash/lex"
+	"github.com/goulash/lex/lextmpl"
+)
+
+// CheckTemplate lexes input with lextmpl.Lex and cross-checks the tokens
+// against the parse tree that text/template/parse builds for input, to
+// guard against drift from the lexer of text/template, which package lex
+// was derived from. Every node of the tree that stems from a single item
+// of its lexer, such as text, comments, constants, fields, variables, and
+// identifiers, must have a token of the corresponding type, at the same
+// offset, with the same value. If the lexer emits an error, parsing must
+// fail as well, with the same error if the lexer of text/template/parse
+// found it; if that lexer finds an error, the lexer must emit it too. It
+// can be used in a test or fuzz function like so:
+//
+//	func FuzzTemplate(f *testing.F) {
+//	    f.Add("{{if .A}}{{.B | printf \"%d\"}}{{end}}")
+//	    f.Fuzz(func(t *testing.T, input string) {
+//	        if err := lextest.CheckTemplate(input); err != nil {
+//	            t.Fatal(err)
+//	        }
+//	    })
+//	}
+//
+// Function names are not checked, and break and continue are keywords.
+func CheckTemplate(input string) error {
+	toks := lex.Collect(lex.Lex("template", input, lextmpl.Lex))
+	trees, err := parseTemplate(input)
+	at := make(map[int][]lex.Token)
+	for _, t := range toks {
+		if t.Type == lex.TypeError {
+			if err == nil {
+				return fmt.Errorf("lextest: %q: lexer failed at offset %d with %q, but parse succeeded", input, t.Pos, t.Value)
+			}
+			if msg, ok := lexicalError(err); ok && !strings.HasPrefix(msg, t.Value) {
+				return fmt.Errorf("lextest: %q: lexer failed at offset %d with %q, but parse failed with %q", input, t.Pos, t.Value, msg)
+			}
+			return nil
+		}
+		at[t.Pos] = append(at[t.Pos], t)
+	}
+	if msg, ok := lexicalError(err); ok {
+		return fmt.Errorf("lextest: %q: parse failed with %q, but the lexer succeeded", input, msg)
+	}
+	if err != nil {
+		return nil
+	}
+	c := &templateChecker{at: at}
+	for _, tree := range trees {
+		if tree.Root != nil {
+			c.walk(tree.Root)
+		}
+	}
+	if c.err != nil {
+		return fmt.Errorf("lextest: %q: %v", input, c.err)
+	}
+	return nil
+}
+
+// lexicalErrors are the beginnings of the errors of the lexer of
+// text/template/parse.
+var lexicalErrors = []string{
+	"bad character",
+	"bad number syntax",
+	"comment ends before closing delimiter",
+	"expected :=",
+	"unclosed action",
+	"unclosed comment",
+	"unclosed left paren",
+	"unexpected right paren",
+	"unrecognized character in action",
+	"unterminated character constant",
+	"unterminated quoted string",
+	"unterminated raw quoted string",
+}
+
+// lexicalError returns the message of err, an error returned by
+// parseTemplate, and whether it was found by the lexer of
+// text/template/parse rather than by the parser.
+func lexicalError(err error) (string, bool) {
+	if err == nil {
+		return "", false
+	}
+	// The error is "template: name:line: msg".
+	msg := err.Error()
+	if i := strings.Index(msg, ": "); i >= 0 {
+		msg = msg[i+2:]
+	}
+	if i := strings.Index(msg, ": "); i >= 0 {
+		msg = msg[i+2:]
+	}
+	for _, prefix := range lexicalErrors {
+		if strings.HasPrefix(msg, prefix) {
+			return msg, true
+		}
+	}
+	return msg, false
+}
+
+// parseTemplate parses input like text/template does, but without
+// checking function names.
+func parseTemplate(input string) (map[string]*parse.Tree, error) {
+	trees := make(map[string]*parse.Tree)
+	t := parse.New("template")
+	t.Mode = parse.ParseComments | parse.SkipFuncCheck
+	_, err := t.Parse(input, "", "", trees)
+	return trees, err
+}
+
+// templateChecker walks a parse tree and checks its nodes against the
+// tokens at their offsets, recording the first mismatch.
+type templateChecker struct {
+	at  map[int][]lex.Token
+	err error
+}
+
+// expect checks that a token with value and one of types is at pos.
+func (c *templateChecker) expect(node parse.Node, pos parse.Pos, value string, types ...lex.Type) {
+	if c.err != nil {
+		return
+	}
+	for _, t := range c.at[int(pos)] {
+		for _, typ := range types {
+			if t.Type == typ && t.Value == value {
+				return
+			}
+		}
+	}
+	c.err = fmt.Errorf("%T %q at offset %d: no token %v %q, have %v", node, node, pos, types, value, c.at[int(pos)])
+}
+
+// walk checks n and its children. A field or variable with more than one
+// segment is built from a chain, and has the offset of its second one.
+func (c *templateChecker) walk(n parse.Node) {
+	switch n := n.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return
+		}
+		for _, m := range n.Nodes {
+			c.walk(m)
+		}
+	case *parse.TextNode:
+		c.expect(n, n.Pos, string(n.Text), lextmpl.TypeText)
+	case *parse.CommentNode:
+		c.expect(n, n.Pos, n.Text, lextmpl.TypeComment)
+	case *parse.ActionNode:
+		c.walk(n.Pipe)
+	case *parse.PipeNode:
+		if n == nil {
+			return
+		}
+		for _, v := range n.Decl {
+			c.walk(v)
+		}
+		for _, cmd := range n.Cmds {
+			c.walk(cmd)
+		}
+	case *parse.CommandNode:
+		for _, arg := range n.Args {
+			c.walk(arg)
+		}
+	case *parse.ChainNode:
+		c.walk(n.Node)
+	case *parse.IfNode:
+		c.walkBranch(&n.BranchNode)
+	case *parse.RangeNode:
+		c.walkBranch(&n.BranchNode)
+	case *parse.WithNode:
+		c.walkBranch(&n.BranchNode)
+	case *parse.TemplateNode:
+		c.walk(n.Pipe)
+	case *parse.BreakNode:
+		c.expect(n, n.Pos, "break", lextmpl.TypeBreak)
+	case *parse.ContinueNode:
+		c.expect(n, n.Pos, "continue", lextmpl.TypeContinue)
+	case *parse.IdentifierNode:
+		c.expect(n, n.Pos, n.Ident, lextmpl.TypeIdentifier)
+	case *parse.FieldNode:
+		if len(n.Ident) > 1 {
+			c.expect(n, n.Pos, "."+n.Ident[1], lextmpl.TypeField)
+		} else {
+			c.expect(n, n.Pos, "."+n.Ident[0], lextmpl.TypeField)
+		}
+	case *parse.VariableNode:
+		if len(n.Ident) > 1 {
+			c.expect(n, n.Pos, "."+n.Ident[1], lextmpl.TypeField)
+		} else {
+			c.expect(n, n.Pos, n.Ident[0], lextmpl.TypeVariable)
+		}
+	case *parse.DotNode:
+		c.expect(n, n.Pos, ".", lextmpl.TypeDot)
+	case *parse.NilNode:
+		c.expect(n, n.Pos, "nil", lextmpl.TypeNil)
+	case *parse.BoolNode:
+		c.expect(n, n.Pos, n.String(), lextmpl.TypeBool)
+	case *parse.NumberNode:
+		c.expect(n, n.Pos, n.Text, lextmpl.TypeNumber, lextmpl.TypeComplex, lextmpl.TypeCharConstant)
+	case *parse.StringNode:
+		typ := lextmpl.TypeString
+		if n.Quoted[0] == '`' {
+			typ = lextmpl.TypeRawString
+		}
+		c.expect(n, n.Pos, n.Quoted, typ)
+	}
+}
+
+func (c *templateChecker) walkBranch(n *parse.BranchNode) {
+	c.walk(n.Pipe)
+	c.walk(n.List)
+	c.walk(n.ElseList)
+}
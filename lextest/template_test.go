@@ -0,0 +1,57 @@
+// Copyright (c) 2015, Ben Morgan. All rights reserved.
+// Use of this source code is governed by an MIT license
+// that can be found in the LICENSE file.
+
+package lextest
+
+import "testing"
+
+var templates = []string{
+	"",
+	"plain text",
+	"{{.}}",
+	"{{.A.B.C}} {{$x := .A}}{{$x.B}}",
+	"{{if .A}}a{{else if .B}}b{{else}}c{{end}}",
+	"{{range $i, $v := .List}}{{$i}}={{$v}}{{break}}{{continue}}{{end}}",
+	"{{with .A}}{{.}}{{end}}",
+	"{{.B | printf \"%d\" | len}}",
+	"{{printf `raw %s` 'x' 1.5e3 0x1F 1i true false nil}}",
+	"{{/* comment */}}text{{- .A -}} more",
+	"{{define \"T\"}}t{{end}}{{template \"T\" .}}",
+	"{{(index .M \"k\").F}}",
+	"{{.A",
+	"{{\"unterminated}}",
+	"{{if}}",
+	"{{3.14.5}}",
+	"{{/* comment",
+	"{{/* comment */ .A}}",
+	"{{(.A}}",
+	"{{.A)}}",
+	"{{$x := 1}}{{$x : 1}}",
+	"{{\x01}}",
+	"{{.A\x01}}",
+	"{{'a}}",
+	"{{`raw}}",
+	"{{\"a\nb\"}}",
+	"text {{.A\n",
+	"{{.A}}{{.B",
+}
+
+func TestCheckTemplate(t *testing.T) {
+	for _, input := range templates {
+		if err := CheckTemplate(input); err != nil {
+			t.Error(err)
+		}
+	}
+}
+
+func FuzzCheckTemplate(f *testing.F) {
+	for _, input := range templates {
+		f.Add(input)
+	}
+	f.Fuzz(func(t *testing.T, input string) {
+		if err := CheckTemplate(input); err != nil {
+			t.Fatal(err)
+		}
+	})
+}
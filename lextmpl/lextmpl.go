@@ -0,0 +1,414 @@
+// Copyright (c) 2015, Ben Morgan. All rights reserved.
+// Use of this source code is governed by an MIT license
+// that can be found in the LICENSE file.
+
+// Package lextmpl provides a lexer for the syntax of text/template.
+//
+// The lexer follows the lexer of text/template/parse, which package lex
+// was derived from, token for token: the tokens have the same types,
+// offsets, and values as its items, with the default delimiters, with
+// comments emitted, and with break and continue recognized as keywords.
+// Trim markers are not part of any token, and the white space they trim
+// is ignored:
+//
+//	Hello, {{- .Name | printf "%q" -}} !
+//
+// is lexed as a TypeText "Hello,", TypeLeftDelim, TypeField ".Name",
+// TypeSpace, TypePipe, TypeSpace, TypeIdentifier "printf", TypeSpace,
+// TypeString `"%q"`, TypeRightDelim, and TypeText "!".
+//
+// The lextest package checks the lexer against text/template/parse,
+// see lextest.CheckTemplate.
+package lextmpl
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/goulash/lex"
+)
+
+const (
+	TypeText         lex.Type = (1 + lex.TypeEOF) + iota // plain text between actions
+	TypeLeftDelim                                        // {{, without trim marker
+	TypeRightDelim                                       // }}, without trim marker
+	TypeComment                                          // comment, with /* and */
+	TypeSpace                                            // run of white space in an action
+	TypeIdentifier                                       // alphanumeric identifier not starting with . or $
+	TypeField                                            // alphanumeric identifier starting with .
+	TypeVariable                                         // variable starting with $, or $ alone
+	TypeBool                                             // true or false
+	TypeNumber                                           // number, including imaginary numbers
+	TypeComplex                                          // complex constant, such as 1+2i
+	TypeCharConstant                                     // quoted character constant
+	TypeString                                           // quoted string, with quotes
+	TypeRawString                                        // raw string, with backquotes
+	TypeAssign                                           // =
+	TypeDeclare                                          // :=
+	TypePipe                                             // |
+	TypeLeftParen                                        // (
+	TypeRightParen                                       // )
+	TypeChar                                             // other printable ASCII character, such as ,
+	TypeDot                                              // . alone
+
+	// Keywords.
+	TypeBlock
+	TypeBreak
+	TypeContinue
+	TypeDefine
+	TypeElse
+	TypeEnd
+	TypeIf
+	TypeNil
+	TypeRange
+	TypeTemplate
+	TypeWith
+)
+
+// Keywords maps the keywords of the template language to their types.
+var Keywords = map[string]lex.Type{
+	"block":    TypeBlock,
+	"break":    TypeBreak,
+	"continue": TypeContinue,
+	"define":   TypeDefine,
+	"else":     TypeElse,
+	"end":      TypeEnd,
+	"if":       TypeIf,
+	"nil":      TypeNil,
+	"range":    TypeRange,
+	"template": TypeTemplate,
+	"with":     TypeWith,
+}
+
+const (
+	leftDelim    = "{{"
+	rightDelim   = "}}"
+	leftComment  = "/*"
+	rightComment = "*/"
+	spaceChars   = " \t\r\n"
+	trimMarker   = '-'
+)
+
+// Lex is the state function for lexing a template.
+func Lex(l *lex.Lexer) lex.StateFn {
+	return lexText
+}
+
+// lexText lexes text up to the next action.
+func lexText(l *lex.Lexer) lex.StateFn {
+	x := strings.Index(l.Input(0), leftDelim)
+	if x < 0 {
+		l.Inc(l.Remaining())
+		l.EmitNonEmpty(TypeText)
+		l.Emit(lex.TypeEOF)
+		return nil
+	}
+	l.Inc(x)
+	trim := 0
+	if hasLeftTrimMarker(l.Input(len(leftDelim))) {
+		trim = len(l.Value()) - len(strings.TrimRight(l.Value(), spaceChars))
+	}
+	l.Dec(trim)
+	l.EmitNonEmpty(TypeText)
+	l.Inc(trim)
+	l.Ignore()
+	return lexLeftDelim
+}
+
+// lexLeftDelim lexes the left delimiter, which is known to be present,
+// and the trim marker or comment that may follow it.
+func lexLeftDelim(l *lex.Lexer) lex.StateFn {
+	l.Inc(len(leftDelim))
+	marker := 0
+	if hasLeftTrimMarker(l.Input(0)) {
+		marker = 2
+	}
+	if strings.HasPrefix(l.Input(marker), leftComment) {
+		l.Inc(marker)
+		l.Ignore()
+		return lexComment
+	}
+	l.Emit(TypeLeftDelim)
+	l.Inc(marker)
+	l.Ignore()
+	return lexAction(0)
+}
+
+// lexComment lexes a comment action, which must end with the comment.
+func lexComment(l *lex.Lexer) lex.StateFn {
+	l.Inc(len(leftComment))
+	x := strings.Index(l.Input(0), rightComment)
+	if x < 0 {
+		return l.Errorf("unclosed comment")
+	}
+	l.Inc(x + len(rightComment))
+	delim, trim := atRightDelim(l)
+	if !delim {
+		return l.Errorf("comment ends before closing delimiter")
+	}
+	l.Emit(TypeComment)
+	if trim {
+		l.Inc(2)
+	}
+	l.Inc(len(rightDelim))
+	if trim {
+		l.Inc(leftTrimLength(l.Input(0)))
+	}
+	l.Ignore()
+	return lexText
+}
+
+// lexRightDelim lexes the right delimiter, which is known to be present,
+// and the trim marker that may precede it.
+func lexRightDelim(l *lex.Lexer) lex.StateFn {
+	if _, trim := atRightDelim(l); trim {
+		l.Inc(2)
+		l.Ignore()
+		l.Inc(len(rightDelim))
+		l.Emit(TypeRightDelim)
+		l.Inc(leftTrimLength(l.Input(0)))
+		l.Ignore()
+		return lexText
+	}
+	l.Inc(len(rightDelim))
+	l.Emit(TypeRightDelim)
+	return lexText
+}
+
+// lexAction returns a state function that lexes the inside of an action,
+// with depth unclosed parentheses.
+func lexAction(depth int) lex.StateFn {
+	return func(l *lex.Lexer) lex.StateFn {
+		if delim, _ := atRightDelim(l); delim {
+			if depth == 0 {
+				return lexRightDelim
+			}
+			return l.Errorf("unclosed left paren")
+		}
+		switch r := l.Next(); {
+		case r < 0:
+			return l.Errorf("unclosed action")
+		case isSpace(r):
+			l.Backup()
+			return lexSpace(depth)
+		case r == '=':
+			l.Emit(TypeAssign)
+		case r == ':':
+			if l.Next() != '=' {
+				return l.Errorf("expected :=")
+			}
+			l.Emit(TypeDeclare)
+		case r == '|':
+			l.Emit(TypePipe)
+		case r == '"':
+			if !scanQuote(l, '"') {
+				return l.Errorf("unterminated quoted string")
+			}
+			l.Emit(TypeString)
+		case r == '`':
+			x := strings.IndexByte(l.Input(0), '`')
+			if x < 0 {
+				return l.Errorf("unterminated raw quoted string")
+			}
+			l.Inc(x + 1)
+			l.Emit(TypeRawString)
+		case r == '\'':
+			if !scanQuote(l, '\'') {
+				return l.Errorf("unterminated character constant")
+			}
+			l.Emit(TypeCharConstant)
+		case r == '$':
+			if !atTerminator(l) && !scanWord(l) {
+				return l.Errorf("bad character %#U", l.Peek())
+			}
+			l.Emit(TypeVariable)
+		case r == '.' && l.Remaining() > 0 && !isDigit(l.Input(0)[0]):
+			switch {
+			case atTerminator(l):
+				l.Emit(TypeDot)
+			case scanWord(l):
+				l.Emit(TypeField)
+			default:
+				return l.Errorf("bad character %#U", l.Peek())
+			}
+		case r == '.' || r == '+' || r == '-' || ('0' <= r && r <= '9'):
+			l.Backup()
+			t, ok := scanConstant(l)
+			if !ok {
+				return l.Errorf("bad number syntax: %q", l.Value())
+			}
+			l.Emit(t)
+		case isAlphaNumeric(r):
+			l.AcceptFuncRun(isAlphaNumeric)
+			if !atTerminator(l) {
+				return l.Errorf("bad character %#U", l.Peek())
+			}
+			emitWord(l)
+		case r == '(':
+			l.Emit(TypeLeftParen)
+			return lexAction(depth + 1)
+		case r == ')':
+			if depth == 0 {
+				return l.Errorf("unexpected right paren")
+			}
+			l.Emit(TypeRightParen)
+			return lexAction(depth - 1)
+		case r <= unicode.MaxASCII && unicode.IsPrint(r):
+			l.Emit(TypeChar)
+		default:
+			return l.Errorf("unrecognized character in action: %#U", r)
+		}
+		return lexAction(depth)
+	}
+}
+
+// lexSpace returns a state function that lexes a run of white space in
+// an action, with depth unclosed parentheses. The space before a trim
+// marker belongs to the right delimiter.
+func lexSpace(depth int) lex.StateFn {
+	return func(l *lex.Lexer) lex.StateFn {
+		n := l.AcceptFuncRun(isSpace)
+		if hasRightTrimMarker(l.Input(-1)) && strings.HasPrefix(l.Input(1), rightDelim) {
+			l.Dec(1)
+			if n == 1 {
+				return lexRightDelim
+			}
+		}
+		l.Emit(TypeSpace)
+		return lexAction(depth)
+	}
+}
+
+// scanConstant consumes a number or a complex constant, and returns its
+// type and whether its syntax is valid.
+func scanConstant(l *lex.Lexer) (lex.Type, bool) {
+	if !scanNumber(l) {
+		return TypeNumber, false
+	}
+	if r := l.Peek(); r == '+' || r == '-' {
+		return TypeComplex, scanNumber(l) && strings.HasSuffix(l.Value(), "i")
+	}
+	return TypeNumber, true
+}
+
+// scanNumber consumes a number with an optional sign, and reports
+// whether it is not followed by an alphanumeric rune.
+func scanNumber(l *lex.Lexer) bool {
+	l.Accept("+-")
+	digits := "0123456789_"
+	if l.Accept("0") {
+		switch {
+		case l.Accept("xX"):
+			digits = "0123456789abcdefABCDEF_"
+		case l.Accept("oO"):
+			digits = "01234567_"
+		case l.Accept("bB"):
+			digits = "01_"
+		}
+	}
+	l.AcceptRun(digits)
+	if l.Accept(".") {
+		l.AcceptRun(digits)
+	}
+	if len(digits) == 10+1 && l.Accept("eE") {
+		l.Accept("+-")
+		l.AcceptRun("0123456789_")
+	}
+	if len(digits) == 16+6+1 && l.Accept("pP") {
+		l.Accept("+-")
+		l.AcceptRun("0123456789_")
+	}
+	l.Accept("i")
+	if isAlphaNumeric(l.Peek()) {
+		l.Next()
+		return false
+	}
+	return true
+}
+
+// scanQuote consumes the rest of a string or character constant closed
+// by q, and reports whether it is closed on the same line.
+func scanQuote(l *lex.Lexer, q rune) bool {
+	for {
+		switch r := l.Next(); {
+		case r == '\\':
+			if r := l.Next(); r >= 0 && r != '\n' {
+				break
+			}
+			return false
+		case r < 0, r == '\n':
+			return false
+		case r == q:
+			return true
+		}
+	}
+}
+
+// scanWord consumes the alphanumeric rest of a field or variable, and
+// reports whether a terminator follows it.
+func scanWord(l *lex.Lexer) bool {
+	l.AcceptFuncRun(isAlphaNumeric)
+	return atTerminator(l)
+}
+
+// emitWord emits the pending word as a keyword, boolean, or identifier.
+func emitWord(l *lex.Lexer) {
+	word := l.Value()
+	switch {
+	case Keywords[word] != 0:
+		l.Emit(Keywords[word])
+	case word == "true" || word == "false":
+		l.Emit(TypeBool)
+	default:
+		l.Emit(TypeIdentifier)
+	}
+}
+
+// atRightDelim reports whether a right delimiter, possibly preceded by a
+// trim marker, is at the current position.
+func atRightDelim(l *lex.Lexer) (delim, trim bool) {
+	if hasRightTrimMarker(l.Input(0)) && strings.HasPrefix(l.Input(2), rightDelim) {
+		return true, true
+	}
+	return l.HasPrefix(rightDelim), false
+}
+
+// atTerminator reports whether the next rune may follow a word.
+func atTerminator(l *lex.Lexer) bool {
+	r := l.Peek()
+	if isSpace(r) {
+		return true
+	}
+	switch r {
+	case '.', ',', '|', ':', ')', '(':
+		return true
+	}
+	if r < 0 {
+		return true
+	}
+	return l.HasPrefix(rightDelim)
+}
+
+func hasLeftTrimMarker(s string) bool {
+	return len(s) >= 2 && s[0] == trimMarker && isSpace(rune(s[1]))
+}
+
+func hasRightTrimMarker(s string) bool {
+	return len(s) >= 2 && isSpace(rune(s[0])) && s[1] == trimMarker
+}
+
+func leftTrimLength(s string) int {
+	return len(s) - len(strings.TrimLeft(s, spaceChars))
+}
+
+func isSpace(r rune) bool {
+	return r == ' ' || r == '\t' || r == '\r' || r == '\n'
+}
+
+func isDigit(c byte) bool {
+	return '0' <= c && c <= '9'
+}
+
+func isAlphaNumeric(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
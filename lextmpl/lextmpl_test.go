@@ -0,0 +1,66 @@
+// Copyright (c) 2015, Ben Morgan. All rights reserved.
+// Use of this source code is governed by an MIT license
+// that can be found in the LICENSE file.
+
+package lextmpl
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/goulash/lex"
+)
+
+func TestLex(t *testing.T) {
+	tests := []struct {
+		input, want string
+	}{
+		// Text and delimiters.
+		{"", `1:""`},
+		{"plain text", `2:"plain text" 1:""`},
+		{"a{{.A}}b{{.}}", `2:"a" 3:"{{" 8:".A" 4:"}}" 2:"b" 3:"{{" 22:"." 4:"}}" 1:""`},
+		{"{{.A.B $x}}", `3:"{{" 8:".A" 8:".B" 6:" " 9:"$x" 4:"}}" 1:""`},
+		{"{{$x := 1}}{{$x = 2}}", `3:"{{" 9:"$x" 6:" " 17:":=" 6:" " 11:"1" 4:"}}" 3:"{{" 9:"$x" 6:" " 16:"=" 6:" " 11:"2" 4:"}}" 1:""`},
+		{"{{if .A}}a{{else}}b{{end}}", `3:"{{" 29:"if" 6:" " 8:".A" 4:"}}" 2:"a" 3:"{{" 27:"else" 4:"}}" 2:"b" 3:"{{" 28:"end" 4:"}}" 1:""`},
+		{"{{range $i, $v := .L}}{{break}}{{continue}}{{end}}", `3:"{{" 31:"range" 6:" " 9:"$i" 21:"," 6:" " 9:"$v" 6:" " 17:":=" 6:" " 8:".L" 4:"}}" 3:"{{" 24:"break" 4:"}}" 3:"{{" 25:"continue" 4:"}}" 3:"{{" 28:"end" 4:"}}" 1:""`},
+		{"{{(index .M \"k\").F | len}}", `3:"{{" 19:"(" 7:"index" 6:" " 8:".M" 6:" " 14:"\"k\"" 20:")" 8:".F" 6:" " 18:"|" 6:" " 7:"len" 4:"}}" 1:""`},
+		{"{{true nil 1.5e3 0x1F 1i 1+2i 'x' `r`}}", "3:\"{{\" 10:\"true\" 6:\" \" 30:\"nil\" 6:\" \" 11:\"1.5e3\" 6:\" \" 11:\"0x1F\" 6:\" \" 11:\"1i\" 6:\" \" 12:\"1+2i\" 6:\" \" 13:\"'x'\" 6:\" \" 15:\"`r`\" 4:\"}}\" 1:\"\""},
+
+		// Trim markers.
+		{"Hello, {{- .Name | printf \"%q\" -}} !", `2:"Hello," 3:"{{" 8:".Name" 6:" " 18:"|" 6:" " 7:"printf" 6:" " 14:"\"%q\"" 4:"}}" 2:"!" 1:""`},
+		{"a \n{{- .A}} b", `2:"a" 3:"{{" 8:".A" 4:"}}" 2:" b" 1:""`},
+		{"a {{.A -}}\n b", `2:"a " 3:"{{" 8:".A" 4:"}}" 2:"b" 1:""`},
+		{"{{-3}} {{3 -}}", `3:"{{" 11:"-3" 4:"}}" 2:" " 3:"{{" 11:"3" 4:"}}" 1:""`},
+
+		// Comments.
+		{"a{{/* c */}}b", `2:"a" 5:"/* c */" 2:"b" 1:""`},
+		{"a {{- /* c */ -}} b", `2:"a" 5:"/* c */" 2:"b" 1:""`},
+		{"{{/* a\nb */}}", `5:"/* a\nb */" 1:""`},
+
+		// Errors.
+		{"{{-.A}}", `3:"{{" 0:"bad number syntax: \"-.A\""`},
+		{"{{.A", `3:"{{" 8:".A" 0:"unclosed action"`},
+		{"{{.A\nb", `3:"{{" 8:".A" 6:"\n" 7:"b" 0:"unclosed action"`},
+		{"{{/* c", `0:"unclosed comment"`},
+		{"{{/* c */ .A}}", `0:"comment ends before closing delimiter"`},
+		{"{{(.A}}", `3:"{{" 19:"(" 8:".A" 0:"unclosed left paren"`},
+		{"{{.A)}}", `3:"{{" 8:".A" 0:"unexpected right paren"`},
+		{"{{$x : 1}}", `3:"{{" 9:"$x" 6:" " 0:"expected :="`},
+		{"{{\x01}}", `3:"{{" 0:"unrecognized character in action: U+0001"`},
+		{"{{.A\x01}}", `3:"{{" 0:"bad character U+0001"`},
+		{"{{\"a}}", `3:"{{" 0:"unterminated quoted string"`},
+		{"{{\"a\nb\"}}", `3:"{{" 0:"unterminated quoted string"`},
+		{"{{'a}}", `3:"{{" 0:"unterminated character constant"`},
+		{"{{`a}}", `3:"{{" 0:"unterminated raw quoted string"`},
+	}
+	for _, tt := range tests {
+		var got []string
+		for _, tok := range lex.Collect(lex.Lex("f", tt.input, Lex)) {
+			got = append(got, fmt.Sprintf("%d:%q", tok.Type, tok.Value))
+		}
+		if s := strings.Join(got, " "); s != tt.want {
+			t.Errorf("%q:\ngot  %s\nwant %s", tt.input, s, tt.want)
+		}
+	}
+}
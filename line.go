@@ -0,0 +1,40 @@
+// Copyright (c) 2015, Ben Morgan. All rights reserved.
+// Use of this source code is governed by an MIT license
+// that can be found in the LICENSE file.
+
+package lex
+
+import "strings"
+
+// RestOfLine returns the input from the current position up to the end
+// of the line, excluding the line ending. It does not consume anything.
+func (l *Lexer) RestOfLine() string {
+	s := l.input[l.pos:]
+	if i := strings.IndexAny(s, Endline); i >= 0 {
+		return s[:i]
+	}
+	return s
+}
+
+// AcceptLine consumes the rest of the line, including the line ending
+// if there is one. The number of bytes advanced is returned. Unlike
+// RestOfLine, it reads more input from a stream as needed.
+func (l *Lexer) AcceptLine() int {
+	var n int
+	for r := l.Next(); r >= 0 && !strings.ContainsRune(Endline, r); r = l.Next() {
+		n += l.width
+	}
+	l.Backup()
+	if l.Consume("\r\n") {
+		n += 2
+	} else if l.Accept(Endline) {
+		n++
+	}
+	return n
+}
+
+// AtLineStart reports whether the current position is at the start
+// of a line.
+func (l *Lexer) AtLineStart() bool {
+	return l.pos == 0 || l.input[l.pos-1] == '\n' || (l.input[l.pos-1] == '\r' && !l.HasPrefix("\n"))
+}
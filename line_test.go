@@ -0,0 +1,58 @@
+// Copyright (c) 2015, Ben Morgan. All rights reserved.
+// Use of this source code is governed by an MIT license
+// that can be found in the LICENSE file.
+
+package lex_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/goulash/lex"
+)
+
+// lexLines emits every line as a token, prefixed by whether it starts at
+// the start of a line and its rest of line before it is consumed.
+func lexLines(got *[]string) lex.StateFn {
+	return func(l *lex.Lexer) lex.StateFn {
+		for l.Peek() >= 0 {
+			*got = append(*got, fmt.Sprintf("%t %q", l.AtLineStart(), l.RestOfLine()))
+			l.AcceptLine()
+			l.Emit(typeWord)
+			if l.Peek() == 'x' {
+				l.Next()
+				l.Ignore()
+			}
+		}
+		return nil
+	}
+}
+
+func TestLines(t *testing.T) {
+	const input = "ab\r\ncd\rxe\n\nf"
+	var got []string
+	toks := lex.Collect(lex.Lex("a", input, lexLines(&got)))
+	if s := strings.Join(values(toks), "|"); s != "ab\r\n|cd\r|e\n|\n|f|" {
+		t.Errorf("got lines %q", s)
+	}
+	want := `true "ab" true "cd" false "e" true "" true "f"`
+	if s := strings.Join(got, " "); s != want {
+		t.Errorf("got  %s\nwant %s", s, want)
+	}
+}
+
+func TestAcceptLineStream(t *testing.T) {
+	l := lex.NewStream("a", iotest.OneByteReader(strings.NewReader("abc\r\ndef")))
+	go l.Run(func(l *lex.Lexer) lex.StateFn {
+		for l.Peek() >= 0 {
+			l.AcceptLine()
+			l.Emit(typeWord)
+		}
+		return nil
+	})
+	if got := strings.Join(values(lex.Collect(l)), "|"); got != "abc\r\n|def|" {
+		t.Errorf("got %q, want whole lines", got)
+	}
+}
@@ -0,0 +1,99 @@
+// Copyright (c) 2015, Ben Morgan. All rights reserved.
+// Use of this source code is governed by an MIT license
+// that can be found in the LICENSE file.
+
+package lex
+
+import (
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// A LineIndex resolves offsets in an input to lines and columns.
+// It is built once in linear time, after which each offset can be
+// resolved in logarithmic time.
+type LineIndex struct {
+	input string
+	lines []int // offset of the start of each line
+}
+
+// NewLineIndex returns a new LineIndex for input.
+func NewLineIndex(input string) *LineIndex {
+	lines := make([]int, 1, 1+strings.Count(input, "\n"))
+	for i := 0; i < len(input); i++ {
+		if input[i] == '\n' {
+			lines = append(lines, i+1)
+		}
+	}
+	return &LineIndex{input: input, lines: lines}
+}
+
+// Offsets returns the offset of the start of each line, which is
+// the format expected by go/token.File.SetLines.
+func (x *LineIndex) Offsets() []int {
+	return append([]int(nil), x.lines...)
+}
+
+// Lines returns the number of lines in the input.
+func (x *LineIndex) Lines() int { return len(x.lines) }
+
+// Resolve returns the line and column of the offset pos, both starting
+// at 1. Columns count runes, like Lexer.ColumnNumber does by default.
+// Offsets outside of the input are resolved as its start or end.
+func (x *LineIndex) Resolve(pos int) (line, col int) {
+	pos = min(max(pos, 0), len(x.input))
+	i := sort.Search(len(x.lines), func(i int) bool { return x.lines[i] > pos }) - 1
+	return i + 1, 1 + utf8.RuneCountInString(x.input[x.lines[i]:pos])
+}
+
+// LineOffsets returns the offset of the start of each line in the input
+// read so far, beginning with 0, so that tools can build their own line
+// tables without scanning the input again. The offsets are in the format
+// expected by go/token.File.SetLines. While an input pushed by PushInput
+// is lexed, the offsets are still those of the main input.
+//
+// Line starts are found incrementally, so calling LineOffsets repeatedly
+// is cheap. It must not be called concurrently with Run. Lines joined by
+// WithLineContinuation start where the continuation was removed.
+func (l *Lexer) LineOffsets() []int {
+	input, pb := l.input, l.pb
+	if len(l.stack) > 0 {
+		input, pb = l.stack[0].input, l.stack[0].pb
+	}
+	if len(l.lines) == 0 {
+		l.lines = append(l.lines, 0)
+	}
+	for ; l.scanned < len(input); l.scanned++ {
+		if input[l.scanned] == '\n' {
+			l.lines = append(l.lines, l.scanned+1)
+		}
+	}
+	var joins []int
+	if pb != nil {
+		joins = pb.joins
+	}
+	// Consecutive continuations are removed at the same offset, which
+	// is listed once.
+	lines := make([]int, 0, len(l.lines)+len(joins))
+	add := func(off int) {
+		if len(lines) == 0 || off > lines[len(lines)-1] {
+			lines = append(lines, off)
+		}
+	}
+	for i := 0; i < len(l.lines); {
+		if len(joins) > 0 && joins[0] <= l.lines[i] {
+			add(joins[0])
+			joins = joins[1:]
+			continue
+		}
+		add(l.lines[i])
+		i++
+	}
+	for _, j := range joins {
+		if j <= l.scanned {
+			add(j)
+		}
+	}
+	return lines
+}
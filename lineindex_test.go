@@ -0,0 +1,122 @@
+// Copyright (c) 2015, Ben Morgan. All rights reserved.
+// Use of this source code is governed by an MIT license
+// that can be found in the LICENSE file.
+
+package lex_test
+
+import (
+	"fmt"
+	"go/token"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/goulash/lex"
+)
+
+func TestLineOffsets(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"", "[0]"},
+		{"a\nb\n", "[0 2 4]"},
+		{"a \\\nb\nc", "[0 2 4]"},
+		{"a \\\n\\\nb\nc", "[0 2 4]"},
+		{"a\\\n", "[0 1]"},
+	}
+	for _, tt := range tests {
+		l := lex.Lex("f", tt.input, lexWords, lex.WithLineContinuation("\\"))
+		lex.Collect(l)
+		if got := fmt.Sprint(l.LineOffsets()); got != tt.want {
+			t.Errorf("%q: got %s, want %s", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestLineOffsetsIncremental(t *testing.T) {
+	var got []string
+	s := lex.NewStream("f", iotest.OneByteReader(strings.NewReader("a\nbb\nc")))
+	go s.Run(func(l *lex.Lexer) lex.StateFn {
+		for r := l.Next(); r >= 0; r = l.Next() {
+			if r == '\n' {
+				got = append(got, fmt.Sprint(l.LineOffsets()))
+			}
+		}
+		l.Emit(typeWord)
+		return nil
+	})
+	lex.Collect(s)
+	if s := strings.Join(got, " "); s != "[0 2] [0 2 5]" {
+		t.Errorf("got %s while reading a stream", s)
+	}
+}
+
+func TestLineOffsetsPushInput(t *testing.T) {
+	var got []string
+	includes := map[string]string{"x": "one\ntwo\nthree"}
+	l := lex.Lex("f", "a\n@x\nb\nc", func(l *lex.Lexer) lex.StateFn {
+		sf := lexInclude(includes)
+		for sf != nil {
+			if l.Peek() == 't' {
+				got = append(got, fmt.Sprint(l.LineOffsets()))
+			}
+			sf = sf(l)
+		}
+		got = append(got, fmt.Sprint(l.LineOffsets()))
+		return nil
+	})
+	lex.Collect(l)
+	if s := strings.Join(got, " "); s != "[0 2 5 7] [0 2 5 7] [0 2 5 7]" {
+		t.Errorf("got %s, want the lines of the main input", s)
+	}
+}
+
+func TestAddToFileSet(t *testing.T) {
+	l := lex.Lex("f", "a \\\n\\\nb\nc", lexWords, lex.WithLineContinuation("\\"))
+	lex.Collect(l)
+	f := l.AddToFileSet(token.NewFileSet())
+	if f.LineCount() != 3 {
+		t.Errorf("got %d lines, want 3", f.LineCount())
+	}
+
+	fset := token.NewFileSet()
+	fset.AddFile("other", -1, 10)
+	l = lex.Lex("f", "ab\ncd\n", lexWords)
+	r := lex.NewReader(l)
+	var toks []lex.Token
+	for tok := r.Next(); ; tok = r.Next() {
+		toks = append(toks, tok)
+		if tok.Type == lex.TypeEOF {
+			break
+		}
+	}
+	f = l.AddToFileSet(fset)
+	for _, tok := range toks {
+		p := lex.GoPos(f, tok)
+		file, line, col := r.PosOf(tok)
+		if tok.Type == lex.TypeEOF {
+			file, line, col = "f", 2, 4 // go/token has no line 3
+		}
+		if got, want := fset.Position(p).String(), fmt.Sprintf("%s:%d:%d", file, line, col); got != want {
+			t.Errorf("%v is at %s in the file set, want %s", tok, got, want)
+		}
+		if off := lex.GoOffset(f, p); off != tok.Pos {
+			t.Errorf("%v has offset %d in the file set", tok, off)
+		}
+	}
+}
+
+func TestLineIndex(t *testing.T) {
+	const input = "ab\nçd\n\nef"
+	x := lex.NewLineIndex(input)
+	if x.Lines() != 4 || fmt.Sprint(x.Offsets()) != "[0 3 7 8]" {
+		t.Errorf("got %d lines at %v, want 4 at [0 3 7 8]", x.Lines(), x.Offsets())
+	}
+	want := map[int]string{-1: "1:1", 0: "1:1", 2: "1:3", 3: "2:1", 5: "2:2", 6: "2:3", 7: "3:1", 8: "4:1", 10: "4:3", 11: "4:3"}
+	for pos, w := range want {
+		if line, col := x.Resolve(pos); fmt.Sprintf("%d:%d", line, col) != w {
+			t.Errorf("Resolve(%d) = %d:%d, want %s", pos, line, col, w)
+		}
+	}
+}
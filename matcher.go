@@ -0,0 +1,60 @@
+// Copyright (c) 2015, Ben Morgan. All rights reserved.
+// Use of this source code is governed by an MIT license
+// that can be found in the LICENSE file.
+
+package lex
+
+// A Matcher consumes input from a Lexer and reports whether it matched.
+type Matcher func(l *Lexer) bool
+
+// AcceptSeq applies the matchers in order, and consumes the input only if
+// all of them match. If any matcher fails, the position is restored to
+// where it was before AcceptSeq was called. This is useful for matching
+// digraphs, trigraphs, and escape sequences:
+//
+//	// Matches \x followed by two hexadecimal digits.
+//	l.AcceptSeq(lex.MatchString(`\x`), lex.MatchAny(hex), lex.MatchAny(hex))
+func (l *Lexer) AcceptSeq(matchers ...Matcher) bool {
+	// Matchers may skip input with SetAutoSkip or resume a suspended
+	// input, which is undone as well. Frames popped from the stack stay
+	// in its backing array, so restoring the slice restores them.
+	f, stack := l.frame(), l.stack
+	for _, m := range matchers {
+		if !m(l) {
+			l.stack = stack
+			l.resume(f)
+			return false
+		}
+	}
+	return true
+}
+
+// MatchString returns a Matcher that consumes exactly the string s.
+func MatchString(s string) Matcher {
+	return func(l *Lexer) bool { return l.Consume(s) }
+}
+
+// MatchAny returns a Matcher that consumes a rune from the valid set.
+func MatchAny(valid string) Matcher {
+	return func(l *Lexer) bool { return l.Accept(valid) }
+}
+
+// MatchRun returns a Matcher that consumes a non-empty run of runes
+// from the valid set.
+func MatchRun(valid string) Matcher {
+	return func(l *Lexer) bool { return l.AcceptRun(valid) > 0 }
+}
+
+// MatchFunc returns a Matcher that consumes a rune for which f
+// returns true.
+func MatchFunc(f func(r rune) bool) Matcher {
+	return func(l *Lexer) bool { return l.AcceptFunc(f) }
+}
+
+// MatchOptional returns a Matcher that applies m, but always matches.
+func MatchOptional(m Matcher) Matcher {
+	return func(l *Lexer) bool {
+		l.AcceptSeq(m)
+		return true
+	}
+}
@@ -0,0 +1,85 @@
+// Copyright (c) 2015, Ben Morgan. All rights reserved.
+// Use of this source code is governed by an MIT license
+// that can be found in the LICENSE file.
+
+package lex_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/goulash/lex"
+)
+
+func TestAcceptSeq(t *testing.T) {
+	const hex = "0123456789abcdef"
+	escape := []lex.Matcher{lex.MatchString(`\x`), lex.MatchAny(hex), lex.MatchAny(hex)}
+	number := []lex.Matcher{
+		lex.MatchOptional(lex.MatchAny("+-")),
+		lex.MatchRun("0123456789"),
+		lex.MatchOptional(lex.MatchString(".")),
+		lex.MatchFunc(func(r rune) bool { return r == 'e' }),
+	}
+	tests := []struct {
+		input    string
+		matchers []lex.Matcher
+		want     string
+	}{
+		{`\x4fz`, escape, `true "\\x4f"`},
+		{`\x4z`, escape, `false ""`},
+		{`\y4f`, escape, `false ""`},
+		{"-12.e", number, `true "-12.e"`},
+		{"12e", number, `true "12e"`},
+		{"-12.x", number, `false ""`},
+		{"+", number, `false ""`},
+		{"", number, `false ""`},
+	}
+	for _, tt := range tests {
+		var got string
+		lex.Collect(lex.Lex("a", tt.input, func(l *lex.Lexer) lex.StateFn {
+			ok := l.AcceptSeq(tt.matchers...)
+			got = fmt.Sprintf("%t %q", ok, l.Value())
+			return nil
+		}))
+		if got != tt.want {
+			t.Errorf("%q: got %s, want %s", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestAcceptSeqRestore(t *testing.T) {
+	autoSkip := func(l *lex.Lexer) lex.StateFn {
+		l.SetAutoSkip(" ")
+		l.AcceptSeq(lex.MatchString("a"), lex.MatchString("x"))
+		l.Emit(typeOther)
+		l.AcceptRun(" ab")
+		l.Emit(typeWord)
+		return nil
+	}
+	pushed := func(l *lex.Lexer) lex.StateFn {
+		l.Next()
+		l.Ignore()
+		l.PushInput("p", "abcd")
+		l.Consume("abcd")
+		l.Ignore()
+		l.AcceptSeq(lex.MatchAny("y"), lex.MatchAny("q"))
+		l.AcceptRun("xy")
+		l.Emit(typeWord)
+		return nil
+	}
+	tests := []struct {
+		input string
+		sf    lex.StateFn
+		want  string
+	}{
+		{"  ab", autoSkip, "|ab|"},
+		{"xy", pushed, "y|"},
+	}
+	for _, tt := range tests {
+		toks := lex.Collect(lex.Lex("a", tt.input, tt.sf))
+		if got := strings.Join(values(toks), "|"); got != tt.want {
+			t.Errorf("%q: got %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
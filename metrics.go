@@ -0,0 +1,74 @@
+// Copyright (c) 2015, Ben Morgan. All rights reserved.
+// Use of this source code is governed by an MIT license
+// that can be found in the LICENSE file.
+
+package lex
+
+import (
+	"expvar"
+	"time"
+)
+
+// A Collector receives metrics from lexers, see WithMetrics.
+// Its methods are called from the lexing goroutines, so a Collector
+// shared by several lexers must be safe for concurrent use.
+type Collector interface {
+	// LexerStarted is called when Run starts.
+	LexerStarted()
+
+	// LexerFinished is called when Run returns, with the number of bytes
+	// of input processed and the time taken.
+	LexerFinished(bytes int, d time.Duration)
+
+	// TokenEmitted is called for every token emitted, including error
+	// tokens, with the size of the token in the input.
+	TokenEmitted(t Type, size int)
+}
+
+// WithMetrics makes the lexer report metrics to c, for observing
+// long-running services that lex untrusted input.
+func WithMetrics(c Collector) Option {
+	return func(l *Lexer) { l.metrics = c }
+}
+
+// ExpvarCollector is a Collector that publishes its metrics as an
+// expvar.Map with the following keys:
+//
+//	active   number of lexers currently running
+//	lexers   number of lexers that finished
+//	bytes    bytes of input processed
+//	tokens   tokens emitted
+//	errors   error tokens emitted
+//	nanos    total time spent lexing, in nanoseconds
+//
+// Tokens per second can be derived from tokens and nanos.
+type ExpvarCollector struct {
+	m *expvar.Map
+}
+
+// NewExpvarCollector returns a new ExpvarCollector published under name.
+// Like expvar.NewMap, it panics if name is already in use.
+func NewExpvarCollector(name string) *ExpvarCollector {
+	return &ExpvarCollector{m: expvar.NewMap(name)}
+}
+
+// Map returns the map the metrics are published in.
+func (c *ExpvarCollector) Map() *expvar.Map { return c.m }
+
+func (c *ExpvarCollector) LexerStarted() {
+	c.m.Add("active", 1)
+}
+
+func (c *ExpvarCollector) LexerFinished(bytes int, d time.Duration) {
+	c.m.Add("active", -1)
+	c.m.Add("lexers", 1)
+	c.m.Add("bytes", int64(bytes))
+	c.m.Add("nanos", int64(d))
+}
+
+func (c *ExpvarCollector) TokenEmitted(t Type, size int) {
+	c.m.Add("tokens", 1)
+	if t == TypeError {
+		c.m.Add("errors", 1)
+	}
+}
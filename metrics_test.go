@@ -0,0 +1,63 @@
+// Copyright (c) 2015, Ben Morgan. All rights reserved.
+// Use of this source code is governed by an MIT license
+// that can be found in the LICENSE file.
+
+package lex_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/goulash/lex"
+)
+
+type recordingCollector struct {
+	calls []string
+}
+
+func (c *recordingCollector) LexerStarted() { c.calls = append(c.calls, "start") }
+
+func (c *recordingCollector) LexerFinished(bytes int, d time.Duration) {
+	c.calls = append(c.calls, fmt.Sprintf("finish %d", bytes))
+}
+
+func (c *recordingCollector) TokenEmitted(t lex.Type, size int) {
+	c.calls = append(c.calls, fmt.Sprintf("%d:%d", t, size))
+}
+
+func TestWithMetrics(t *testing.T) {
+	c := new(recordingCollector)
+	sf := func(l *lex.Lexer) lex.StateFn {
+		lexWords(l)
+		return l.Errorf("stop")
+	}
+	l := lex.Lex("a", "ab c", sf, lex.WithMetrics(c), lex.WithSkip(typeSpace))
+	l.Drain()
+	<-l.Done()
+	if got := strings.Join(c.calls, " "); got != "start 2:2 2:1 0:0 finish 4" {
+		t.Errorf("got calls %s", got)
+	}
+}
+
+func TestExpvarCollector(t *testing.T) {
+	c := lex.NewExpvarCollector("lex_test_metrics")
+	for _, in := range []string{"ab c", "d!"} {
+		l := lex.Lex("a", in, lexWords, lex.WithMetrics(c))
+		l.Drain()
+		<-l.Done()
+	}
+	l := lex.Lex("a", "x", func(l *lex.Lexer) lex.StateFn { return l.Errorf("bad") }, lex.WithMetrics(c))
+	l.Drain()
+	<-l.Done()
+	m := c.Map()
+	for key, want := range map[string]string{"active": "0", "lexers": "3", "bytes": "6", "tokens": "8", "errors": "1"} {
+		if v := m.Get(key); v == nil || v.String() != want {
+			t.Errorf("%s = %v, want %s", key, v, want)
+		}
+	}
+	if m.Get("nanos") == nil {
+		t.Error("nanos not published")
+	}
+}
@@ -0,0 +1,47 @@
+// Copyright (c) 2015, Ben Morgan. All rights reserved.
+// Use of this source code is governed by an MIT license
+// that can be found in the LICENSE file.
+
+package lex
+
+import (
+	"strconv"
+	"sync"
+)
+
+var typeNames = struct {
+	sync.RWMutex
+	m map[Type]string
+}{m: map[Type]string{
+	TypeError: "Error",
+	TypeEOF:   "EOF",
+}}
+
+// RegisterTypeName registers the name of the type t, which is returned
+// by Type.String and used in messages and dumps:
+//
+//	func init() {
+//	    lex.RegisterTypeName(TypeIdent, "Ident")
+//	    lex.RegisterTypeName(TypeNumber, "Number")
+//	}
+func RegisterTypeName(t Type, name string) {
+	typeNames.Lock()
+	defer typeNames.Unlock()
+	typeNames.m[t] = name
+}
+
+// String returns the registered name of t, or Type(n) if it has none.
+func (t Type) String() string {
+	typeNames.RLock()
+	name, ok := typeNames.m[t]
+	typeNames.RUnlock()
+	if !ok {
+		return "Type(" + strconv.Itoa(int(t)) + ")"
+	}
+	return name
+}
+
+// String returns the type and value of the token, such as Ident "foo".
+func (t Token) String() string {
+	return t.Type.String() + " " + strconv.Quote(t.Value)
+}
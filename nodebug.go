@@ -0,0 +1,11 @@
+// Copyright (c) 2015, Ben Morgan. All rights reserved.
+// Use of this source code is governed by an MIT license
+// that can be found in the LICENSE file.
+
+//go:build !lexdebug
+// +build !lexdebug
+
+package lex
+
+// debug is true when built with the lexdebug build tag.
+const debug = false
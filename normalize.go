@@ -0,0 +1,132 @@
+// Copyright (c) 2015, Ben Morgan. All rights reserved.
+// Use of this source code is governed by an MIT license
+// that can be found in the LICENSE file.
+
+package lex
+
+import (
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+const bom = "\ufeff"
+
+// A posShift records that from offset pos in the normalized input on,
+// the offset in the original input is delta bytes larger.
+type posShift struct {
+	pos   int
+	delta int
+}
+
+// normalize returns input as configured by the options of l, appending
+// the shifts of positions it caused to shifts. It also returns the offsets
+// in the normalized input where line continuations were removed.
+func (l *Lexer) normalize(input string, shifts []posShift) (string, []posShift, []int) {
+	return l.normalizeAt(input, 0, 0, shifts)
+}
+
+// normalizeAt is like normalize for input that is appended at offset at
+// of the normalized input, after removed bytes were removed before it.
+// A BOM is only stripped at the start of the input.
+func (l *Lexer) normalizeAt(input string, at, removed int, shifts []posShift) (string, []posShift, []int) {
+	if l.stripBOM && at == 0 && removed == 0 && strings.HasPrefix(input, bom) {
+		input = input[len(bom):]
+		removed = len(bom)
+		shifts = append(shifts, posShift{0, removed})
+	}
+	cr := l.newlines && strings.Contains(input, "\r")
+	cont := l.continuation != "" && strings.Contains(input, l.continuation)
+	if !cr && !cont {
+		return input, shifts, nil
+	}
+	var joins []int
+	var b strings.Builder
+	b.Grow(len(input))
+	for i := 0; i < len(input); {
+		switch {
+		case cont && strings.HasPrefix(input[i:], l.continuation):
+			n := len(l.continuation)
+			switch {
+			case strings.HasPrefix(input[i+n:], "\r\n"):
+				n += 2
+			case strings.HasPrefix(input[i+n:], "\n"), cr && strings.HasPrefix(input[i+n:], "\r"):
+				n++
+			default:
+				i += n
+				continue
+			}
+			b.WriteString(input[:i])
+			input = input[i+n:]
+			i = 0
+			removed += n
+			joins = append(joins, at+b.Len())
+			shifts = append(shifts, posShift{at + b.Len(), removed})
+		case cr && input[i] == '\r':
+			b.WriteString(input[:i])
+			b.WriteByte('\n')
+			input = input[i+1:]
+			i = 0
+			if strings.HasPrefix(input, "\n") {
+				input = input[1:]
+				removed++
+				shifts = append(shifts, posShift{at + b.Len(), removed})
+			}
+		default:
+			i++
+		}
+	}
+	b.WriteString(input)
+	return b.String(), shifts, joins
+}
+
+// unfinished returns the length of the end of s, which is appended to the
+// input, that may be normalized differently depending on the input that
+// follows, such as a CR that may be followed by LF, or the start of a
+// line continuation. An incomplete UTF-8 sequence is held back as well,
+// so that a rune split between appends is not read as invalid.
+func (l *Lexer) unfinished(s string) int {
+	if l.stripBOM && len(l.input) == 0 && len(l.shifts) == 0 && len(s) < len(bom) && strings.HasPrefix(bom, s) {
+		return len(s)
+	}
+	n := 0
+	for i := len(s) - 1; i >= 0 && i >= len(s)-utf8.UTFMax; i-- {
+		if utf8.RuneStart(s[i]) {
+			if !utf8.FullRuneInString(s[i:]) {
+				n = len(s) - i
+			}
+			break
+		}
+	}
+	if l.newlines && strings.HasSuffix(s, "\r") {
+		n = max(n, 1)
+	}
+	if m := l.continuation; m != "" {
+		if strings.HasSuffix(s, m+"\r") {
+			n = max(n, len(m)+1)
+		}
+		for k := min(len(m), len(s)); k > n; k-- {
+			if strings.HasSuffix(s, m[:k]) {
+				n = k
+				break
+			}
+		}
+	}
+	return n
+}
+
+// OriginalPos maps the offset pos in the input as lexed to the offset in
+// the input as passed to the lexer, before it was normalized by options
+// such as WithNormalizeNewlines, WithStripBOM, and WithLineContinuation.
+// This lets external tools such as editors get accurate spans.
+//
+// Only offsets in the main input are mapped, not those in inputs
+// added with PushInput. For a stream, which is normalized as it is read,
+// OriginalPos may only be called after the lexer has finished.
+func (l *Lexer) OriginalPos(pos int) int {
+	i := sort.Search(len(l.shifts), func(i int) bool { return l.shifts[i].pos > pos })
+	if i == 0 {
+		return pos
+	}
+	return pos + l.shifts[i-1].delta
+}
@@ -0,0 +1,70 @@
+// Copyright (c) 2015, Ben Morgan. All rights reserved.
+// Use of this source code is governed by an MIT license
+// that can be found in the LICENSE file.
+
+package lex_test
+
+import (
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/goulash/lex"
+)
+
+// lexBytes emits every byte of the input as a token.
+func lexBytes(l *lex.Lexer) lex.StateFn {
+	for l.Next() >= 0 {
+		l.Emit(typeOther)
+	}
+	return nil
+}
+
+// FuzzOriginalPos checks that OriginalPos maps every byte of the
+// normalized input to the byte of the input it came from, and that
+// streams are normalized like strings.
+func FuzzOriginalPos(f *testing.F) {
+	for _, input := range []string{"\ufeffa\r\nb\rc", "a\\\r\nb\\\nc\\", "\r\r\n\\\\\n"} {
+		f.Add(input)
+	}
+	opts := []lex.Option{lex.WithStripBOM(), lex.WithNormalizeNewlines(), lex.WithLineContinuation(`\`)}
+	f.Fuzz(func(t *testing.T, input string) {
+		l := lex.Lex("f", input, lexBytes, opts...)
+		toks := lex.Collect(l)
+		for _, tok := range toks[:len(toks)-1] {
+			pos := l.OriginalPos(tok.Pos)
+			if pos < 0 || pos >= len(input) {
+				t.Fatalf("%v at %d maps to %d outside the input", tok, tok.Pos, pos)
+			}
+			// A line continuation may be removed within a rune, so only
+			// the first byte is compared.
+			c := input[pos]
+			if tok.Value[0] != c && !(tok.Value == "\n" && c == '\r') {
+				t.Fatalf("%v at %d maps to %d, which is %q", tok, tok.Pos, pos, c)
+			}
+		}
+		eof := toks[len(toks)-1]
+		if pos := l.OriginalPos(eof.Pos); pos != len(input) && !strings.HasSuffix(input, `\`) {
+			t.Fatalf("EOF at %d maps to %d, want %d", eof.Pos, pos, len(input))
+		}
+
+		s := lex.NewStream("f", iotest.OneByteReader(strings.NewReader(input)), opts...)
+		go s.Run(lexBytes)
+		streamed := lex.Collect(s)
+		if got, want := strings.Join(values(streamed), ""), strings.Join(values(toks), ""); got != want {
+			t.Fatalf("stream lexed %q, want %q", got, want)
+		}
+		for i, tok := range streamed {
+			if tok.Pos != toks[i].Pos || s.OriginalPos(tok.Pos) != l.OriginalPos(tok.Pos) {
+				t.Fatalf("stream token %v at %d differs", tok, tok.Pos)
+			}
+		}
+	})
+}
+
+func TestStripBOM(t *testing.T) {
+	toks := lex.Collect(lex.Lex("f", "\ufeffab\ufeff", lexWords, lex.WithStripBOM()))
+	if got := strings.Join(values(toks), "|"); got != "ab|\ufeff|" {
+		t.Errorf("got %q, want only the leading BOM stripped", got)
+	}
+}
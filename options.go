@@ -0,0 +1,235 @@
+// Copyright (c) 2015, Ben Morgan. All rights reserved.
+// Use of this source code is governed by an MIT license
+// that can be found in the LICENSE file.
+
+package lex
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// An Option configures a Lexer, see New.
+type Option func(*Lexer)
+
+// WithBufferSize sets the number of tokens that can be buffered in the
+// tokens channel, letting the lexer run ahead of the client. By default,
+// the channel is unbuffered.
+func WithBufferSize(n int) Option {
+	return func(l *Lexer) { l.bufSize = n }
+}
+
+// WithAutoEOF sets whether Run emits a TypeEOF token when the state
+// functions did not emit one. It is enabled by default.
+func WithAutoEOF(enabled bool) Option {
+	return func(l *Lexer) { l.noAutoEOF = !enabled }
+}
+
+// WithNormalizeNewlines makes the lexer convert the line endings \r\n
+// and \r in the input to \n before lexing. Token positions refer to the
+// normalized input, see also Lexer.OriginalPos.
+func WithNormalizeNewlines() Option {
+	return func(l *Lexer) { l.newlines = true }
+}
+
+// WithStripBOM makes the lexer remove a leading UTF-8 byte order mark
+// from the input before lexing. Token positions refer to the stripped
+// input, see also Lexer.OriginalPos.
+func WithStripBOM() Option {
+	return func(l *Lexer) { l.stripBOM = true }
+}
+
+// WithLineContinuation makes the lexer join lines that end with marker,
+// such as a backslash in shell scripts and the C preprocessor, by
+// removing every marker that is directly followed by a line ending,
+// together with the line ending, before lexing:
+//
+//	lex.New(name, input, lex.WithLineContinuation(`\`))
+//
+// State functions see the joined lines, and token values do not contain
+// the continuations. Line and column numbers still refer to the lines
+// of the input, and token positions to the joined input, see also
+// Lexer.OriginalPos.
+func WithLineContinuation(marker string) Option {
+	return func(l *Lexer) { l.continuation = marker }
+}
+
+// WithTrace makes the lexer write a line to w for every state transition
+// and every token emitted, which is useful for debugging.
+func WithTrace(w io.Writer) Option {
+	return func(l *Lexer) {
+		l.trace = w
+		l.stateHooks = append(l.stateHooks, func(prev, next StateFn, l *Lexer) {
+			fmt.Fprintf(w, "lex: state %s -> %s at %d\n", StateName(prev), StateName(next), l.pos)
+		})
+	}
+}
+
+// WithTabWidth makes a tab advance the column to the next tab stop,
+// where tab stops are n columns apart. By default, a tab is one column.
+func WithTabWidth(n int) Option {
+	return func(l *Lexer) { l.tabWidth = n }
+}
+
+// WithDisplayWidth makes columns count the display width of runes
+// instead of the number of runes: wide East Asian characters count as
+// two columns and combining marks as none. This matches the cursor
+// position in most terminals and editors.
+func WithDisplayWidth() Option {
+	return func(l *Lexer) { l.displayWidth = true }
+}
+
+// WithSkip makes the lexer drop all tokens of the given types when they
+// are emitted, as if Ignore had been called instead. This lets state
+// functions emit whitespace and comments uniformly, while the decision
+// whether the client receives them is made in one place.
+// Error tokens cannot be skipped.
+func WithSkip(types ...Type) Option {
+	return func(l *Lexer) {
+		if l.skip == nil {
+			l.skip = make(map[Type]bool)
+		}
+		for _, t := range types {
+			l.skip[t] = true
+		}
+	}
+}
+
+// A StateHook is called by Run on every transition from the state
+// function prev to the state function next. At the end of lexing,
+// next is nil.
+type StateHook func(prev, next StateFn, l *Lexer)
+
+// WithStateHook adds a hook that is called on every state transition,
+// enabling instrumentation such as tracing, metrics, or coverage analysis.
+// Hooks are called in the lexing goroutine, in the order they were added.
+func WithStateHook(hook StateHook) Option {
+	return func(l *Lexer) { l.stateHooks = append(l.stateHooks, hook) }
+}
+
+// WithEmitHook makes the lexer call hook with every token before it is
+// delivered, after the token has passed the other options. The hook may
+// modify the token, which makes it possible to apply a policy to all
+// tokens without wrapping the Reader:
+//
+//	lex.WithEmitHook(func(t *lex.Token) {
+//	    t.Value = strings.TrimSpace(t.Value)
+//	})
+//
+// A hook may also shift the positions of tokens, such as for a snippet
+// embedded in a larger document, but the line and column of tokens, as
+// reported by Reader.PosOf, are then no longer accurate.
+// Hooks are called in the order they were added.
+func WithEmitHook(hook func(*Token)) Option {
+	return func(l *Lexer) { l.emitHooks = append(l.emitHooks, hook) }
+}
+
+// WithMaxTokenLength limits the length of tokens to n bytes. When a state
+// function reads beyond the limit, such as in an unterminated string,
+// the lexer emits an error and stops, so malformed input fails fast.
+func WithMaxTokenLength(n int) Option {
+	return func(l *Lexer) { l.maxTokenLen = n }
+}
+
+// WithInterning makes the lexer intern token values, so that tokens with
+// identical values share one string. This reduces memory use when token
+// values are not substrings of the input, such as with EmitMapped,
+// and the same values repeat many times.
+func WithInterning() Option {
+	return func(l *Lexer) { l.interned = make(map[string]string) }
+}
+
+// intern replaces the value of t by the interned string.
+func (l *Lexer) intern(t *Token) {
+	s, ok := l.interned[t.Value]
+	if !ok {
+		l.interned[t.Value] = t.Value
+		return
+	}
+	if t.Raw == t.Value {
+		t.Raw = s
+	}
+	t.Value = s
+}
+
+// WithCopyValues makes the lexer copy the values of tokens instead of
+// referring to the input. Otherwise, a single retained token keeps the
+// whole input from being garbage collected, which matters when few tokens
+// of a large input are kept, such as in a symbol table. Tokens still
+// retain a copy of their line for computing their position.
+//
+// The copies cost an allocation per token, so this is not the default.
+func WithCopyValues() Option {
+	return func(l *Lexer) { l.copyValues = true }
+}
+
+// copyValues replaces the value and raw text of t by copies.
+func copyValues(t *Token) {
+	same := t.Raw == t.Value
+	t.Value = strings.Clone(t.Value)
+	if same {
+		t.Raw = t.Value
+	} else {
+		t.Raw = strings.Clone(t.Raw)
+	}
+}
+
+// A Normalizer normalizes strings, such as the Unicode normalization
+// forms norm.NFC and norm.NFKC of golang.org/x/text/unicode/norm.
+type Normalizer interface {
+	String(s string) string
+}
+
+// WithIdentifierFold makes the lexer normalize the values of tokens of
+// the given types with norm when they are emitted:
+//
+//	l := lex.New(name, input, lex.WithIdentifierFold(norm.NFKC, TypeIdent))
+//
+// This keeps identifiers that look identical but are made of different
+// code points from being distinct, which matters for security-sensitive
+// languages. The original text remains available in the Raw field.
+func WithIdentifierFold(norm Normalizer, types ...Type) Option {
+	return func(l *Lexer) {
+		l.fold = norm
+		l.foldTypes = make(map[Type]bool, len(types))
+		for _, t := range types {
+			l.foldTypes[t] = true
+		}
+	}
+}
+
+// WithDeadline limits the time Run may take to d. When the deadline is
+// exceeded, the lexer emits an error and stops, so that pathological input
+// cannot hang a server indefinitely. The deadline is checked between
+// state functions and periodically while reading input, so a state
+// function that loops without reading input cannot be stopped.
+func WithDeadline(d time.Duration) Option {
+	return func(l *Lexer) { l.timeout = d }
+}
+
+// expired reports whether the deadline of the lexer has been exceeded
+// or its context canceled, halting the lexer if so.
+func (l *Lexer) expired() bool {
+	if l.ctx != nil {
+		select {
+		case <-l.ctx.Done():
+			// The context error is wrapped, see LexAndWait.
+			err := l.ctx.Err()
+			l.emit(Token{Type: TypeError, Pos: l.base, End: l.pos, Value: "lexing canceled: " + err.Error(), Data: err, pb: l.pb})
+			l.stop()
+			return true
+		default:
+		}
+	}
+	if l.deadline.IsZero() || time.Now().Before(l.deadline) {
+		return false
+	}
+	if l.state != nil {
+		l.halt("lexing exceeded deadline of %v in state %s", l.timeout, l.State())
+	} else {
+		l.halt("lexing exceeded deadline of %v", l.timeout)
+	}
+	return true
+}
@@ -0,0 +1,222 @@
+// Copyright (c) 2015, Ben Morgan. All rights reserved.
+// Use of this source code is governed by an MIT license
+// that can be found in the LICENSE file.
+
+package lex_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+	"unsafe"
+
+	"github.com/goulash/lex"
+)
+
+func TestWithSkip(t *testing.T) {
+	sf := func(l *lex.Lexer) lex.StateFn {
+		lexWords(l)
+		return l.Errorf("stop")
+	}
+	toks := lex.Collect(lex.Lex("a", "ab cd!", sf, lex.WithSkip(typeSpace, typeOther, lex.TypeError)))
+	if got := strings.Join(values(toks), "|"); got != "ab|cd|stop" {
+		t.Errorf("got %q, want ab, cd, and the error", got)
+	}
+}
+
+func TestWithStateHook(t *testing.T) {
+	var lexB lex.StateFn
+	lexA := lex.Named("A", func(l *lex.Lexer) lex.StateFn { return lexB })
+	lexB = lex.Named("B", func(l *lex.Lexer) lex.StateFn { return nil })
+	var calls []string
+	hook := func(tag string) lex.StateHook {
+		return func(prev, next lex.StateFn, l *lex.Lexer) {
+			calls = append(calls, tag+lex.StateName(prev)+">"+lex.StateName(next))
+		}
+	}
+	lex.Lex("a", "", lexA, lex.WithStateHook(hook("1:")), lex.WithStateHook(hook("2:"))).Drain()
+	if got := strings.Join(calls, " "); got != "1:A>B 2:A>B 1:B>nil 2:B>nil" {
+		t.Errorf("got calls %s", got)
+	}
+}
+
+func TestWithEmitHook(t *testing.T) {
+	var seen []string
+	first := lex.WithEmitHook(func(t *lex.Token) { t.Value = strings.ToUpper(t.Value) })
+	second := lex.WithEmitHook(func(t *lex.Token) {
+		seen = append(seen, t.Value)
+		t.Pos, t.End = t.Pos+10, t.End+10
+	})
+	l := lex.Lex("f", "ab cd", lexWords, first, second, lex.WithSkip(typeSpace))
+	r := lex.NewReader(l)
+	var got []string
+	for tok := r.Next(); tok.Type != lex.TypeEOF; tok = r.Next() {
+		file, line, col := r.PosOf(tok)
+		got = append(got, fmt.Sprintf("%s@%d:%s:%d:%d", tok.Value, tok.Pos, file, line, col))
+	}
+	if s := strings.Join(got, " "); s != "AB@10:f:1:6 CD@13:f:1:6" {
+		t.Errorf("got %s", s)
+	}
+	if s := strings.Join(seen, "|"); s != "AB|CD|" {
+		t.Errorf("second hook saw %q, want the values of the first and no skipped tokens", s)
+	}
+}
+
+func TestWithMaxTokenLength(t *testing.T) {
+	unterminated := func(l *lex.Lexer) lex.StateFn {
+		for l.Next() >= 0 {
+		}
+		return l.Errorf("unterminated")
+	}
+	consume := func(l *lex.Lexer) lex.StateFn {
+		l.Consume("abcd")
+		l.Emit(typeWord)
+		return nil
+	}
+	tests := []struct {
+		input string
+		sf    lex.StateFn
+		want  string
+	}{
+		{"ab abc", lexWords, "ab| |abc|"},
+		{"ab abcd e", lexWords, "ab| |token exceeds maximum length of 3 bytes"},
+		{`"` + strings.Repeat("x", 1<<16), unterminated, "token exceeds maximum length of 3 bytes"},
+		{"abcd", consume, "token exceeds maximum length of 3 bytes"},
+	}
+	for _, tt := range tests {
+		l := lex.Lex("a", tt.input, tt.sf, lex.WithMaxTokenLength(3))
+		toks := lex.Collect(l)
+		if got := strings.Join(values(toks), "|"); got != tt.want {
+			t.Errorf("%.10q: got %q, want %q", tt.input, got, tt.want)
+		}
+		if last := toks[len(toks)-1]; last.Type == lex.TypeError && l.Err() == nil {
+			t.Errorf("%.10q: got no error from Err", tt.input)
+		}
+	}
+}
+
+func TestWithBufferSize(t *testing.T) {
+	ahead := make(chan struct{})
+	l := lex.Lex("a", "a b c d", func(l *lex.Lexer) lex.StateFn {
+		lexWords(l)
+		close(ahead)
+		return nil
+	}, lex.WithBufferSize(8))
+	select {
+	case <-ahead:
+	case <-time.After(time.Second):
+		t.Fatal("lexer did not run ahead of the client")
+	}
+	if got := strings.Join(values(lex.Collect(l)), "|"); got != "a| |b| |c| |d|" {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestWithNormalizeNewlines(t *testing.T) {
+	l := lex.Lex("f", "a\r\nb\rc\n\rd", lexWords, lex.WithNormalizeNewlines())
+	got := strings.Join(positions(l), " ")
+	if want := "f:1:1 f:1:2 f:2:1 f:2:2 f:3:1 f:3:2 f:4:1 f:5:1 f:5:2"; got != want {
+		t.Errorf("got  %s\nwant %s", got, want)
+	}
+	toks := lex.Collect(lex.Lex("f", "a\r\nb", lexWords, lex.WithNormalizeNewlines()))
+	if got := strings.Join(values(toks), "|"); got != "a|\n|b|" {
+		t.Errorf("got %q, want the line ending normalized", got)
+	}
+}
+
+func TestWithTrace(t *testing.T) {
+	var b strings.Builder
+	sf := lex.Named("words", func(l *lex.Lexer) lex.StateFn {
+		lexWords(l)
+		return nil
+	})
+	lex.Lex("a", "ab c", sf, lex.WithTrace(&b)).Drain()
+	want := `lex: emit 2 "ab" at 0
+lex: emit 3 " " at 2
+lex: emit 2 "c" at 3
+lex: state words -> nil at 4
+lex: emit 1 "" at 4
+`
+	if b.String() != want {
+		t.Errorf("got\n%swant\n%s", b.String(), want)
+	}
+}
+
+func TestWithInterning(t *testing.T) {
+	upper := func(l *lex.Lexer) lex.StateFn {
+		for l.Peek() >= 0 {
+			l.AcceptRun("abc")
+			l.EmitMapped(typeWord, strings.ToUpper)
+			l.AcceptRun(" ")
+			l.Emit(typeSpace)
+		}
+		return nil
+	}
+	for _, opts := range [][]lex.Option{{lex.WithInterning()}, {lex.WithInterning(), lex.WithCopyValues()}} {
+		toks := lex.Collect(lex.Lex("a", "ab ab  ab ", upper, opts...))
+		if got := strings.Join(values(toks), "|"); got != "AB| |AB|  |AB| |" {
+			t.Fatalf("got %q", got)
+		}
+		for _, i := range []int{2, 4} {
+			if unsafe.StringData(toks[i].Value) != unsafe.StringData(toks[0].Value) {
+				t.Errorf("token %d does not share its value with token 0", i)
+			}
+			if toks[i].Raw != "ab" {
+				t.Errorf("token %d has raw %q, want ab", i, toks[i].Raw)
+			}
+		}
+		if unsafe.StringData(toks[5].Value) != unsafe.StringData(toks[1].Value) {
+			t.Error("spaces do not share their value")
+		}
+	}
+}
+
+// widthFold folds fullwidth ASCII letters to ASCII, like NFKC does.
+type widthFold struct{}
+
+func (widthFold) String(s string) string {
+	return strings.Map(func(r rune) rune {
+		if 'Ａ' <= r && r <= 'ｚ' {
+			return r - 'Ａ' + 'A'
+		}
+		return r
+	}, s)
+}
+
+func TestWithIdentifierFold(t *testing.T) {
+	toks := lex.Collect(lex.Lex("a", "ａｂ ab ｃ", lexWords, lex.WithIdentifierFold(widthFold{}, typeWord)))
+	if got := strings.Join(values(toks), "|"); got != "ab| |ab| |c|" {
+		t.Errorf("got %q", got)
+	}
+	if toks[0].Raw != "ａｂ" || toks[0].End-toks[0].Pos != len("ａｂ") {
+		t.Errorf("got raw %q at %d-%d, want the original text", toks[0].Raw, toks[0].Pos, toks[0].End)
+	}
+
+	toks = lex.Collect(lex.Lex("a", "ａ ｂ", lexWords, lex.WithIdentifierFold(widthFold{}, typeSpace)))
+	if got := strings.Join(values(toks), "|"); got != "ａ| |ｂ|" {
+		t.Errorf("got %q, want only spaces folded", got)
+	}
+}
+
+func TestWithDeadline(t *testing.T) {
+	var spin, peek lex.StateFn
+	spin = lex.Named("spin", func(l *lex.Lexer) lex.StateFn { return spin })
+	peek = lex.Named("peek", func(l *lex.Lexer) lex.StateFn {
+		for l.Peek() >= 0 {
+		}
+		return nil
+	})
+	for _, sf := range []lex.StateFn{spin, peek} {
+		l := lex.Lex("a", "ab", sf, lex.WithDeadline(10*time.Millisecond))
+		toks := lex.Collect(l)
+		want := "lexing exceeded deadline of 10ms in state " + lex.StateName(sf)
+		if last := toks[len(toks)-1]; len(toks) != 1 || last.Type != lex.TypeError || last.Value != want {
+			t.Errorf("got %v, want %q", toks, want)
+		}
+	}
+	toks := lex.Collect(lex.Lex("a", "ab", lexWords, lex.WithDeadline(time.Minute)))
+	if got := strings.Join(values(toks), "|"); got != "ab|" {
+		t.Errorf("got %q within the deadline", got)
+	}
+}
@@ -0,0 +1,50 @@
+// Copyright (c) 2015, Ben Morgan. All rights reserved.
+// Use of this source code is governed by an MIT license
+// that can be found in the LICENSE file.
+
+package lex
+
+import "fmt"
+
+// An Origin describes where input that was generated by expansion,
+// such as of a macro, was originally defined.
+type Origin struct {
+	Name string // name of the expansion, such as the macro name
+	File string
+	Line int
+	Col  int
+
+	// Parent is the origin that was active when this one was pushed,
+	// for nested expansions.
+	Parent *Origin
+}
+
+func (o *Origin) String() string {
+	if o.Name == "" {
+		return fmt.Sprintf("%s:%d:%d", o.File, o.Line, o.Col)
+	}
+	return fmt.Sprintf("%s (%s:%d:%d)", o.Name, o.File, o.Line, o.Col)
+}
+
+// PushOrigin marks the input lexed from now on as originating from o,
+// until the matching call to PopOrigin. All tokens emitted in between
+// have o as their Origin, in addition to their position in the
+// expanded input:
+//
+//	l.PushInput(macro.Name, macro.Body)
+//	l.PushOrigin(lex.Origin{Name: macro.Name, File: macro.File, Line: macro.Line})
+//
+// Origins can be nested, in which case the Parent of o is set to the
+// active origin. An origin pushed after PushInput ends with the pushed
+// input, without calling PopOrigin.
+func (l *Lexer) PushOrigin(o Origin) {
+	o.Parent = l.origin
+	l.origin = &o
+}
+
+// PopOrigin ends the origin set by the last call to PushOrigin.
+func (l *Lexer) PopOrigin() {
+	if l.origin != nil {
+		l.origin = l.origin.Parent
+	}
+}
@@ -0,0 +1,41 @@
+// Copyright (c) 2015, Ben Morgan. All rights reserved.
+// Use of this source code is governed by an MIT license
+// that can be found in the LICENSE file.
+
+package lex_test
+
+import (
+	"testing"
+
+	"github.com/goulash/lex"
+)
+
+func TestOriginEndsWithInput(t *testing.T) {
+	l := lex.Lex("f", "a M b", func(l *lex.Lexer) lex.StateFn {
+		l.Inc(2)
+		l.Emit(typeWord)
+		l.Inc(1)
+		l.Ignore()
+		l.PushInput("M", "x y")
+		l.PushOrigin(lex.Origin{Name: "M", File: "f", Line: 1, Col: 3})
+		return lexWords
+	})
+	var got []string
+	for _, tok := range lex.Collect(l) {
+		o := "-"
+		if tok.Origin != nil {
+			o = tok.Origin.Name
+		}
+		got = append(got, tok.Value+":"+o)
+	}
+	want := []string{"a :-", "x:M", " :M", "y:M", " :-", "b:-", ":-"}
+	if len(got) != len(want) {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+	for i := range got {
+		if got[i] != want[i] {
+			t.Errorf("got %q, want %q", got, want)
+			break
+		}
+	}
+}
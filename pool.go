@@ -0,0 +1,31 @@
+// Copyright (c) 2015, Ben Morgan. All rights reserved.
+// Use of this source code is governed by an MIT license
+// that can be found in the LICENSE file.
+
+package lex
+
+import "sync"
+
+var lexPool = sync.Pool{
+	New: func() interface{} { return New("", "") },
+}
+
+// AcquireLexer returns a Lexer for input from a pool of lexers, which
+// reuses the lexers and their internal buffers in servers that lex many
+// small inputs. The channels of a lexer are closed when it finishes, so
+// they are allocated anew for every input. The Lexer has the default
+// options. It should be returned to the pool with ReleaseLexer once it
+// is no longer needed.
+func AcquireLexer(name, input string) *Lexer {
+	l := lexPool.Get().(*Lexer)
+	l.Reset(name, input)
+	return l
+}
+
+// ReleaseLexer returns l to the pool of lexers. The lexer must have
+// finished and its tokens must have been consumed, such as with Drain.
+// The lexer must not be used afterwards.
+func ReleaseLexer(l *Lexer) {
+	l.Reset("", "")
+	lexPool.Put(l)
+}
@@ -0,0 +1,34 @@
+// Copyright (c) 2015, Ben Morgan. All rights reserved.
+// Use of this source code is governed by an MIT license
+// that can be found in the LICENSE file.
+
+package lex_test
+
+import (
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/goulash/lex"
+)
+
+func TestLexerPool(t *testing.T) {
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				l := lex.AcquireLexer("f", "ab cd")
+				go l.Run(lexWords)
+				got := values(lex.Collect(l))
+				lex.ReleaseLexer(l)
+				if strings.Join(got, "|") != "ab| |cd|" {
+					t.Errorf("got %q", got)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,128 @@
+// Copyright (c) 2015, Ben Morgan. All rights reserved.
+// Use of this source code is governed by an MIT license
+// that can be found in the LICENSE file.
+
+package lex
+
+import (
+	"sort"
+	"strings"
+)
+
+// A posBase determines the file and line that offsets in the input
+// are reported as, starting at offset pos.
+type posBase struct {
+	input string
+	off   int // offset of input within the whole input, see detach
+	pos   int
+	file  string
+	line  int
+	joins []int // offsets of removed line continuations, see normalize
+}
+
+// lineStart returns the line of the offset pos in b.input, and the offset
+// that its column is counted from. The text directly following the start
+// of b is at column 1.
+func (b *posBase) lineStart(pos int) (line, start int) {
+	pos = b.clamp(pos)
+	code := b.input[b.pos-b.off : pos-b.off]
+	line = b.line + strings.Count(code, "\n")
+	start = b.pos
+	if i := strings.LastIndex(code, "\n"); i >= 0 {
+		start = b.pos + i + 1
+	}
+	if len(b.joins) > 0 {
+		i := sort.SearchInts(b.joins, b.pos+1)
+		j := sort.SearchInts(b.joins, pos+1)
+		line += j - i
+		if j > i {
+			start = max(start, b.joins[j-1])
+		}
+	}
+	return line, start
+}
+
+// clamp returns the offset in b closest to pos, which may be outside b
+// if it was shifted by an emit hook or emitted by EmitAt before b.
+func (b *posBase) clamp(pos int) int {
+	return min(max(pos, b.pos), b.off+len(b.input))
+}
+
+// lineCol returns the line and column of the offset pos relative to b,
+// honoring the column options of the lexer.
+func (l *Lexer) lineCol(b *posBase, pos int) (line, col int) {
+	pos = b.clamp(pos)
+	line, start := b.lineStart(pos)
+	col = 1
+	for _, r := range b.input[start-b.off : pos-b.off] {
+		switch {
+		case r == '\t' && l.tabWidth > 0:
+			col += l.tabWidth - (col-1)%l.tabWidth
+		case l.displayWidth:
+			col += runeWidth(r)
+		default:
+			col++
+		}
+	}
+	return line, col
+}
+
+// SetPosition sets the file and line that the input from the current
+// position onwards is reported as. This lets languages with #line or
+// //line directives report positions against the original source:
+//
+//	if l.Consume("#line ") {
+//	    // ... read file and line ...
+//	    l.AcceptRun(lex.Endline)
+//	    l.Ignore()
+//	    l.SetPosition(file, line)
+//	}
+//
+// LineNumber, ColumnNumber, and Filename honor the override for all
+// tokens emitted after the call.
+func (l *Lexer) SetPosition(file string, line int) {
+	l.pb = &posBase{input: l.input, pos: l.pos, file: file, line: line, joins: l.pb.joins}
+}
+
+// position returns the file, line, and column of the token t.
+func (l *Lexer) position(t Token) (file string, line, col int) {
+	pb := t.pb
+	if pb == nil && l.last != nil {
+		pb = l.last.pb
+	}
+	if pb == nil {
+		return "", 0, 0
+	}
+	line, col = l.lineCol(pb, t.Pos)
+	return pb.file, line, col
+}
+
+// detach returns a position base for the offset pos that holds a copy of
+// only the line of pos, so that a token positioned by it does not keep the
+// whole input alive, see WithCopyValues. The copy is shared by all tokens
+// on the line.
+func (l *Lexer) detach(b *posBase, pos int) *posBase {
+	pos = b.clamp(pos)
+	d := l.detached
+	if d == nil || l.detachedFrom != b || pos < d.pos {
+		d = nil
+	} else if pos-d.off <= len(d.input) {
+		return d
+	}
+	// Lines are counted from the previous copy, so that detaching the
+	// tokens of the whole input only scans it once.
+	from := b
+	if d != nil {
+		c := *b
+		c.pos, c.line = d.pos, d.line
+		from = &c
+	}
+	line, start := from.lineStart(pos)
+	rest := b.input[start-b.off:]
+	if i := strings.IndexByte(rest[pos-start:], '\n'); i >= 0 {
+		rest = rest[:pos-start+i]
+	}
+	l.detachedFrom = b
+	l.detached = &posBase{input: strings.Clone(rest), off: start, pos: start, file: b.file, line: line, joins: b.joins}
+	return l.detached
+}
@@ -0,0 +1,131 @@
+// Copyright (c) 2015, Ben Morgan. All rights reserved.
+// Use of this source code is governed by an MIT license
+// that can be found in the LICENSE file.
+
+package lex_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/goulash/lex"
+)
+
+// positions returns the positions of the tokens read from l.
+func positions(l *lex.Lexer) []string {
+	r := lex.NewReader(l)
+	var ps []string
+	for {
+		t := r.Next()
+		file, line, col := r.PosOf(t)
+		ps = append(ps, fmt.Sprintf("%s:%d:%d", file, line, col))
+		if t.Type == lex.TypeEOF || t.Type == lex.TypeError {
+			return ps
+		}
+	}
+}
+
+func TestCopyValuesPositions(t *testing.T) {
+	inputs := []string{
+		"ab cd\nef\n\ngh ij\n",
+		"a \\\nb\nc",
+	}
+	for _, in := range inputs {
+		opts := []lex.Option{lex.WithLineContinuation("\\"), lex.WithNormalizeNewlines()}
+		want := positions(lex.Lex("f", in, lexWords, opts...))
+		got := positions(lex.Lex("f", in, lexWords, append(opts, lex.WithCopyValues())...))
+		if strings.Join(got, " ") != strings.Join(want, " ") {
+			t.Errorf("%q: got %v, want %v", in, got, want)
+		}
+	}
+}
+
+func TestCopyValuesLinear(t *testing.T) {
+	lexLines := func(n int) time.Duration {
+		in := strings.Repeat("abc def ghi\n", n)
+		start := time.Now()
+		lex.Collect(lex.Lex("f", in, lexWords, lex.WithCopyValues()))
+		return time.Since(start)
+	}
+	lexLines(1000)
+	small, large := lexLines(10000), lexLines(80000)
+	if large > 20*small {
+		t.Errorf("lexing 8x the input took %v instead of %v", large, small)
+	}
+}
+
+func TestCopyValuesShiftedPos(t *testing.T) {
+	for _, shift := range []int{-1000, 1000} {
+		hook := lex.WithEmitHook(func(t *lex.Token) { t.Pos += shift })
+		toks := lex.Collect(lex.Lex("f", "ab cd", lexWords, hook, lex.WithCopyValues()))
+		if got := values(toks); strings.Join(got, "|") != "ab| |cd|" {
+			t.Errorf("shift %d: got %q", shift, got)
+		}
+	}
+}
+
+func TestEmitAtBeforePosition(t *testing.T) {
+	l := lex.Lex("a", "0123456789abc", func(l *lex.Lexer) lex.StateFn {
+		l.Inc(10)
+		l.Ignore()
+		l.SetPosition("f", 100)
+		l.EmitAt(typeOther, 2, "x")
+		l.Inc(3)
+		l.Emit(typeWord)
+		return nil
+	})
+	got := strings.Join(positions(l), " ")
+	if want := "f:100:1 f:100:1 f:100:4"; got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
+
+func TestSetPosition(t *testing.T) {
+	directive := func(l *lex.Lexer) lex.StateFn {
+		l.AcceptRun("a")
+		l.Emit(typeWord)
+		l.AcceptRun("\n")
+		l.Ignore()
+		l.SetPosition("foo.c", 10)
+		return lexWords
+	}
+	got := positions(lex.Lex("f", "a\nb c\nd", directive))
+	want := "f:1:1 foo.c:10:1 foo.c:10:2 foo.c:10:3 foo.c:10:4 foo.c:11:1 foo.c:11:2"
+	if strings.Join(got, " ") != want {
+		t.Errorf("got %v, want %s", got, want)
+	}
+
+	l := lex.Lex("f", "a\nb c", directive)
+	var pos []string
+	for tok := l.NextToken(); tok.Type != lex.TypeEOF; tok = l.NextToken() {
+		pos = append(pos, fmt.Sprintf("%s:%d:%d", l.Filename(), l.LineNumber(), l.ColumnNumber()))
+	}
+	if want := "f:1:1 foo.c:10:1 foo.c:10:2 foo.c:10:3"; strings.Join(pos, " ") != want {
+		t.Errorf("NextToken positions %v, want %s", pos, want)
+	}
+}
+
+func TestColumns(t *testing.T) {
+	const input = "\tab\t日本 e\u0301x"
+	tests := []struct {
+		opts []lex.Option
+		want string
+	}{
+		// tab, ab, tab, 日本, space, e, U+0301, x, EOF
+		{nil, "1 2 4 5 7 8 9 10 11"},
+		{[]lex.Option{lex.WithTabWidth(4)}, "1 5 7 9 11 12 13 14 15"},
+		{[]lex.Option{lex.WithDisplayWidth()}, "1 2 4 5 9 10 11 11 12"},
+		{[]lex.Option{lex.WithTabWidth(8), lex.WithDisplayWidth()}, "1 9 11 17 21 22 23 23 24"},
+	}
+	for _, tt := range tests {
+		var cols []string
+		for _, p := range positions(lex.Lex("f", input, lexWords, tt.opts...)) {
+			cols = append(cols, p[strings.LastIndex(p, ":")+1:])
+		}
+		if got := strings.Join(cols, " "); got != tt.want {
+			t.Errorf("%d options: got columns %s, want %s", len(tt.opts), got, tt.want)
+		}
+	}
+}
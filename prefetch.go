@@ -0,0 +1,167 @@
+// Copyright (c) 2015, Ben Morgan. All rights reserved.
+// Use of this source code is governed by an MIT license
+// that can be found in the LICENSE file.
+
+package lex
+
+// prefetchBatch is the largest number of tokens passed from the
+// prefetching goroutine to the Reader at once.
+const prefetchBatch = 64
+
+// A PrefetchReader is a Reader whose tokens are received ahead of time
+// by a separate goroutine, see NewPrefetchReader.
+type PrefetchReader struct {
+	*Reader
+	p *prefetchSource
+}
+
+// NewPrefetchReader returns a Reader that reads the tokens of l, which
+// are received by a separate goroutine up to n tokens ahead of the
+// Reader. The tokens are passed on in batches, so that the parser does
+// not wait on the lexer token by token, which lets lexing and parsing
+// overlap on multicore machines:
+//
+//	r := lex.NewPrefetchReader(lex.Lex(name, input, lexText), 4096)
+//	defer r.Stop()
+//
+// Tokens count against the memory budget of l until they are read from
+// the Reader, see WithMemoryBudget. If the tokens are not read up to the
+// TypeEOF or TypeError token, Stop must be called so that the goroutines
+// exit. Tokens must only be read from the returned Reader.
+func NewPrefetchReader(l *Lexer, n int) *PrefetchReader {
+	size := min(max(n, 1), prefetchBatch)
+	p := &prefetchSource{
+		lex:     l,
+		batches: make(chan []Token, max(n/size-1, 0)),
+		want:    make(chan struct{}, 1),
+		stop:    make(chan struct{}),
+	}
+	go p.run(size)
+	return &PrefetchReader{Reader: &Reader{src: p, lex: l}, p: p}
+}
+
+// Stop stops receiving tokens and waits until the lexer has finished,
+// after which the Reader only returns zero tokens. The remaining tokens
+// of the lexer are discarded.
+func (r *PrefetchReader) Stop() {
+	p := r.p
+	if p.stopped {
+		return
+	}
+	p.stopped = true
+	close(p.stop)
+	for batch := range p.batches {
+		p.release(batch)
+	}
+	p.release(p.cur)
+	p.cur = nil
+}
+
+// prefetchSource is a TokenSource that reads the batches of tokens
+// received by the prefetching goroutine.
+type prefetchSource struct {
+	lex     *Lexer
+	batches chan []Token
+	want    chan struct{} // signals that the Reader is waiting for tokens
+	stop    chan struct{} // closed by Stop
+	stopped bool
+	cur     []Token
+}
+
+// run receives the tokens of the lexer and passes them on in batches of
+// up to size tokens. While the Reader is waiting, tokens are passed on as
+// soon as they are received, so that they are not held back when the
+// lexer is slow, such as when it waits for input from a stream.
+func (p *prefetchSource) run(size int) {
+	defer close(p.batches)
+	batch := make([]Token, 0, size)
+	wanted := false
+	for {
+		var t Token
+		select {
+		case t = <-p.lex.tokens:
+		case <-p.want:
+			wanted = true
+			if len(batch) == 0 {
+				continue
+			}
+			if !p.send(batch) {
+				return
+			}
+			batch, wanted = make([]Token, 0, size), false
+			continue
+		case <-p.stop:
+			p.discard(batch)
+			return
+		}
+		batch = append(batch, t)
+		if t.Type == TypeEOF || t.Type == TypeError {
+			p.send(batch)
+			return
+		}
+		if wanted || len(batch) == size {
+			if !p.send(batch) {
+				return
+			}
+			batch, wanted = make([]Token, 0, size), false
+		}
+	}
+}
+
+// send passes batch on to the Reader. If the Reader is stopped instead,
+// it discards the tokens and returns false.
+func (p *prefetchSource) send(batch []Token) bool {
+	select {
+	case p.batches <- batch:
+		return true
+	case <-p.stop:
+		p.discard(batch)
+		return false
+	}
+}
+
+// discard releases the tokens of batch and the remaining tokens of the
+// lexer, without recording them as received.
+func (p *prefetchSource) discard(batch []Token) {
+	p.release(batch)
+	for t := range p.lex.tokens {
+		if p.lex.budget != nil {
+			p.lex.release(t)
+		}
+	}
+}
+
+// release releases the memory budget of the tokens in batch.
+func (p *prefetchSource) release(batch []Token) {
+	if p.lex.budget == nil {
+		return
+	}
+	for _, t := range batch {
+		p.lex.release(t)
+	}
+}
+
+func (p *prefetchSource) NextToken() Token {
+	for len(p.cur) == 0 {
+		if p.stopped {
+			return Token{}
+		}
+		var ok bool
+		select {
+		case p.cur, ok = <-p.batches:
+		default:
+			select {
+			case p.want <- struct{}{}:
+			default:
+			}
+			p.cur, ok = <-p.batches
+		}
+		if !ok {
+			return Token{}
+		}
+	}
+	t := p.cur[0]
+	p.cur = p.cur[1:]
+	p.lex.received(t)
+	return t
+}
@@ -0,0 +1,108 @@
+// Copyright (c) 2015, Ben Morgan. All rights reserved.
+// Use of this source code is governed by an MIT license
+// that can be found in the LICENSE file.
+
+package lex_test
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/goulash/lex"
+)
+
+var prefetchInput = strings.Repeat("alpha beta, gamma; delta\n", 4000)
+
+func TestPrefetchReader(t *testing.T) {
+	want := lex.Collect(lex.Lex("f", prefetchInput, lexWords))
+	for _, n := range []int{0, 1, 63, 64, 65, 4096} {
+		r := lex.NewPrefetchReader(lex.Lex("f", prefetchInput, lexWords), n)
+		for i, w := range want {
+			if got := r.Next(); got.Type != w.Type || got.Pos != w.Pos || got.Value != w.Value {
+				t.Fatalf("n=%d: token %d is %v, want %v", n, i, got, w)
+			}
+		}
+		if _, line, col := r.PosInfo(); line != 4001 || col != 1 {
+			t.Errorf("n=%d: last position is %d:%d", n, line, col)
+		}
+		r.Stop()
+	}
+}
+
+func TestPrefetchReaderStop(t *testing.T) {
+	l := lex.Lex("f", prefetchInput, lexWords)
+	r := lex.NewPrefetchReader(l, 256)
+	r.Next()
+	r.Stop()
+	select {
+	case <-l.Done():
+	case <-time.After(time.Second):
+		t.Fatal("lexer still running after Stop")
+	}
+	if tok := r.Next(); tok != (lex.Token{}) {
+		t.Errorf("got %v after Stop", tok)
+	}
+}
+
+func TestPrefetchReaderBudget(t *testing.T) {
+	l := lex.Lex("f", prefetchInput, lexWords, lex.WithMemoryBudget(4000))
+	r := lex.NewPrefetchReader(l, 4096)
+	defer r.Stop()
+	time.Sleep(10 * time.Millisecond) // let the buffer fill up
+	for tok := r.Next(); tok.Type != lex.TypeEOF && tok.Type != lex.TypeError; tok = r.Next() {
+	}
+	var b *lex.BudgetError
+	if err := l.Err(); !errors.As(err, &b) {
+		t.Errorf("got error %v, want budget error", err)
+	}
+}
+
+func TestPrefetchReaderStream(t *testing.T) {
+	pr, pw := io.Pipe()
+	l := lex.NewStream("s", pr)
+	go l.Run(lexWords)
+	r := lex.NewPrefetchReader(l, 4096)
+	defer r.Stop()
+	pw.Write([]byte("abc def"))
+	defer pw.Close()
+	got := make(chan lex.Token)
+	go func() { got <- r.Next() }()
+	select {
+	case tok := <-got:
+		if tok.Value != "abc" {
+			t.Errorf("got %v, want abc", tok)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("token held back while the stream is open")
+	}
+}
+
+// parse simulates the work of a parser on a token.
+func parse(t lex.Token) int {
+	n := 0
+	for i := 0; i < 200; i++ {
+		n += len(t.Value) * i
+	}
+	return n
+}
+
+func BenchmarkReader(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		r := lex.NewReader(lex.Lex("f", prefetchInput, lexWords, lex.WithBufferSize(256)))
+		for t := r.Next(); t.Type != lex.TypeEOF; t = r.Next() {
+			parse(t)
+		}
+	}
+}
+
+func BenchmarkPrefetchReader(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		r := lex.NewPrefetchReader(lex.Lex("f", prefetchInput, lexWords, lex.WithBufferSize(256)), 4096)
+		for t := r.Next(); t.Type != lex.TypeEOF; t = r.Next() {
+			parse(t)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+// Copyright (c) 2015, Ben Morgan. All rights reserved.
+// Use of this source code is governed by an MIT license
+// that can be found in the LICENSE file.
+
+package lex
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+// histogramWidth is the width of the longest bar of a histogram.
+const histogramWidth = 40
+
+// A TypeCount holds the number of tokens of a type and the bytes of
+// input they span.
+type TypeCount struct {
+	Type  Type
+	Count int
+	Bytes int
+}
+
+// A TokenProfile holds statistics about the tokens of an input, to see
+// which rules of a lexer dominate, see Profile.
+type TokenProfile struct {
+	Tokens int         // number of tokens
+	Bytes  int         // bytes of input spanned by the tokens
+	Types  []TypeCount // per type, most frequent first
+}
+
+// Profile reads the remaining tokens from l and returns their profile.
+func Profile(l *Lexer) TokenProfile {
+	counts := make(map[Type]*TypeCount)
+	var p TokenProfile
+	for {
+		t := l.NextToken()
+		if isEnd(t) {
+			break // finished without a TypeEOF token, see WithAutoEOF
+		}
+		c := counts[t.Type]
+		if c == nil {
+			c = &TypeCount{Type: t.Type}
+			counts[t.Type] = c
+		}
+		c.Count++
+		c.Bytes += t.End - t.Pos
+		p.Tokens++
+		p.Bytes += t.End - t.Pos
+		if t.Type == TypeEOF || t.Type == TypeError {
+			break
+		}
+	}
+	for _, c := range counts {
+		p.Types = append(p.Types, *c)
+	}
+	sort.Slice(p.Types, func(i, j int) bool {
+		a, b := p.Types[i], p.Types[j]
+		if a.Count != b.Count {
+			return a.Count > b.Count
+		}
+		return a.Type < b.Type
+	})
+	return p
+}
+
+// WriteHistogram writes p to w as a text histogram, one line per type,
+// with the share of the tokens and a bar proportional to it:
+//
+//	type     count  bytes  share
+//	Space    1200   1450   48.0%  ########################################
+//	Ident    800    5200   32.0%  ###########################
+//	Number   500    1300   20.0%  #################
+//
+// Type names are registered with RegisterTypeName.
+func (p TokenProfile) WriteHistogram(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintf(tw, "type\tcount\tbytes\tshare\n")
+	most := 1
+	if len(p.Types) > 0 {
+		most = p.Types[0].Count
+	}
+	for _, c := range p.Types {
+		share := 100 * float64(c.Count) / float64(p.Tokens)
+		bar := strings.Repeat("#", (c.Count*histogramWidth+most-1)/most)
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%.1f%%\t%s\n", c.Type, c.Count, c.Bytes, share, bar)
+	}
+	return tw.Flush()
+}
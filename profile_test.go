@@ -0,0 +1,54 @@
+// Copyright (c) 2015, Ben Morgan. All rights reserved.
+// Use of this source code is governed by an MIT license
+// that can be found in the LICENSE file.
+
+package lex_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/goulash/lex"
+)
+
+func TestProfile(t *testing.T) {
+	p := lex.Profile(lex.Lex("f", "ab cd  efg!", lexWords))
+	want := lex.TokenProfile{
+		Tokens: 7,
+		Bytes:  11,
+		Types: []lex.TypeCount{
+			{Type: typeWord, Count: 3, Bytes: 7},
+			{Type: typeSpace, Count: 2, Bytes: 3},
+			{Type: lex.TypeEOF, Count: 1, Bytes: 0},
+			{Type: typeOther, Count: 1, Bytes: 1},
+		},
+	}
+	if !reflect.DeepEqual(p, want) {
+		t.Fatalf("Profile = %+v, want %+v", p, want)
+	}
+	var b strings.Builder
+	if err := p.WriteHistogram(&b); err != nil {
+		t.Fatal(err)
+	}
+	const hist = `type     count  bytes  share
+Type(2)  3      7      42.9%  ########################################
+Type(3)  2      3      28.6%  ###########################
+EOF      1      0      14.3%  ##############
+Type(4)  1      1      14.3%  ##############
+`
+	if b.String() != hist {
+		t.Errorf("WriteHistogram =\n%s\nwant\n%s", b.String(), hist)
+	}
+}
+
+func TestProfileWithoutEOF(t *testing.T) {
+	p := lex.Profile(lex.Lex("f", "", func(l *lex.Lexer) lex.StateFn { return nil }, lex.WithAutoEOF(false)))
+	if p.Tokens != 0 || p.Bytes != 0 || len(p.Types) != 0 {
+		t.Errorf("Profile = %+v, want no tokens", p)
+	}
+	var b strings.Builder
+	if err := p.WriteHistogram(&b); err != nil || b.String() != "type  count  bytes  share\n" {
+		t.Errorf("WriteHistogram = %q, %v", b.String(), err)
+	}
+}
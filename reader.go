@@ -4,37 +4,114 @@
 
 package lex
 
+import (
+	"fmt"
+	"time"
+)
+
+// A TokenSource is anything that a Reader can read from, such as a Lexer.
+// Parsers that read from a TokenSource can be tested with handcrafted
+// tokens, see SliceSource.
+type TokenSource interface {
+	NextToken() Token
+}
+
 type Reader struct {
-	lex *Lexer
-	buf *Token
+	src  TokenSource
+	lex  *Lexer  // for position information
+	last Token   // last token returned by Next
+	buf  []Token // unread tokens, the next one last
+	log  []Token // tokens read from src in open transactions
+	txs  int     // number of open transactions
+}
+
+// NewReader returns a new Reader reading from src. Positions are only
+// known if src is a Lexer or the tokens were emitted by one.
+func NewReader(src TokenSource) *Reader {
+	l, ok := src.(*Lexer)
+	if !ok {
+		l = new(Lexer)
+	}
+	return &Reader{src: src, lex: l}
+}
+
+// read returns the next token from the source.
+func (r *Reader) read() Token {
+	t := r.src.NextToken()
+	r.record(t)
+	return t
 }
 
-func NewReader(l *Lexer) *Reader {
-	return &Reader{lex: l}
+// record records that t was read from the source, so that it can be
+// unread by Rollback.
+func (r *Reader) record(t Token) {
+	if r.txs > 0 {
+		r.log = append(r.log, t)
+	}
 }
 
 func (r *Reader) Peek() Token {
-	if r.buf == nil {
-		t := r.lex.NextToken()
-		r.buf = &t
+	if len(r.buf) == 0 {
+		r.buf = append(r.buf, r.read())
 	}
-	return *r.buf
+	return r.buf[len(r.buf)-1]
 }
 
 func (r *Reader) Next() Token {
-	if r.buf != nil {
-		t := r.buf
-		r.buf = nil
-		return *t
+	var t Token
+	if n := len(r.buf); n > 0 {
+		t = r.buf[n-1]
+		r.buf = r.buf[:n-1]
+	} else {
+		t = r.read()
 	}
-	return r.lex.NextToken()
+	r.consumed(t)
+	return t
 }
 
-func (r *Reader) Backup(t Token) {
-	if r.buf != nil {
-		panic("cannot backup more than one token")
+// consumed records that t was returned by Next.
+func (r *Reader) consumed(t Token) {
+	r.last = t
+}
+
+// NextTimeout is like Next, but waits at most d for the lexer to emit
+// a token, so that interactive frontends are not blocked forever by a
+// state function that stalls. If no token arrives in time, it returns
+// false and a TypeError token positioned after the last token read,
+// which describes the problem. The token may still arrive later.
+//
+// Only lexers can be waited for; other sources block like Next.
+func (r *Reader) NextTimeout(d time.Duration) (Token, bool) {
+	if len(r.buf) > 0 {
+		return r.Next(), true
 	}
-	r.buf = &t
+	l, ok := r.src.(*Lexer)
+	if !ok {
+		return r.Next(), true
+	}
+	t, ok := l.nextTokenWithin(d)
+	if !ok {
+		msg := fmt.Sprintf("no token within %v", d)
+		if d <= 0 {
+			msg = "no token available"
+		}
+		return Token{Type: TypeError, Pos: r.last.End, End: r.last.End, Value: msg, pb: r.last.pb}, false
+	}
+	r.record(t)
+	r.consumed(t)
+	return t, true
+}
+
+// TryNext is like Next, but returns false instead of waiting if the
+// lexer has not emitted the next token yet, see NextTimeout.
+func (r *Reader) TryNext() (Token, bool) {
+	return r.NextTimeout(0)
+}
+
+// Backup unreads the token t, which is returned by the next call to Next.
+// Backup can be called repeatedly to unread several tokens.
+func (r *Reader) Backup(t Token) {
+	r.buf = append(r.buf, t)
 }
 
 // Expect reads the expected tokens and returns them in a slice.
@@ -55,6 +132,168 @@ func (r *Reader) Expect(types ...Type) ([]Token, bool) {
 	return tokens, ok
 }
 
+// PosInfo returns the position of the last token returned by Next.
+// Tokens read by Peek are not taken into account.
 func (r *Reader) PosInfo() (name string, line, col int) {
-	return r.lex.Name(), r.lex.LineNumber(), r.lex.ColumnNumber()
+	return r.PosOf(r.last)
+}
+
+// PosOf returns the position of the token t, which must have been read
+// from r. If the position is unknown, the line and column are 0.
+func (r *Reader) PosOf(t Token) (name string, line, col int) {
+	return r.lex.position(t)
+}
+
+// ExpectAny reads the next token and returns it, along with whether
+// it has one of the given types.
+func (r *Reader) ExpectAny(types ...Type) (Token, bool) {
+	t := r.Next()
+	return t, hasType(t, types)
+}
+
+// Accept consumes the next token only if it has one of the given types.
+// If it does not, the token remains unread and false is returned.
+func (r *Reader) Accept(types ...Type) (Token, bool) {
+	t := r.Peek()
+	if !hasType(t, types) {
+		return t, false
+	}
+	return r.Next(), true
+}
+
+// Insert pushes toks to the front of the token stream, so that the next
+// call to Next returns toks[0]. This is useful for splitting one token
+// into several, such as >> into two > when closing type parameters.
+func (r *Reader) Insert(toks ...Token) {
+	for i := len(toks) - 1; i >= 0; i-- {
+		r.buf = append(r.buf, toks[i])
+	}
+}
+
+// Window returns the next n tokens without consuming them, which is
+// useful for parsers that need more than one token of lookahead.
+// If the token stream ends before, the window is shorter and ends with
+// the TypeEOF or TypeError token. If n is not positive, Window returns
+// nil.
+func (r *Reader) Window(n int) []Token {
+	if n <= 0 {
+		return nil
+	}
+	w := make([]Token, 0, n)
+	for i := len(r.buf) - 1; i >= 0 && len(w) < n; i-- {
+		w = append(w, r.buf[i])
+	}
+	buffered := len(w)
+	for len(w) < n {
+		if k := len(w); k > 0 && (w[k-1].Type == TypeEOF || w[k-1].Type == TypeError) {
+			break
+		}
+		w = append(w, r.read())
+	}
+	if read := w[buffered:]; len(read) > 0 {
+		buf := make([]Token, 0, len(r.buf)+len(read))
+		for i := len(read) - 1; i >= 0; i-- {
+			buf = append(buf, read[i])
+		}
+		r.buf = append(buf, r.buf...)
+	}
+	return w
+}
+
+// PeekType returns the type of the next token without consuming it.
+func (r *Reader) PeekType() Type {
+	return r.Peek().Type
+}
+
+// At reports whether the next token has one of the given types,
+// without consuming it.
+func (r *Reader) At(types ...Type) bool {
+	return hasType(r.Peek(), types)
+}
+
+func hasType(t Token, types []Type) bool {
+	for _, typ := range types {
+		if t.Type == typ {
+			return true
+		}
+	}
+	return false
+}
+
+// A Tx is a transaction on a Reader, see Reader.Begin.
+type Tx struct {
+	r     *Reader
+	start int     // length of the log when the transaction began
+	buf   []Token // unread tokens when the transaction began
+}
+
+// Begin starts a transaction, which records all tokens read until it is
+// ended by either Commit or Rollback. This allows a backtracking parser
+// to attempt one production and fall back to another with unlimited
+// lookahead:
+//
+//	tx := r.Begin()
+//	if n, ok := parseCall(r); ok {
+//	    tx.Commit()
+//	    return n
+//	}
+//	tx.Rollback()
+//	return parseExpr(r)
+//
+// Transactions can be nested, but must be ended in reverse order.
+func (r *Reader) Begin() Tx {
+	r.txs++
+	return Tx{r: r, start: len(r.log), buf: append([]Token(nil), r.buf...)}
+}
+
+// Commit ends the transaction, keeping all tokens read as consumed.
+// If the transaction is nested, an outer Rollback still unreads them.
+func (tx Tx) Commit() {
+	tx.r.endTx()
+}
+
+// Rollback ends the transaction, unreading all tokens read since
+// the transaction began. Tokens unread with Backup or Insert during the
+// transaction are discarded.
+func (tx Tx) Rollback() {
+	r := tx.r
+	start := min(tx.start, len(r.log))
+	buf := make([]Token, 0, len(r.log)-start+len(tx.buf))
+	for i := len(r.log) - 1; i >= start; i-- {
+		buf = append(buf, r.log[i])
+	}
+	r.buf = append(buf, tx.buf...)
+	r.log = r.log[:start]
+	r.endTx()
+}
+
+func (r *Reader) endTx() {
+	r.txs--
+	if r.txs == 0 {
+		r.log = r.log[:0]
+	}
+}
+
+// Demand reads the next token, which must have type t. If it has another
+// type, it is left unread and an *Error is returned, positioned at the
+// token, with a message such as
+//
+//	expected Ident in parameter list, got Number "42"
+//
+// Type names are registered with RegisterTypeName.
+func (r *Reader) Demand(t Type, context string) (Token, error) {
+	tok, ok := r.Accept(t)
+	if ok {
+		return tok, nil
+	}
+	got := tok.String()
+	if tok.Type == TypeEOF {
+		got = "EOF"
+	}
+	msg := "expected " + t.String()
+	if context != "" {
+		msg += " in " + context
+	}
+	file, line, col := r.PosOf(tok)
+	return tok, &Error{File: file, Line: line, Col: col, Msg: msg + ", got " + got}
 }
@@ -0,0 +1,254 @@
+// Copyright (c) 2015, Ben Morgan. All rights reserved.
+// Use of this source code is governed by an MIT license
+// that can be found in the LICENSE file.
+
+package lex_test
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/goulash/lex"
+)
+
+func TestReaderAccept(t *testing.T) {
+	r := lex.NewReader(lex.Lex("f", "ab cd", lexWords))
+	if tok, ok := r.Accept(typeSpace, typeOther); ok || tok.Value != "ab" {
+		t.Errorf("Accept(space, other) = %v, %t; want ab left unread", tok, ok)
+	}
+	if tok, ok := r.Accept(typeSpace, typeWord); !ok || tok.Value != "ab" {
+		t.Errorf("Accept(space, word) = %v, %t; want ab", tok, ok)
+	}
+	if tok, ok := r.ExpectAny(typeWord, typeOther); ok || tok.Type != typeSpace {
+		t.Errorf("ExpectAny(word, other) = %v, %t; want the space consumed", tok, ok)
+	}
+	if tok, ok := r.ExpectAny(typeOther, typeWord); !ok || tok.Value != "cd" {
+		t.Errorf("ExpectAny(other, word) = %v, %t; want cd", tok, ok)
+	}
+	if tok, ok := r.ExpectAny(); ok || tok.Type != lex.TypeEOF {
+		t.Errorf("ExpectAny() = %v, %t; want EOF and false", tok, ok)
+	}
+}
+
+func TestReaderTx(t *testing.T) {
+	r := lex.NewReader(lex.Lex("f", "a b c d", lexWords))
+	tx := r.Begin()
+	r.Next()
+	inner := r.Begin()
+	r.Next()
+	r.Next()
+	inner.Commit()
+	if tok := r.Next(); tok.Value != " " {
+		t.Fatalf("got %v, want a space", tok)
+	}
+	tx.Rollback()
+	if tok := r.Next(); tok.Value != "a" {
+		t.Errorf("after outer rollback got %v, want a", tok)
+	}
+
+	tx = r.Begin()
+	r.Next()
+	tok := r.Next()
+	r.Backup(tok)
+	inner = r.Begin()
+	r.Next()
+	r.Next()
+	inner.Rollback()
+	if tok := r.Next(); tok.Value != "b" {
+		t.Errorf("after inner rollback got %v, want b", tok)
+	}
+	tx.Commit()
+	if tok := r.Next(); tok.Value != " " {
+		t.Errorf("after commit got %v, want the space after b", tok)
+	}
+	r.Begin().Rollback()
+	if tok := r.Next(); tok.Value != "c" {
+		t.Errorf("after empty rollback got %v, want c", tok)
+	}
+}
+
+func TestReaderPositions(t *testing.T) {
+	pos := func(name string, line, col int) string { return fmt.Sprintf("%s:%d:%d", name, line, col) }
+
+	r := lex.NewReader(lex.Lex("f", "ab\ncd ef", lexWords))
+	ab := r.Next()
+	r.Next()
+	r.Peek()
+	if got := pos(r.PosInfo()); got != "f:1:3" {
+		t.Errorf("after peeking cd got %s, want the newline at f:1:3", got)
+	}
+	cd := r.Next()
+	r.Next()
+	r.Next()
+	if got := pos(r.PosOf(ab)) + " " + pos(r.PosOf(cd)); got != "f:1:1 f:2:1" {
+		t.Errorf("got %s, want f:1:1 f:2:1", got)
+	}
+
+	toks := lex.Collect(lex.Lex("g", "a\nb", lexWords))
+	r = lex.NewReader(lex.SliceSource(toks))
+	r.Next()
+	r.Next()
+	if got := pos(r.PosOf(r.Next())); got != "g:2:1" {
+		t.Errorf("got %s for a recorded token, want g:2:1", got)
+	}
+	r = lex.NewReader(lex.SliceSource([]lex.Token{{Type: typeWord, Value: "x"}}))
+	if got := pos(r.PosOf(r.Next())); got != ":0:0" {
+		t.Errorf("got %s for a handcrafted token, want it unknown", got)
+	}
+}
+
+func TestReaderPeekType(t *testing.T) {
+	r := lex.NewReader(lex.Lex("f", "ab !", lexWords))
+	if typ := r.PeekType(); typ != typeWord || !r.At(typeOther, typeWord) || r.At(typeSpace) || r.At() {
+		t.Errorf("got %v, want to be at the word", typ)
+	}
+	if tok := r.Next(); tok.Value != "ab" {
+		t.Errorf("got %v, want ab left unread by PeekType and At", tok)
+	}
+	r.Next()
+	r.Next()
+	if !r.At(lex.TypeEOF) || r.PeekType() != lex.TypeEOF {
+		t.Errorf("got %v, want EOF", r.Peek())
+	}
+}
+
+func TestReaderInsert(t *testing.T) {
+	next := func(r *lex.Reader, n int) string {
+		var vs []string
+		for i := 0; i < n; i++ {
+			vs = append(vs, r.Next().Value)
+		}
+		return strings.Join(vs, "|")
+	}
+	split := func(r *lex.Reader) {
+		tok := r.Next()
+		a, b := tok, tok
+		a.Value, a.End = tok.Value[:1], tok.Pos+1
+		b.Value, b.Pos = tok.Value[1:], tok.Pos+1
+		r.Insert(a, b)
+	}
+
+	r := lex.NewReader(lex.Lex("f", "ab cd", lexWords))
+	split(r)
+	if got := next(r, 4); got != "a|b| |cd" {
+		t.Errorf("got %s, want ab split", got)
+	}
+
+	r = lex.NewReader(lex.Lex("f", "ab cd", lexWords))
+	r.Peek()
+	tx := r.Begin()
+	split(r)
+	if got := next(r, 2); got != "a|b" {
+		t.Errorf("got %s in the transaction, want ab split", got)
+	}
+	r.Insert(lex.Token{Type: typeOther, Value: "x"})
+	r.Peek()
+	tx.Rollback()
+	if got := next(r, 4); got != "ab| |cd|" {
+		t.Errorf("got %s after rollback, want the tokens as before the transaction", got)
+	}
+
+	r = lex.NewReader(lex.Lex("f", "ab cd", lexWords))
+	tx = r.Begin()
+	r.Next()
+	r.Next()
+	inner := r.Begin()
+	r.Insert(lex.Token{Type: typeOther, Value: "x"})
+	r.Next()
+	inner.Commit()
+	tx.Rollback()
+	if got := next(r, 4); got != "ab| |cd|" {
+		t.Errorf("got %s after rolling back a committed insertion", got)
+	}
+}
+
+func TestReaderDemand(t *testing.T) {
+	const typeIdent lex.Type = 210
+	lex.RegisterTypeName(typeIdent, "Ident")
+	r := lex.NewReader(lex.Lex("f", "ab\n!", lexWords))
+	if tok, err := r.Demand(typeWord, "list"); err != nil || tok.Value != "ab" {
+		t.Errorf("got %v, %v; want ab", tok, err)
+	}
+	tok, err := r.Demand(typeIdent, "list")
+	var e *lex.Error
+	if !errors.As(err, &e) || err.Error() != `f:1:3: expected Ident in list, got Type(4) "\n"` {
+		t.Errorf("got %v", err)
+	}
+	if next := r.Next(); next != tok {
+		t.Errorf("got %v, want %v left unread", next, tok)
+	}
+	r.Next()
+	if _, err := r.Demand(typeIdent, ""); err == nil || err.Error() != "f:2:2: expected Ident, got EOF" {
+		t.Errorf("got %v at EOF", err)
+	}
+}
+
+func TestReaderWindow(t *testing.T) {
+	window := func(w []lex.Token) string {
+		return strings.Join(values(w), "|")
+	}
+	r := lex.NewReader(lex.Lex("f", "ab cd", lexWords))
+	if got := window(r.Window(2)); got != "ab| " {
+		t.Errorf("Window(2) = %q", got)
+	}
+	if got := r.Next().Value; got != "ab" {
+		t.Errorf("Next after Window = %q, want ab", got)
+	}
+	r.Backup(lex.Token{Type: typeOther, Value: "x"})
+	if got := window(r.Window(10)); got != "x| |cd|" {
+		t.Errorf("Window(10) = %q, want it to end at EOF", got)
+	}
+	if r.Window(0) != nil || r.Window(-1) != nil {
+		t.Errorf("Window of no tokens is not nil")
+	}
+
+	tx := r.Begin()
+	r.Next()
+	if got := window(r.Window(2)); got != " |cd" {
+		t.Errorf("Window(2) in a transaction = %q", got)
+	}
+	r.Next()
+	tx.Rollback()
+	if got := window(r.Window(4)); got != "x| |cd|" {
+		t.Errorf("Window(4) after Rollback = %q", got)
+	}
+}
+
+func TestReaderNextTimeout(t *testing.T) {
+	release := make(chan struct{})
+	l := lex.Lex("f", "ab cd", func(l *lex.Lexer) lex.StateFn {
+		l.AcceptRun("ab")
+		l.Emit(typeWord)
+		<-release // stall
+		return lexWords
+	})
+	r := lex.NewReader(l)
+	if tok, ok := r.NextTimeout(time.Second); !ok || tok.Value != "ab" {
+		t.Fatalf("NextTimeout = %v, %t; want ab", tok, ok)
+	}
+	tok, ok := r.NextTimeout(10 * time.Millisecond)
+	if file, line, col := r.PosOf(tok); ok || tok.Type != lex.TypeError || tok.Value != "no token within 10ms" || fmt.Sprintf("%s:%d:%d", file, line, col) != "f:1:3" {
+		t.Errorf("NextTimeout of a stalled lexer = %v, %t at %s:%d:%d", tok, ok, file, line, col)
+	}
+	if tok, ok := r.TryNext(); ok || tok.Value != "no token available" {
+		t.Errorf("TryNext = %v, %t", tok, ok)
+	}
+	close(release)
+
+	tx := r.Begin()
+	if tok, ok := r.NextTimeout(time.Second); !ok || tok.Value != " " {
+		t.Errorf("NextTimeout = %v, %t; want the space", tok, ok)
+	}
+	tx.Rollback()
+	if tok, ok := r.TryNext(); !ok || tok.Value != " " {
+		t.Errorf("TryNext after Rollback = %v, %t; want the space again", tok, ok)
+	}
+
+	r = lex.NewReader(lex.SliceSource([]lex.Token{{Type: typeWord, Value: "x"}}))
+	if tok, ok := r.TryNext(); !ok || tok.Value != "x" {
+		t.Errorf("TryNext from a slice = %v, %t", tok, ok)
+	}
+}
@@ -0,0 +1,58 @@
+// Copyright (c) 2015, Ben Morgan. All rights reserved.
+// Use of this source code is governed by an MIT license
+// that can be found in the LICENSE file.
+
+package lex
+
+// A Recording holds all tokens emitted by a lexer, so that they can be
+// read multiple times without lexing the input again.
+type Recording struct {
+	lex    *Lexer
+	Tokens []Token
+}
+
+// Record reads all tokens from l until the channel is closed,
+// and returns them as a Recording.
+func Record(l *Lexer) *Recording {
+	rec := &Recording{lex: l}
+	for t := range l.tokens {
+		l.received(t)
+		rec.Tokens = append(rec.Tokens, t)
+	}
+	return rec
+}
+
+// Reader returns a new Reader that replays the recorded tokens.
+// Each Reader is independent of the others.
+func (rec *Recording) Reader() *Reader {
+	return &Reader{src: SliceSource(rec.Tokens), lex: rec.lex}
+}
+
+// SliceSource returns a TokenSource reading the tokens from a slice.
+// Once exhausted, a TypeEOF token positioned at the end of the last
+// token is returned, so that the slice need not end with one:
+//
+//	r := lex.NewReader(lex.SliceSource([]lex.Token{
+//	    {Type: TypeIdent, Value: "x"},
+//	    {Type: TypeOp, Value: "+"},
+//	}))
+func SliceSource(tokens []Token) TokenSource {
+	return &sliceSource{tokens: tokens}
+}
+
+type sliceSource struct {
+	tokens []Token
+	i      int
+}
+
+func (s *sliceSource) NextToken() Token {
+	if s.i < len(s.tokens) {
+		s.i++
+		return s.tokens[s.i-1]
+	}
+	if len(s.tokens) == 0 {
+		return Token{Type: TypeEOF}
+	}
+	last := s.tokens[len(s.tokens)-1]
+	return Token{Type: TypeEOF, Pos: last.End, End: last.End, pb: last.pb}
+}
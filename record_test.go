@@ -0,0 +1,78 @@
+// Copyright (c) 2015, Ben Morgan. All rights reserved.
+// Use of this source code is governed by an MIT license
+// that can be found in the LICENSE file.
+
+package lex_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/goulash/lex"
+)
+
+func TestRecord(t *testing.T) {
+	rec := lex.Record(lex.Lex("f", "ab\ncd", lexWords))
+	if len(rec.Tokens) != 4 {
+		t.Fatalf("recorded %q", values(rec.Tokens))
+	}
+	r1, r2 := rec.Reader(), rec.Reader()
+	r1.Next()
+	for i, want := range []string{"ab", "\n", "cd", ""} {
+		tok := r2.Next()
+		file, line, col := r2.PosOf(tok)
+		if tok.Value != want || fmt.Sprintf("%s:%d:%d", file, line, col) != fmt.Sprintf("f:%d:%d", 1+i/2, 1+i%2*2) {
+			t.Errorf("replayed %v at %s:%d:%d", tok, file, line, col)
+		}
+	}
+	if tok := r1.Next(); tok.Value != "\n" {
+		t.Errorf("second reader got %v, want the newline", tok)
+	}
+	if tok := r2.Next(); tok.Type != lex.TypeEOF {
+		t.Errorf("exhausted reader got %v, want EOF again", tok)
+	}
+}
+
+func TestSliceSource(t *testing.T) {
+	var _ lex.TokenSource = (*lex.Lexer)(nil)
+
+	r := lex.NewReader(lex.SliceSource([]lex.Token{
+		{Type: typeWord, Value: "x"},
+		{Type: typeSpace, Value: " "},
+		{Type: lex.TypeEOF},
+	}))
+	if toks, ok := r.Expect(typeWord, typeSpace); !ok || toks[0].Value != "x" {
+		t.Errorf("Expect = %v, %t", toks, ok)
+	}
+	if file, line, col := r.PosInfo(); file != "" || line != 0 || col != 0 {
+		t.Errorf("PosInfo = %s:%d:%d, want an unknown position", file, line, col)
+	}
+	if tok, ok := r.TryNext(); !ok || tok.Type != lex.TypeEOF {
+		t.Errorf("TryNext = %v, %t; want EOF", tok, ok)
+	}
+	if _, err := r.Demand(typeWord, ""); err == nil || err.Error() != ":0:0: expected Type(2), got EOF" {
+		t.Errorf("Demand at the end = %v", err)
+	}
+
+	r = lex.NewReader(lex.SliceSource(nil))
+	if tok := r.Next(); tok.Type != lex.TypeEOF {
+		t.Errorf("empty source returned %v, want EOF", tok)
+	}
+
+	// A slice without a TypeEOF token ends with one.
+	toks := lex.Collect(lex.Lex("f", "ab\ncd", lexWords))
+	r = lex.NewReader(lex.SliceSource(toks[:2]))
+	var got []lex.Token
+	for tok := range r.All() {
+		got = append(got, tok)
+	}
+	if s := describeTypes(got); s != `2:"ab" 4:"\n" 1:""` {
+		t.Errorf("All = %s, want the tokens and EOF", s)
+	}
+	if tok := r.Next(); tok.Type != lex.TypeEOF {
+		t.Errorf("Next after the end = %v, want EOF", tok)
+	}
+	if _, line, col := r.PosInfo(); line != 2 || col != 1 {
+		t.Errorf("EOF at %d:%d, want 2:1", line, col)
+	}
+}
@@ -0,0 +1,67 @@
+// Copyright (c) 2015, Ben Morgan. All rights reserved.
+// Use of this source code is governed by an MIT license
+// that can be found in the LICENSE file.
+
+package lex
+
+// A TokenRef is a compact reference to a token, consisting of its type
+// and its span in the input. The text can be retrieved with Lexer.Text.
+//
+// Working with TokenRefs instead of Tokens avoids holding on to
+// substrings of the input, which is useful for very large inputs.
+type TokenRef struct {
+	Type
+	Start int
+	End   int
+
+	pb *posBase // input the span refers to
+}
+
+// Ref returns a reference to the span of t in the input.
+func (t Token) Ref() TokenRef {
+	return TokenRef{Type: t.Type, Start: t.Pos, End: t.End, pb: t.pb}
+}
+
+// NextTokenRef is like NextToken, but returns a reference to the token.
+// The lexer still sends complete tokens; to lex without building them,
+// see RunRefs.
+func (l *Lexer) NextTokenRef() TokenRef {
+	return l.NextToken().Ref()
+}
+
+// RunRefs is like RunSink, but delivers references to the tokens instead
+// of the tokens, so that lexing huge inputs neither builds nor transfers
+// the values of tokens:
+//
+//	l.RunRefs(sf, func(ref lex.TokenRef) bool {
+//	    if ref.Type == TypeIdent {
+//	        idents[l.Text(ref)]++
+//	    }
+//	    return true
+//	})
+//
+// Options that change the values of tokens, such as WithCopyValues and
+// WithIdentifierFold, have no effect, as the text is always taken from
+// the input. If sink returns false, lexing stops without emitting
+// further tokens. NextToken must not be used with RunRefs.
+func (l *Lexer) RunRefs(fn StateFn, sink func(TokenRef) bool) {
+	l.refSink = sink
+	defer func() { l.refSink = nil }()
+	l.Run(fn)
+}
+
+// Text returns the input text that ref refers to, which may be in an
+// input pushed with PushInput. If the text is not available, such as for
+// a token spanning several lines emitted with WithCopyValues, the empty
+// string is returned.
+func (l *Lexer) Text(ref TokenRef) string {
+	pb := ref.pb
+	if pb == nil {
+		pb = l.last.pb
+	}
+	start, end := ref.Start-pb.off, ref.End-pb.off
+	if start < 0 || start > end || end > len(pb.input) {
+		return ""
+	}
+	return pb.input[start:end]
+}
@@ -0,0 +1,66 @@
+// Copyright (c) 2015, Ben Morgan. All rights reserved.
+// Use of this source code is governed by an MIT license
+// that can be found in the LICENSE file.
+
+package lex_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/goulash/lex"
+)
+
+func TestTextAfterPushInput(t *testing.T) {
+	l := lex.Lex("f", "main text", func(l *lex.Lexer) lex.StateFn {
+		l.Inc(4)
+		l.Emit(typeWord)
+		l.PushInput("inc", "xy")
+		return lexWords
+	})
+	var refs []lex.TokenRef
+	for {
+		ref := l.NextTokenRef()
+		refs = append(refs, ref)
+		if ref.Type == lex.TypeEOF || ref.Type == lex.TypeError {
+			break
+		}
+	}
+	var texts []string
+	for _, ref := range refs {
+		texts = append(texts, l.Text(ref))
+	}
+	if got := strings.Join(texts, "|"); got != "main|xy| |text|" {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestRunRefs(t *testing.T) {
+	in := "ab cd, ef"
+	want := lex.Collect(lex.Lex("f", in, lexWords))
+	l := lex.New("f", in, lex.WithCopyValues())
+	var got []string
+	l.RunRefs(lexWords, func(ref lex.TokenRef) bool {
+		got = append(got, l.Text(ref))
+		return true
+	})
+	if strings.Join(got, "|") != strings.Join(values(want), "|") {
+		t.Errorf("got %q, want %q", got, values(want))
+	}
+}
+
+func TestRunRefsAllocs(t *testing.T) {
+	in := strings.Repeat("ab cd ", 1000)
+	l := lex.New("f", in)
+	n := 0
+	allocs := testing.AllocsPerRun(10, func() {
+		l.Reset("f", in)
+		l.RunRefs(lexWords, func(ref lex.TokenRef) bool {
+			n += len(l.Text(ref))
+			return true
+		})
+	})
+	if allocs > 10 {
+		t.Errorf("lexing 4000 tokens took %v allocations", allocs)
+	}
+}
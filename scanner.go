@@ -0,0 +1,68 @@
+// Copyright (c) 2015, Ben Morgan. All rights reserved.
+// Use of this source code is governed by an MIT license
+// that can be found in the LICENSE file.
+
+package lex
+
+// A TokenScanner reads the tokens of an input like bufio.Scanner reads
+// lines, see ScanTokens.
+type TokenScanner struct {
+	l    *Lexer
+	tok  Token
+	err  error
+	done bool
+}
+
+// ScanTokens starts lexing input with sf and returns a TokenScanner for
+// the tokens, which is convenient for quick scripts:
+//
+//	s := lex.ScanTokens(input, lexText)
+//	for s.Scan() {
+//	    fmt.Println(s.Token())
+//	}
+//	if err := s.Err(); err != nil {
+//	    log.Fatal(err)
+//	}
+//
+// The TypeEOF token and a TypeError token end the scan and are not
+// returned by Token. If the scan is abandoned before Scan returns false,
+// Stop must be called so that the lexing goroutine exits.
+func ScanTokens(input string, sf StateFn, opts ...Option) *TokenScanner {
+	return &TokenScanner{l: Lex("scan", input, sf, opts...)}
+}
+
+// Scan advances to the next token, which is then available through
+// Token. It returns false when the scan stops, either by reaching the
+// end of the input or an error, after which Err returns the error.
+func (s *TokenScanner) Scan() bool {
+	if s.done {
+		return false
+	}
+	t := s.l.NextToken()
+	switch {
+	case isEnd(t) || t.Type == TypeEOF:
+		s.Stop()
+		return false
+	case t.Type == TypeError:
+		s.err = s.l.newError(t)
+		s.Stop()
+		return false
+	}
+	s.tok = t
+	return true
+}
+
+// Token returns the token read by the last call to Scan.
+func (s *TokenScanner) Token() Token { return s.tok }
+
+// Err returns the first error encountered by the scan as an *Error,
+// or nil if it reached the end of the input.
+func (s *TokenScanner) Err() error { return s.err }
+
+// Stop ends the scan, after which Scan returns false.
+func (s *TokenScanner) Stop() {
+	if !s.done {
+		s.done = true
+		s.l.Drain()
+	}
+}
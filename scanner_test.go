@@ -0,0 +1,71 @@
+// Copyright (c) 2015, Ben Morgan. All rights reserved.
+// Use of this source code is governed by an MIT license
+// that can be found in the LICENSE file.
+
+package lex_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/goulash/lex"
+)
+
+func TestScanTokens(t *testing.T) {
+	s := lex.ScanTokens("ab cd", lexWords)
+	var got []string
+	for s.Scan() {
+		got = append(got, s.Token().Value)
+	}
+	if want := []string{"ab", " ", "cd"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("tokens = %q, want %q", got, want)
+	}
+	if err := s.Err(); err != nil {
+		t.Errorf("Err = %v", err)
+	}
+	if s.Scan() {
+		t.Error("Scan after the end = true")
+	}
+}
+
+func TestScanTokensError(t *testing.T) {
+	s := lex.ScanTokens("ab\ncd!", func(l *lex.Lexer) lex.StateFn {
+		for l.AcceptFuncRun(func(r rune) bool { return r >= 'a' && r <= 'z' || r == '\n' }) > 0 {
+			l.Emit(typeWord)
+		}
+		return l.Errorf("unexpected %q", l.Peek())
+	})
+	var got []string
+	for s.Scan() {
+		got = append(got, s.Token().Value)
+	}
+	if want := []string{"ab\ncd"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("tokens = %q, want %q", got, want)
+	}
+	var e *lex.Error
+	if err := s.Err(); !errors.As(err, &e) || err.Error() != `scan:2:3: unexpected '!'` {
+		t.Errorf("Err = %v, want *lex.Error", err)
+	}
+}
+
+func TestScanTokensWithoutEOF(t *testing.T) {
+	s := lex.ScanTokens("ab", lexWords, lex.WithAutoEOF(false))
+	for s.Scan() {
+	}
+	if err := s.Err(); err != nil {
+		t.Errorf("Err = %v, want nil", err)
+	}
+}
+
+func TestScanTokensStop(t *testing.T) {
+	s := lex.ScanTokens("a b c d", lexWords)
+	if !s.Scan() || s.Token().Value != "a" {
+		t.Fatalf("Scan = %v", s.Token())
+	}
+	s.Stop()
+	s.Stop()
+	if s.Scan() || s.Err() != nil {
+		t.Errorf("Scan after Stop = true or Err = %v", s.Err())
+	}
+}
@@ -0,0 +1,79 @@
+// Copyright (c) 2015, Ben Morgan. All rights reserved.
+// Use of this source code is governed by an MIT license
+// that can be found in the LICENSE file.
+
+package lex
+
+import (
+	"math"
+	"strings"
+)
+
+// SkipTo consumes the input up to the nearest occurrence of any of the
+// literal patterns, without consuming the pattern, and returns the index
+// of the pattern found there. If several patterns occur at the nearest
+// position, the first one in patterns wins. If none occurs, the rest of
+// the input is consumed and false is returned. This is useful for the
+// text runs of template languages:
+//
+//	i, ok := l.SkipTo("{{", "<%", "${")
+//	l.EmitNonEmpty(TypeText)
+//	if !ok {
+//	    return nil
+//	}
+//	return lexAction[i]
+//
+// The input is searched for the first bytes of the patterns, so long
+// runs of text without them are skipped quickly. Like Next, SkipTo
+// only continues after the end of a pushed input at the start of a
+// token, so the text before the end must be emitted first.
+func (l *Lexer) SkipTo(patterns ...string) (index int, found bool) {
+	// next holds the position of the next occurrence of each first byte,
+	// -1 if it is unknown, and absent if it does not occur in the input.
+	const absent = math.MaxInt
+	var next [256]int
+	var firsts []byte
+	for i, p := range patterns {
+		if p == "" {
+			return i, true
+		}
+		if next[p[0]] == 0 {
+			next[p[0]] = -1
+			firsts = append(firsts, p[0])
+		}
+	}
+	l.autoSkip()
+	from := l.pos
+	for {
+		at := absent
+		for _, b := range firsts {
+			if next[b] < from {
+				next[b] = absent
+				if i := strings.IndexByte(l.input[from:], b); i >= 0 {
+					next[b] = from + i
+				}
+			}
+			at = min(at, next[b])
+		}
+		if at == absent {
+			// Consume the input searched so far, and continue with more
+			// input or after a pushed input like Next.
+			l.pos, l.width = len(l.input), 0
+			if l.atEnd() {
+				return -1, false
+			}
+			from = l.pos
+			for _, b := range firsts {
+				next[b] = -1
+			}
+			continue
+		}
+		for i, p := range patterns {
+			if p[0] == l.input[at] && l.HasPrefixAfter(at-l.pos, p) {
+				l.pos, l.width = at, 0
+				return i, true
+			}
+		}
+		from = at + 1
+	}
+}
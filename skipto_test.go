@@ -0,0 +1,107 @@
+// Copyright (c) 2015, Ben Morgan. All rights reserved.
+// Use of this source code is governed by an MIT license
+// that can be found in the LICENSE file.
+
+package lex_test
+
+import (
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/goulash/lex"
+)
+
+// lexTemplate emits the text up to the patterns as words and the
+// patterns as other tokens, like the template lexer of SkipTo. Inputs
+// are pushed in place of @, like lexInclude.
+func lexTemplate(includes map[string]string, patterns ...string) lex.StateFn {
+	var sf lex.StateFn
+	sf = func(l *lex.Lexer) lex.StateFn {
+		i, ok := l.SkipTo(append(patterns, "@")...)
+		l.EmitNonEmpty(typeWord)
+		if !ok {
+			return nil
+		}
+		if i == len(patterns) {
+			l.Next()
+			l.Next()
+			name := l.Value()[1:]
+			l.Ignore()
+			l.PushInput(name, includes[name])
+			return sf
+		}
+		l.Consume(patterns[i])
+		l.Emit(typeOther)
+		return sf
+	}
+	return sf
+}
+
+func TestSkipTo(t *testing.T) {
+	tests := []struct {
+		input    string
+		patterns []string
+		want     string
+	}{
+		{"ab{{cd}}e", []string{"{{", "}}"}, `2:"ab" 4:"{{" 2:"cd" 4:"}}" 2:"e" 1:""`},
+		{"a{b{{c", []string{"{{"}, `2:"a{b" 4:"{{" 2:"c" 1:""`},
+		{"a<%=b", []string{"<%", "<%="}, `2:"a" 4:"<%" 2:"=b" 1:""`},
+		{"a<%=b", []string{"<%=", "<%"}, `2:"a" 4:"<%=" 2:"b" 1:""`},
+		{"{{", []string{"{{"}, `4:"{{" 1:""`},
+		{"abc", []string{"{{", "${"}, `2:"abc" 1:""`},
+		{"ab{", []string{"{{"}, `2:"ab{" 1:""`},
+		{"", []string{"{{"}, `1:""`},
+	}
+	for _, tt := range tests {
+		l := lex.Lex("f", tt.input, lexTemplate(nil, tt.patterns...))
+		if got := describeTypes(lex.Collect(l)); got != tt.want {
+			t.Errorf("%q %q: got %s, want %s", tt.input, tt.patterns, got, tt.want)
+		}
+	}
+}
+
+func TestSkipToEmptyPattern(t *testing.T) {
+	l := lex.New("f", "ab")
+	if i, ok := l.SkipTo("a", ""); i != 1 || !ok || l.Pos() != 0 {
+		t.Errorf("SkipTo = %d, %t at %d, want 1, true at 0", i, ok, l.Pos())
+	}
+}
+
+func TestSkipToStream(t *testing.T) {
+	input := strings.Repeat("x", 5000) + "{{y}"
+	l := lex.NewStream("f", iotest.OneByteReader(strings.NewReader(input)))
+	go l.Run(lexTemplate(nil, "{{"))
+	want := `2:"` + strings.Repeat("x", 5000) + `" 4:"{{" 2:"y}" 1:""`
+	if got := describeTypes(lex.Collect(l)); got != want {
+		t.Errorf("got %.40s..., want the pattern found in a later chunk", got)
+	}
+}
+
+func TestSkipToPushInput(t *testing.T) {
+	// The pushed input ends with a pattern, so SkipTo continues in the
+	// input it was pushed in.
+	l := lex.Lex("f", "a@xb{{c", lexTemplate(map[string]string{"x": "y{{"}, "{{"))
+	want := `2:"a" 2:"y" 4:"{{" 2:"b" 4:"{{" 2:"c" 1:""`
+	if got := describeTypes(lex.Collect(l)); got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
+
+func TestSkipToIncremental(t *testing.T) {
+	l := lex.New("f", "a", lex.WithIncremental())
+	l.Append("b{{c")
+	l.CloseInput()
+	go l.Run(lexTemplate(nil, "{{"))
+	if got, want := describeTypes(lex.Collect(l)), `2:"ab" 4:"{{" 2:"c" 1:""`; got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
+
+func TestSkipToAutoSkip(t *testing.T) {
+	l := lex.New("f", "  ab{{")
+	l.SetAutoSkip(" ")
+	if i, ok := l.SkipTo("{{"); i != 0 || !ok || l.Value() != "ab" {
+		t.Errorf("SkipTo = %d, %t with %q, want 0, true with ab", i, ok, l.Value())
+	}
+}
@@ -0,0 +1,70 @@
+// Copyright (c) 2015, Ben Morgan. All rights reserved.
+// Use of this source code is governed by an MIT license
+// that can be found in the LICENSE file.
+
+package lex
+
+import (
+	"fmt"
+	"unicode/utf8"
+)
+
+// snapshotContext is the number of bytes of input before the current
+// position that a StateSnapshot holds.
+const snapshotContext = 40
+
+// A StateSnapshot describes where a lexer is, for error reports.
+type StateSnapshot struct {
+	Pos     int    // current offset in the input
+	File    string // file of the current position, see SetPosition
+	Line    int    // line of the current position, starting at 1
+	Column  int    // column of the current position, starting at 1
+	Context string // up to 40 bytes of input before the current position
+	Start   int    // offset where the pending token starts
+	Pending string // input of the pending token
+	State   string // name of the running state function, see Lexer.State
+}
+
+// String formats s on a single line:
+//
+//	input:3:14: in state main.lexString at offset 52, pending "\"abc", after "x := \"abc"
+func (s StateSnapshot) String() string {
+	state := s.State
+	if state == "" {
+		state = "none"
+	}
+	return fmt.Sprintf("%s:%d:%d: in state %s at offset %d, pending %q, after %q",
+		s.File, s.Line, s.Column, state, s.Pos, s.Pending, s.Context)
+}
+
+// DebugState returns a snapshot of where the lexer is, to be included in
+// error reports:
+//
+//	default:
+//	    return l.Errorf("unexpected %q (%v)", r, l.DebugState())
+//
+// When a state function panics, the TypeError token reporting it carries
+// the snapshot from before the panic was recovered as its Data.
+// DebugState must be called from the lexing goroutine.
+func (l *Lexer) DebugState() StateSnapshot {
+	// The positions are clamped, as they may be invalid when a state
+	// function panicked.
+	pos := min(max(l.pos, 0), len(l.input))
+	base := min(max(l.base, 0), pos)
+	s := StateSnapshot{
+		Pos:     l.pos,
+		Start:   l.base,
+		Pending: l.input[base:pos],
+		State:   l.State(),
+	}
+	from := max(0, pos-snapshotContext)
+	for from < pos && !utf8.RuneStart(l.input[from]) {
+		from++
+	}
+	s.Context = l.input[from:pos]
+	if l.pb != nil {
+		s.File = l.pb.file
+		s.Line, s.Column = l.lineCol(l.pb, pos)
+	}
+	return s
+}
@@ -0,0 +1,53 @@
+// Copyright (c) 2015, Ben Morgan. All rights reserved.
+// Use of this source code is governed by an MIT license
+// that can be found in the LICENSE file.
+
+package lex_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/goulash/lex"
+)
+
+func TestPanicBeyondInput(t *testing.T) {
+	tests := []struct {
+		name string
+		sf   lex.StateFn
+		opts []lex.Option
+	}{
+		{"validate", func(l *lex.Lexer) lex.StateFn { l.Inc(10); return nil }, []lex.Option{lex.Validate()}},
+		{"panic", func(l *lex.Lexer) lex.StateFn { l.Inc(10); panic("boom") }, nil},
+	}
+	for _, tt := range tests {
+		l := lex.Lex("f", "abc", tt.sf, tt.opts...)
+		toks := lex.Collect(l)
+		last := toks[len(toks)-1]
+		if last.Type != lex.TypeError || !strings.Contains(last.Value, "panic") {
+			t.Errorf("%s: last token is %v, want panic error", tt.name, last)
+			continue
+		}
+		s, ok := last.Data.(lex.StateSnapshot)
+		if !ok || s.Pos != 10 || s.Context != "abc" {
+			t.Errorf("%s: snapshot is %+v", tt.name, last.Data)
+		}
+	}
+}
+
+func TestDebugState(t *testing.T) {
+	var s lex.StateSnapshot
+	l := lex.Lex("f", "ab\ncd ef", func(l *lex.Lexer) lex.StateFn {
+		l.Inc(4)
+		l.Ignore()
+		l.Inc(2)
+		s = l.DebugState()
+		return nil
+	})
+	lex.Collect(l)
+	s.State = ""
+	want := lex.StateSnapshot{Pos: 6, File: "f", Line: 2, Column: 4, Context: "ab\ncd ", Start: 4, Pending: "d "}
+	if s != want {
+		t.Errorf("got %+v, want %+v", s, want)
+	}
+}
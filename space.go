@@ -0,0 +1,61 @@
+// Copyright (c) 2015, Ben Morgan. All rights reserved.
+// Use of this source code is governed by an MIT license
+// that can be found in the LICENSE file.
+
+package lex
+
+import "strings"
+
+// SkipSpaces skips over spaces and tabs, and returns the number of bytes
+// skipped. If no input was pending, the skipped input is ignored.
+func (l *Lexer) SkipSpaces() int {
+	return l.skipRun(Space)
+}
+
+// SkipSpacesAndNewlines skips over spaces, tabs, and line endings, and
+// returns the number of bytes skipped. If no input was pending, the
+// skipped input is ignored.
+func (l *Lexer) SkipSpacesAndNewlines() int {
+	return l.skipRun(Space + Endline)
+}
+
+// SetAutoSkip sets the runes that are insignificant whitespace. While set,
+// Consume and the Accept functions first skip over such runes when called
+// at the start of a token, so that state functions need not skip them
+// explicitly. It can be changed in every state function, for example:
+//
+//	func lexExpr(l *lex.Lexer) lex.StateFn {
+//	    l.SetAutoSkip(lex.Space)
+//	    ...
+//	}
+//
+//	func lexString(l *lex.Lexer) lex.StateFn {
+//	    l.SetAutoSkip("") // whitespace is significant in strings
+//	    ...
+//	}
+func (l *Lexer) SetAutoSkip(set string) {
+	l.skipSet = set
+}
+
+// autoSkip skips the runes set by SetAutoSkip at the start of a token.
+func (l *Lexer) autoSkip() {
+	if l.skipSet != "" && l.pos == l.base {
+		l.skipRun(l.skipSet)
+	}
+}
+
+// skipRun skips a run of runes from set, ignoring them if no input
+// was pending. The bytes are counted as they are read, since reading
+// may resume a suspended input, see PushInput.
+func (l *Lexer) skipRun(set string) int {
+	pending := l.pos > l.base
+	var n int
+	for r := l.Next(); r >= 0 && strings.ContainsRune(set, r); r = l.Next() {
+		n += l.width
+	}
+	l.Backup()
+	if !pending {
+		l.base = l.pos
+	}
+	return n
+}
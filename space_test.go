@@ -0,0 +1,74 @@
+// Copyright (c) 2015, Ben Morgan. All rights reserved.
+// Use of this source code is governed by an MIT license
+// that can be found in the LICENSE file.
+
+package lex_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/goulash/lex"
+)
+
+func TestSkipSpaces(t *testing.T) {
+	var got []string
+	toks := lex.Collect(lex.Lex("a", " \tab \r\n cd  ", func(l *lex.Lexer) lex.StateFn {
+		got = append(got, fmt.Sprint(l.SkipSpaces()))
+		l.AcceptRun("ab")
+		got = append(got, fmt.Sprint(l.SkipSpaces())) // pending, so kept
+		l.Emit(typeWord)
+		got = append(got, fmt.Sprint(l.SkipSpaces(), l.SkipSpacesAndNewlines()))
+		l.AcceptRun("cd")
+		l.Emit(typeWord)
+		got = append(got, fmt.Sprint(l.SkipSpacesAndNewlines(), l.SkipSpaces()))
+		return nil
+	}))
+	if s := strings.Join(got, " "); s != "2 1 0 3 2 0" {
+		t.Errorf("got skipped %s", s)
+	}
+	if s := strings.Join(values(toks), "|"); s != "ab |cd|" {
+		t.Errorf("got %q", s)
+	}
+}
+
+func TestSkipSpacesPushed(t *testing.T) {
+	var n int
+	toks := lex.Collect(lex.Lex("a", "x  yz", func(l *lex.Lexer) lex.StateFn {
+		l.Next()
+		l.Ignore()
+		l.PushInput("b", "abcd")
+		l.AcceptRun("abcd")
+		l.Emit(typeWord)
+		n = l.SkipSpaces()
+		l.AcceptRun("yz")
+		l.Emit(typeWord)
+		return nil
+	}))
+	if n != 2 {
+		t.Errorf("skipped %d bytes, want the 2 spaces after the pushed input", n)
+	}
+	if s := strings.Join(values(toks), "|"); s != "abcd|yz|" {
+		t.Errorf("got %q", s)
+	}
+}
+
+func TestSetAutoSkip(t *testing.T) {
+	toks := lex.Collect(lex.Lex("a", "  ab  \"c d\" ", func(l *lex.Lexer) lex.StateFn {
+		l.SetAutoSkip(lex.Space)
+		l.AcceptRun("ab")
+		l.Emit(typeWord)
+		l.Accept(`"`)
+		l.SetAutoSkip("")
+		l.AcceptButRun(`"`)
+		l.Accept(`"`)
+		l.Emit(typeOther)
+		l.SetAutoSkip(lex.Space)
+		l.Consume("x")
+		return nil
+	}))
+	if s := strings.Join(values(toks), "|"); s != `ab|"c d"|` {
+		t.Errorf("got %q", s)
+	}
+}
@@ -0,0 +1,95 @@
+// Copyright (c) 2015, Ben Morgan. All rights reserved.
+// Use of this source code is governed by an MIT license
+// that can be found in the LICENSE file.
+
+package lex
+
+import "strings"
+
+// FieldOptions configures how SplitFields splits a string.
+// The zero value splits at whitespace and honors single and double
+// quotes, but no escapes.
+type FieldOptions struct {
+	Separators string // runes separating fields, white space if empty
+	Quotes     string // runes quoting parts of fields, ' and " if empty
+	Escape     rune   // rune escaping the rune following it, none if 0
+}
+
+// ShellFields splits fields like a POSIX shell without expansions.
+var ShellFields = FieldOptions{Escape: '\\'}
+
+// typeField is the type of the fields emitted by lexFields.
+const typeField = 1 + TypeEOF
+
+// SplitFields splits s into fields like a shell splits a command line
+// into words: fields are separated by runs of separators, and quotes
+// and escapes make separators part of a field. Quotes and escapes are
+// removed from the fields:
+//
+//	lex.SplitFields(`cp "my file" it\'s`, lex.ShellFields) // cp, my file, it's
+//
+// Inside single quotes, the escape rune has no special meaning, and
+// inside other quotes, it only escapes the quote and itself. Invalid
+// UTF-8 is kept as it is. A quote that is not closed extends to the end of s.
+func SplitFields(s string, opts FieldOptions) []string {
+	if opts.Separators == "" {
+		opts.Separators = " \t\r\n"
+	}
+	if opts.Quotes == "" {
+		opts.Quotes = `"'`
+	}
+	var fields []string
+	New("fields", s).RunSink(opts.lexFields, func(t Token) bool {
+		if t.Type == typeField {
+			fields = append(fields, t.Value)
+		}
+		return true
+	})
+	return fields
+}
+
+// lexFields is the state function for splitting fields.
+func (o FieldOptions) lexFields(l *Lexer) StateFn {
+	for {
+		l.AcceptRun(o.Separators)
+		l.Ignore()
+		if l.Peek() < 0 {
+			return nil
+		}
+		var b strings.Builder
+	field:
+		for {
+			switch r := l.Next(); {
+			case r < 0:
+				break field
+			case strings.ContainsRune(o.Separators, r):
+				l.Backup()
+				break field
+			case r == o.Escape && o.Escape != 0:
+				if l.Next() < 0 {
+					b.WriteRune(r)
+					break
+				}
+				b.WriteString(last(l))
+			case strings.ContainsRune(o.Quotes, r):
+				for q := l.Next(); q != r && q >= 0; q = l.Next() {
+					if q == o.Escape && o.Escape != 0 && r != '\'' {
+						if n := l.Peek(); n == r || n == q {
+							l.Next()
+						}
+					}
+					b.WriteString(last(l))
+				}
+			default:
+				b.WriteString(last(l))
+			}
+		}
+		l.EmitMapped(typeField, func(string) string { return b.String() })
+	}
+}
+
+// last returns the input of the rune last read by l, so that invalid
+// UTF-8 is kept as it is.
+func last(l *Lexer) string {
+	return l.input[l.pos-l.width : l.pos]
+}
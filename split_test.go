@@ -0,0 +1,37 @@
+// Copyright (c) 2015, Ben Morgan. All rights reserved.
+// Use of this source code is governed by an MIT license
+// that can be found in the LICENSE file.
+
+package lex_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/goulash/lex"
+)
+
+func TestSplitFields(t *testing.T) {
+	tests := []struct {
+		s    string
+		opts lex.FieldOptions
+		want []string
+	}{
+		{`cp "my file" it\'s`, lex.ShellFields, []string{"cp", "my file", "it's"}},
+		{" \ta  b\n", lex.FieldOptions{}, []string{"a", "b"}},
+		{"", lex.FieldOptions{}, nil},
+		{`"" x''y`, lex.FieldOptions{}, []string{"", "xy"}},
+		{`a\ b`, lex.FieldOptions{}, []string{`a\`, "b"}},
+		{`a\ b c\`, lex.ShellFields, []string{"a b", `c\`}},
+		{`"a\"b\\c\d" 'e\f'`, lex.ShellFields, []string{`a"b\c\d`, `e\f`}},
+		{`"open quote`, lex.ShellFields, []string{"open quote"}},
+		{"a,b,,c", lex.FieldOptions{Separators: ","}, []string{"a", "b", "c"}},
+		{"|a b| c", lex.FieldOptions{Quotes: "|"}, []string{"a b", "c"}},
+		{"\xff \"\xfe\"", lex.FieldOptions{}, []string{"\xff", "\xfe"}},
+	}
+	for _, tt := range tests {
+		if got := lex.SplitFields(tt.s, tt.opts); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("SplitFields(%q) = %q, want %q", tt.s, got, tt.want)
+		}
+	}
+}
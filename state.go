@@ -0,0 +1,98 @@
+// Copyright (c) 2015, Ben Morgan. All rights reserved.
+// Use of this source code is governed by an MIT license
+// that can be found in the LICENSE file.
+
+package lex
+
+import (
+	"reflect"
+	"runtime"
+	"sync"
+)
+
+// stateNames holds the names given by Named, by function entry point.
+var stateNames struct {
+	sync.RWMutex
+	m map[uintptr]string
+}
+
+// Named gives the state function fn a name, which is used instead of the
+// name of the Go function in traces and error messages, and returns fn.
+// It can be called once up front or where the state is returned:
+//
+//	return lex.Named("string", lexString)
+//
+// Names belong to Go functions, so all closures created by the same
+// function share a name.
+func Named(name string, fn StateFn) StateFn {
+	pc := reflect.ValueOf(fn).Pointer()
+	stateNames.RLock()
+	old, ok := stateNames.m[pc]
+	stateNames.RUnlock()
+	if ok && old == name {
+		return fn
+	}
+	stateNames.Lock()
+	if stateNames.m == nil {
+		stateNames.m = make(map[uintptr]string)
+	}
+	stateNames.m[pc] = name
+	stateNames.Unlock()
+	return fn
+}
+
+// StateName returns the name of fn given by Named, or else the name of
+// the Go function, such as "main.lexString".
+func StateName(fn StateFn) string {
+	if fn == nil {
+		return "nil"
+	}
+	pc := reflect.ValueOf(fn).Pointer()
+	stateNames.RLock()
+	name, ok := stateNames.m[pc]
+	stateNames.RUnlock()
+	if ok {
+		return name
+	}
+	if f := runtime.FuncForPC(pc); f != nil {
+		return f.Name()
+	}
+	return "?"
+}
+
+// State returns the name of the state function that is running, see
+// StateName, or the empty string if Run is not running a state function.
+func (l *Lexer) State() string {
+	if l.state == nil {
+		return ""
+	}
+	return StateName(l.state)
+}
+
+// PushState pushes fn onto the mode stack of the lexer, to be returned
+// by a later call to PopState. This lets a state function enter a mode,
+// such as an interpolation inside a string, without knowing which state
+// to continue with when the mode ends:
+//
+//	case l.Consume("${"):
+//	    l.Emit(TypeInterpStart)
+//	    l.PushState(lexString)
+//	    return lexExpr // which returns l.PopState() at the closing }
+func (l *Lexer) PushState(fn StateFn) {
+	l.modes = append(l.modes, fn)
+}
+
+// PopState removes the state function on top of the mode stack and
+// returns it. If the stack is empty, nil is returned, which ends lexing.
+func (l *Lexer) PopState() StateFn {
+	n := len(l.modes)
+	if n == 0 {
+		return nil
+	}
+	fn := l.modes[n-1]
+	l.modes = l.modes[:n-1]
+	return fn
+}
+
+// StateDepth returns the number of state functions on the mode stack.
+func (l *Lexer) StateDepth() int { return len(l.modes) }
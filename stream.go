@@ -0,0 +1,98 @@
+// Copyright (c) 2015, Ben Morgan. All rights reserved.
+// Use of this source code is governed by an MIT license
+// that can be found in the LICENSE file.
+
+package lex
+
+import "io"
+
+// minChunkSize is the minimum number of bytes read from a stream at once.
+const minChunkSize = 4096
+
+// NewStream creates a new Lexer that reads its input from r as needed,
+// instead of requiring all input up front.
+//
+// Next, Peek, HasPrefix, and Consume transparently read more input when
+// the input read so far ends within a rune or prefix, so that chunk
+// boundaries are never visible to state functions. Methods that only
+// look at the input read so far, such as Input, Remaining, and
+// RestOfLine, do not read more input.
+//
+// The input is normalized as configured by options such as
+// WithNormalizeNewlines, even where a CR LF pair or a line continuation
+// is split between reads.
+//
+// If reading fails with an error other than io.EOF, the lexer emits
+// an error and stops.
+func NewStream(name string, r io.Reader, opts ...Option) *Lexer {
+	l := New(name, "", opts...)
+	l.stream = r
+	return l
+}
+
+// fill reads more input from the stream and reports whether any was read.
+func (l *Lexer) fill() bool {
+	if l.stream == nil || len(l.stack) > 0 || l.halted {
+		return false
+	}
+	size := max(minChunkSize, len(l.input))
+	buf := make([]byte, size)
+	start := len(l.input)
+	for {
+		n, err := l.stream.Read(buf)
+		if n > 0 {
+			l.extendInput(string(buf[:n]))
+		}
+		if err != nil {
+			l.stream = nil
+			l.endInput()
+			if err != io.EOF {
+				l.halt("read error: %v", err)
+			}
+			return len(l.input) > start
+		}
+		if len(l.input) > start {
+			return true
+		}
+	}
+}
+
+// extendInput appends more to the main input, normalized like the input
+// passed to New. The end of the input that may be normalized differently
+// depending on the input that follows, such as a CR that may be followed
+// by LF, is held back until more input is appended or endInput is called.
+func (l *Lexer) extendInput(more string) {
+	more = l.tail + more
+	n := len(more) - l.unfinished(more)
+	l.tail = more[n:]
+	l.appendInput(more[:n])
+}
+
+// endInput appends the input held back by extendInput, once the input
+// has ended.
+func (l *Lexer) endInput() {
+	tail := l.tail
+	l.tail = ""
+	l.appendInput(tail)
+}
+
+// appendInput normalizes s and appends it to the main input.
+func (l *Lexer) appendInput(s string) {
+	if s == "" {
+		return
+	}
+	removed := 0
+	if n := len(l.shifts); n > 0 {
+		removed = l.shifts[n-1].delta
+	}
+	var joins []int
+	s, l.shifts, joins = l.normalizeAt(s, len(l.input), removed, l.shifts)
+	l.input += s
+	pb := l.pb
+	if len(joins) > 0 {
+		joins = append(pb.joins[:len(pb.joins):len(pb.joins)], joins...)
+	} else {
+		joins = pb.joins
+	}
+	l.pb = &posBase{input: l.input, pos: pb.pos, file: pb.file, line: pb.line, joins: joins}
+}
@@ -0,0 +1,75 @@
+// Copyright (c) 2015, Ben Morgan. All rights reserved.
+// Use of this source code is governed by an MIT license
+// that can be found in the LICENSE file.
+
+package lex_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/goulash/lex"
+)
+
+// describe returns the tokens of l with their positions and original
+// offsets, and the line offsets of l. The offsets are looked up once
+// all tokens are read, as the input of l may still grow until then.
+func describe(l *lex.Lexer) string {
+	var b strings.Builder
+	r := lex.NewReader(l)
+	var toks []lex.Token
+	for {
+		t := r.Next()
+		toks = append(toks, t)
+		if t.Type == lex.TypeEOF || t.Type == lex.TypeError {
+			break
+		}
+	}
+	for _, t := range toks {
+		_, line, col := r.PosOf(t)
+		fmt.Fprintf(&b, "%q@%d:%d/%d ", t.Value, line, col, l.OriginalPos(t.Pos))
+	}
+	fmt.Fprint(&b, l.LineOffsets())
+	return b.String()
+}
+
+var normalizeOpts = []lex.Option{
+	lex.WithNormalizeNewlines(),
+	lex.WithStripBOM(),
+	lex.WithLineContinuation("\\\\"),
+}
+
+var normalizeInputs = []string{
+	"\ufeffab\r\ncd\\\\\r\nef\rgh \\\\\n\\\\\r\nij\r",
+	"ab\\\\ \\\\",
+	"\ufeff",
+	"\xef\xbbx",
+}
+
+func TestStreamNormalize(t *testing.T) {
+	for _, in := range normalizeInputs {
+		want := describe(lex.Lex("f", in, lexWords, normalizeOpts...))
+		l := lex.NewStream("f", iotest.OneByteReader(strings.NewReader(in)), normalizeOpts...)
+		go l.Run(lexWords)
+		if got := describe(l); got != want {
+			t.Errorf("%q:\ngot  %s\nwant %s", in, got, want)
+		}
+	}
+}
+
+func TestIncrementalNormalize(t *testing.T) {
+	for _, in := range normalizeInputs {
+		want := describe(lex.Lex("f", in, lexWords, normalizeOpts...))
+		l := lex.New("f", "", append(normalizeOpts, lex.WithIncremental())...)
+		for i := range len(in) {
+			l.Append(in[i : i+1])
+		}
+		l.CloseInput()
+		go l.Run(lexWords)
+		if got := describe(l); got != want {
+			t.Errorf("%q:\ngot  %s\nwant %s", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,41 @@
+// Copyright (c) 2015, Ben Morgan. All rights reserved.
+// Use of this source code is governed by an MIT license
+// that can be found in the LICENSE file.
+
+package lex
+
+// WithStrict makes Run emit a TypeError token instead of TypeEOF if the
+// state functions finish without emitting or ignoring all of the input,
+// which catches the common bug of a state function that consumes the
+// last token of the input and returns nil without emitting it:
+//
+//	func lexIdent(l *lex.Lexer) lex.StateFn {
+//	    l.AcceptFuncRun(unicode.IsLetter)
+//	    if l.Peek() == lex.EOF {
+//	        return nil // the identifier is lost
+//	    }
+//	    ...
+//	}
+//
+// The same error replaces a TypeEOF token emitted by the state functions
+// while input is pending or left, such as when lexIdent above emits
+// TypeEOF instead of returning nil, which would fold the identifier into
+// the TypeEOF token. The error is positioned at the input that was
+// dropped. It is not emitted after a TypeError token.
+func WithStrict() Option {
+	return func(l *Lexer) { l.strict = true }
+}
+
+// checkDropped emits an error if the input from the start of the pending
+// token onwards was neither emitted nor ignored.
+func (l *Lexer) checkDropped() {
+	n := len(l.input)
+	if l.base >= n {
+		return
+	}
+	msg := "input was neither emitted nor ignored"
+	if l.base < l.pos {
+		msg = "pending input was neither emitted nor ignored"
+	}
+	l.emit(Token{Type: TypeError, Pos: l.base, End: n, Value: msg, pb: l.pb})
+}
@@ -0,0 +1,52 @@
+// Copyright (c) 2015, Ben Morgan. All rights reserved.
+// Use of this source code is governed by an MIT license
+// that can be found in the LICENSE file.
+
+package lex_test
+
+import (
+	"testing"
+	"unicode"
+
+	"github.com/goulash/lex"
+)
+
+func TestStrict(t *testing.T) {
+	tests := []struct {
+		name string
+		sf   lex.StateFn
+		want lex.Type
+	}{
+		{"dropped", func(l *lex.Lexer) lex.StateFn {
+			l.AcceptFuncRun(unicode.IsLetter)
+			return nil
+		}, lex.TypeError},
+		{"emit EOF with pending input", func(l *lex.Lexer) lex.StateFn {
+			l.AcceptFuncRun(unicode.IsLetter)
+			if l.Peek() == lex.EOF {
+				l.Emit(lex.TypeEOF)
+			}
+			return nil
+		}, lex.TypeError},
+		{"emit EOF before the end", func(l *lex.Lexer) lex.StateFn {
+			l.Emit(lex.TypeEOF)
+			return nil
+		}, lex.TypeError},
+		{"emit EOF at the end", func(l *lex.Lexer) lex.StateFn {
+			l.AcceptFuncRun(unicode.IsLetter)
+			l.Emit(typeWord)
+			l.Emit(lex.TypeEOF)
+			return nil
+		}, lex.TypeEOF},
+	}
+	for _, tt := range tests {
+		toks := lex.Collect(lex.Lex("f", "ident", tt.sf, lex.WithStrict()))
+		last := toks[len(toks)-1]
+		if last.Type != tt.want {
+			t.Errorf("%s: got %v, want type %v", tt.name, last, tt.want)
+		}
+		if tt.want == lex.TypeError && (last.Pos != 0 || last.End != 5) {
+			t.Errorf("%s: error spans %d-%d, want 0-5", tt.name, last.Pos, last.End)
+		}
+	}
+}
@@ -0,0 +1,62 @@
+// Copyright (c) 2015, Ben Morgan. All rights reserved.
+// Use of this source code is governed by an MIT license
+// that can be found in the LICENSE file.
+
+package lex
+
+// SubLex lexes the next length bytes of the input with the state function
+// sf, such as for a script block embedded in HTML or SQL embedded in a
+// string. The sub-lexer has the given name, and sees the region as the
+// end of its input. Its tokens are merged into the token stream of l,
+// with positions relative to the input of l; a TypeEOF token emitted by
+// the sub-lexer is dropped.
+//
+// When SubLex returns, the region has been consumed. If the sub-lexer
+// emitted an error or was stopped, such as by the sink of RunSink, false
+// is returned and l should stop lexing:
+//
+//	if !l.SubLex("script", n, lexJS) {
+//	    return nil
+//	}
+//
+// If the region extends beyond the input, an error is emitted and false
+// is returned. SubLex should be called when no input is pending.
+func (l *Lexer) SubLex(name string, length int, sf StateFn) bool {
+	end := l.pos + length
+	if length < 0 || end > len(l.input) {
+		l.Errorf("region of %s (%d bytes) exceeds the input", name, length)
+		return false
+	}
+	l.flushCoalesced()
+	sub := *l
+	sub.name = name
+	sub.input = l.input[:end]
+	sub.base = l.pos
+	sub.stack = nil
+	sub.modes = nil
+	sub.stream = nil
+	sub.ended = false
+	sub.nested = true
+	sub.runStates(sf)
+	sub.flushCoalesced()
+	if l.err == nil {
+		l.err = sub.err
+	}
+	l.halted, l.slow, l.ticks = sub.halted, sub.slow, sub.ticks
+	l.skipSet, l.origin = sub.skipSet, sub.origin
+	l.lines, l.scanned = sub.lines, sub.scanned
+	l.wsEnd = sub.wsEnd
+	if sub.pb != l.pb {
+		// The sub-lexer set the position, see SetPosition, relative to
+		// its region of the input.
+		pb := *sub.pb
+		pb.input = l.input
+		l.pb = &pb
+	}
+	if sub.ended || sub.halted {
+		l.ended = l.ended || sub.ended
+		return false
+	}
+	l.base, l.pos, l.width = end, end, 0
+	return true
+}
@@ -0,0 +1,109 @@
+// Copyright (c) 2015, Ben Morgan. All rights reserved.
+// Use of this source code is governed by an MIT license
+// that can be found in the LICENSE file.
+
+package lex_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/goulash/lex"
+)
+
+func TestSubLex(t *testing.T) {
+	l := lex.Lex("f", "ab<cd ef>gh", func(l *lex.Lexer) lex.StateFn {
+		l.Inc(2)
+		l.Emit(typeWord)
+		l.Inc(1)
+		l.Ignore()
+		if !l.SubLex("inner", 5, lexWords) {
+			return nil
+		}
+		l.Inc(1)
+		l.Ignore()
+		l.Inc(2)
+		l.Emit(typeWord)
+		return nil
+	})
+	toks := lex.Collect(l)
+	if got := strings.Join(values(toks), "|"); got != "ab|cd| |ef|gh|" {
+		t.Errorf("got %q", got)
+	}
+	if toks[2].Pos != 5 {
+		t.Errorf("sub-lexer token at %d, want 5", toks[2].Pos)
+	}
+}
+
+func TestSubLexError(t *testing.T) {
+	_, err := lex.LexAndWait(context.Background(), "f", "ab cd", func(l *lex.Lexer) lex.StateFn {
+		l.Inc(2)
+		l.Emit(typeWord)
+		l.SubLex("inner", 3, func(l *lex.Lexer) lex.StateFn {
+			return l.Errorf("bad")
+		})
+		return nil
+	})
+	if err == nil || !strings.HasSuffix(err.Error(), "bad") {
+		t.Errorf("got error %v, want bad", err)
+	}
+}
+
+func TestSubLexBeyondInput(t *testing.T) {
+	var ok bool
+	l := lex.Lex("f", "abc", func(l *lex.Lexer) lex.StateFn {
+		l.Inc(1)
+		l.Ignore()
+		ok = l.SubLex("inner", 10, lexWords)
+		return nil
+	})
+	lex.Collect(l)
+	if ok || l.Err() == nil {
+		t.Errorf("got %v and error %v, want false and an error", ok, l.Err())
+	}
+}
+
+func TestSubLexSinkStop(t *testing.T) {
+	var got []string
+	ok := true
+	l := lex.New("f", "ab<cd ef gh>ij")
+	l.RunSink(func(l *lex.Lexer) lex.StateFn {
+		l.Inc(2)
+		l.Emit(typeWord)
+		l.Inc(1)
+		l.Ignore()
+		ok = l.SubLex("inner", 8, lexWords)
+		l.Inc(1)
+		l.Emit(typeOther)
+		return nil
+	}, func(t lex.Token) bool {
+		got = append(got, t.Value)
+		return len(got) < 2
+	})
+	if s := strings.Join(got, "|"); ok || s != "ab|cd" {
+		t.Errorf("got %q and %v, want ab|cd and false", s, ok)
+	}
+}
+
+func TestSubLexState(t *testing.T) {
+	// SetAutoSkip and SetPosition in the sub-lexer carry over to l.
+	l := lex.Lex("f", "<ab>  cd\nef", func(l *lex.Lexer) lex.StateFn {
+		l.Inc(1)
+		l.Ignore()
+		l.SubLex("inner", 2, func(l *lex.Lexer) lex.StateFn {
+			l.SetAutoSkip(" \n")
+			l.SetPosition("g", 10)
+			return lexWords
+		})
+		l.Inc(1)
+		l.Ignore()
+		for l.Consume("cd") || l.Consume("ef") {
+			l.Emit(typeWord)
+		}
+		return nil
+	})
+	if got, want := strings.Join(positions(l), " "), "g:10:1 g:10:6 g:11:1 g:11:3"; got != want {
+		t.Errorf("got  %s\nwant %s", got, want)
+	}
+}
@@ -0,0 +1,66 @@
+// Copyright (c) 2015, Ben Morgan. All rights reserved.
+// Use of this source code is governed by an MIT license
+// that can be found in the LICENSE file.
+
+package lex
+
+import "sync"
+
+// SyncReader is a Reader that is safe for use from multiple goroutines.
+type SyncReader struct {
+	mu sync.Mutex
+	r  *Reader
+}
+
+// NewSyncReader returns a new SyncReader reading tokens from src.
+func NewSyncReader(src TokenSource) *SyncReader {
+	return &SyncReader{r: NewReader(src)}
+}
+
+func (s *SyncReader) Peek() Token {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.r.Peek()
+}
+
+func (s *SyncReader) Next() Token {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.r.Next()
+}
+
+func (s *SyncReader) Backup(t Token) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.r.Backup(t)
+}
+
+// Expect is like Reader.Expect, and reads all tokens without
+// interruption from other goroutines.
+func (s *SyncReader) Expect(types ...Type) ([]Token, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.r.Expect(types...)
+}
+
+func (s *SyncReader) PosInfo() (name string, line, col int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.r.PosInfo()
+}
+
+func (s *SyncReader) PosOf(t Token) (name string, line, col int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.r.PosOf(t)
+}
+
+// Do calls fn with exclusive access to the underlying Reader.
+// This lets a goroutine consume a whole sequence of tokens, such as
+// a top-level declaration, without interruption from other goroutines.
+// The Reader must not be retained after fn returns.
+func (s *SyncReader) Do(fn func(r *Reader)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	fn(s.r)
+}
@@ -0,0 +1,65 @@
+// Copyright (c) 2015, Ben Morgan. All rights reserved.
+// Use of this source code is governed by an MIT license
+// that can be found in the LICENSE file.
+
+package lex_test
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/goulash/lex"
+)
+
+func TestSyncReader(t *testing.T) {
+	input := strings.Repeat("ab cd ", 500)
+	s := lex.NewSyncReader(lex.Lex("f", input, lexWords))
+	var mu sync.Mutex
+	var pos []int
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for {
+				var toks []lex.Token
+				if i%2 == 0 {
+					toks = append(toks, s.Next())
+				} else {
+					// Read a word and the space after it without interruption.
+					s.Do(func(r *lex.Reader) {
+						toks = append(toks, r.Next())
+						if toks[0].Type == typeWord {
+							toks = append(toks, r.Next())
+						}
+					})
+					if len(toks) == 2 && toks[1].Pos != toks[0].End {
+						t.Errorf("Do read %v and %v", toks[0], toks[1])
+					}
+				}
+				mu.Lock()
+				for _, tok := range toks {
+					if tok.Type != lex.TypeError {
+						pos = append(pos, tok.Pos)
+					}
+				}
+				mu.Unlock()
+				if last := toks[len(toks)-1]; last.Type == lex.TypeEOF || last.Type == lex.TypeError {
+					return
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+	sort.Ints(pos)
+	if len(pos) != 2001 {
+		t.Fatalf("read %d tokens, want 2001", len(pos))
+	}
+	for i := 1; i < len(pos); i++ {
+		if pos[i] == pos[i-1] {
+			t.Fatalf("token at %d read twice", pos[i])
+		}
+	}
+}
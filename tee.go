@@ -0,0 +1,76 @@
+// Copyright (c) 2015, Ben Morgan. All rights reserved.
+// Use of this source code is governed by an MIT license
+// that can be found in the LICENSE file.
+
+package lex
+
+import "sync"
+
+// Tee returns n Readers that each read all tokens of l independently.
+// Tokens are buffered until all Readers have read them, so the Readers
+// can be used concurrently from different goroutines, such as by a
+// syntax highlighter and a parser, or one after the other.
+//
+// Each Reader by itself is not safe for concurrent use.
+func Tee(l *Lexer, n int) []*Reader {
+	t := &tee{lex: l, pos: make([]int, n)}
+	rs := make([]*Reader, n)
+	for i := range rs {
+		rs[i] = &Reader{src: &teeSource{tee: t, i: i}, lex: l}
+	}
+	return rs
+}
+
+// tee buffers the tokens of a lexer for several readers.
+type tee struct {
+	mu    sync.Mutex
+	fetch sync.Mutex // held while reading a token from the lexer
+	lex   *Lexer
+	buf   []Token // tokens not yet read by all readers
+	off   int     // index in the token stream of buf[0]
+	pos   []int   // index in the token stream of the next token per reader
+}
+
+type teeSource struct {
+	tee *tee
+	i   int
+}
+
+func (s *teeSource) NextToken() Token {
+	t := s.tee
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.pos[s.i]-t.off == len(t.buf) {
+		// Read the next token without holding mu, so that the other
+		// readers can read the buffered tokens meanwhile. Another
+		// reader may have read it before fetch was acquired.
+		t.mu.Unlock()
+		t.fetch.Lock()
+		t.mu.Lock()
+		if t.pos[s.i]-t.off == len(t.buf) {
+			t.mu.Unlock()
+			tok := t.lex.NextToken()
+			t.mu.Lock()
+			t.buf = append(t.buf, tok)
+		}
+		t.fetch.Unlock()
+	}
+	tok := t.buf[t.pos[s.i]-t.off]
+	t.pos[s.i]++
+	t.trim()
+	return tok
+}
+
+// trim drops the tokens that all readers have read.
+func (t *tee) trim() {
+	min := t.pos[0]
+	for _, p := range t.pos[1:] {
+		if p < min {
+			min = p
+		}
+	}
+	if min > t.off {
+		t.buf = t.buf[min-t.off:]
+		t.off = min
+	}
+}
@@ -0,0 +1,93 @@
+// Copyright (c) 2015, Ben Morgan. All rights reserved.
+// Use of this source code is governed by an MIT license
+// that can be found in the LICENSE file.
+
+package lex_test
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/goulash/lex"
+)
+
+func TestTee(t *testing.T) {
+	input := strings.Repeat("ab cd\n", 200)
+	want := strings.Join(values(lex.Collect(lex.Lex("f", input, lexWords))), "|")
+
+	rs := lex.Tee(lex.Lex("f", input, lexWords), 3)
+	got := make([]string, len(rs))
+	var wg sync.WaitGroup
+	for i, r := range rs[:2] {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var vs []string
+			for {
+				tok := r.Next()
+				_, line, col := r.PosOf(tok)
+				vs = append(vs, tok.Value)
+				if want := fmt.Sprint(tok.Pos/6+1, ":", tok.Pos%6+1); fmt.Sprint(line, ":", col) != want && tok.Type != lex.TypeEOF {
+					t.Errorf("reader %d: got %v at %d:%d, want %s", i, tok, line, col, want)
+				}
+				if tok.Type == lex.TypeEOF {
+					break
+				}
+			}
+			got[i] = strings.Join(vs, "|")
+		}()
+	}
+	wg.Wait()
+	// The third reader starts after the others have read everything.
+	var vs []string
+	for tok := range rs[2].All() {
+		vs = append(vs, tok.Value)
+	}
+	got[2] = strings.Join(vs, "|")
+	for i, g := range got {
+		if g != want {
+			t.Errorf("reader %d: got %d bytes of values, want %d", i, len(g), len(want))
+		}
+	}
+}
+
+func TestTeeBuffered(t *testing.T) {
+	// A reader waiting for the lexer does not block the other readers
+	// from reading the tokens already buffered.
+	release := make(chan struct{})
+	rs := lex.Tee(lex.Lex("f", "abc", func(l *lex.Lexer) lex.StateFn {
+		l.Inc(1)
+		l.Emit(typeWord)
+		l.Inc(1)
+		l.Emit(typeWord)
+		<-release
+		l.Inc(1)
+		l.Emit(typeWord)
+		return nil
+	}), 2)
+	rs[0].Next()
+	rs[0].Next()
+	waiting := make(chan lex.Token)
+	go func() { waiting <- rs[0].Next() }()
+	time.Sleep(10 * time.Millisecond) // let the first reader wait for c
+	done := make(chan string)
+	go func() { done <- rs[1].Next().Value + rs[1].Next().Value }()
+	select {
+	case got := <-done:
+		if got != "ab" {
+			t.Errorf("got %q, want ab", got)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("reader blocked by another reader waiting for the lexer")
+	}
+	close(release)
+	if tok := <-waiting; tok.Value != "c" {
+		t.Errorf("got %v, want c", tok)
+	}
+	if tok := rs[1].Next(); tok.Value != "c" {
+		t.Errorf("got %v, want c", tok)
+	}
+}
@@ -0,0 +1,61 @@
+// Copyright (c) 2015, Ben Morgan. All rights reserved.
+// Use of this source code is governed by an MIT license
+// that can be found in the LICENSE file.
+
+package lex
+
+import "sort"
+
+// A TokenIndex finds tokens by their offset in the input, such as the
+// token under the cursor in an editor. Queries take logarithmic time.
+type TokenIndex struct {
+	tokens []Token
+	maxEnd []int // greatest End of tokens[:i+1]
+}
+
+// NewTokenIndex returns a new TokenIndex for toks, such as the Tokens of
+// a Recording. The index keeps its own copy of toks, sorted by position.
+func NewTokenIndex(toks []Token) *TokenIndex {
+	x := &TokenIndex{tokens: append([]Token(nil), toks...)}
+	sort.SliceStable(x.tokens, func(i, j int) bool { return x.tokens[i].Pos < x.tokens[j].Pos })
+	x.maxEnd = make([]int, len(x.tokens))
+	end := 0
+	for i, t := range x.tokens {
+		if t.End > end {
+			end = t.End
+		}
+		x.maxEnd[i] = end
+	}
+	return x
+}
+
+// Tokens returns all tokens of the index, sorted by position.
+func (x *TokenIndex) Tokens() []Token { return x.tokens }
+
+// AtOffset returns the token containing the offset pos. If tokens
+// overlap, the one starting last is returned. If no token contains pos,
+// such as in whitespace that was ignored, false is returned.
+func (x *TokenIndex) AtOffset(pos int) (Token, bool) {
+	i := sort.Search(len(x.tokens), func(i int) bool { return x.tokens[i].Pos > pos })
+	for i--; i >= 0 && x.maxEnd[i] > pos; i-- {
+		if x.tokens[i].End > pos {
+			return x.tokens[i], true
+		}
+	}
+	return Token{}, false
+}
+
+// Range returns the tokens overlapping the offsets from start up to end,
+// in order. Tokens without extent, such as TypeEOF, are included if
+// they are positioned in the range.
+func (x *TokenIndex) Range(start, end int) []Token {
+	lo := sort.Search(len(x.tokens), func(i int) bool { return x.maxEnd[i] >= start })
+	hi := sort.Search(len(x.tokens), func(i int) bool { return x.tokens[i].Pos >= end })
+	var toks []Token
+	for _, t := range x.tokens[lo:max(lo, hi)] {
+		if t.End > start || (t.Pos == t.End && t.Pos >= start) {
+			toks = append(toks, t)
+		}
+	}
+	return toks
+}
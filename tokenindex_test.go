@@ -0,0 +1,83 @@
+// Copyright (c) 2015, Ben Morgan. All rights reserved.
+// Use of this source code is governed by an MIT license
+// that can be found in the LICENSE file.
+
+package lex_test
+
+import (
+	"math/rand"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/goulash/lex"
+)
+
+func TestTokenIndex(t *testing.T) {
+	const input = "ab  cd!"
+	toks := lex.Collect(lex.Lex("f", input, lexWords))
+	x := lex.NewTokenIndex(toks)
+	for pos, want := range []string{"ab", "ab", "  ", "  ", "cd", "cd", "!"} {
+		if tok, ok := x.AtOffset(pos); !ok || tok.Value != want {
+			t.Errorf("AtOffset(%d) = %v, %t; want %q", pos, tok, ok, want)
+		}
+	}
+	for _, pos := range []int{-1, len(input), 100} {
+		if tok, ok := x.AtOffset(pos); ok {
+			t.Errorf("AtOffset(%d) = %v, want no token", pos, tok)
+		}
+	}
+	for _, tt := range []struct {
+		start, end int
+		want       string
+	}{
+		{0, 2, "ab"},
+		{1, 5, "ab|  |cd"},
+		{2, 2, ""},
+		{6, 8, "!|"},
+		{7, 8, ""},
+		{-5, 0, ""},
+	} {
+		if got := strings.Join(values(x.Range(tt.start, tt.end)), "|"); got != tt.want {
+			t.Errorf("Range(%d, %d) = %q, want %q", tt.start, tt.end, got, tt.want)
+		}
+	}
+}
+
+// TestTokenIndexOverlapping compares the queries of an index of random
+// overlapping tokens with a linear search.
+func TestTokenIndexOverlapping(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	for n := 0; n < 200; n++ {
+		toks := make([]lex.Token, rnd.Intn(10))
+		for i := range toks {
+			pos := rnd.Intn(20)
+			toks[i] = lex.Token{Type: lex.Type(i), Pos: pos, End: pos + rnd.Intn(6)}
+		}
+		x := lex.NewTokenIndex(toks)
+		sorted := x.Tokens()
+		for pos := -1; pos < 27; pos++ {
+			var want lex.Token
+			found := false
+			for _, tok := range sorted {
+				if tok.Pos <= pos && pos < tok.End {
+					want, found = tok, true
+				}
+			}
+			if got, ok := x.AtOffset(pos); ok != found || got != want {
+				t.Fatalf("%v: AtOffset(%d) = %v, %t; want %v, %t", toks, pos, got, ok, want, found)
+			}
+			for end := pos; end < pos+8; end++ {
+				var want []lex.Token
+				for _, tok := range sorted {
+					if tok.Pos < end && (tok.End > pos || tok.Pos == tok.End && tok.Pos >= pos) {
+						want = append(want, tok)
+					}
+				}
+				if got := x.Range(pos, end); !reflect.DeepEqual(got, want) {
+					t.Fatalf("%v: Range(%d, %d) = %v, want %v", toks, pos, end, got, want)
+				}
+			}
+		}
+	}
+}
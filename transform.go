@@ -0,0 +1,65 @@
+// Copyright (c) 2015, Ben Morgan. All rights reserved.
+// Use of this source code is governed by an MIT license
+// that can be found in the LICENSE file.
+
+package lex
+
+// mapSource is a TokenSource that reads the tokens of a Reader and
+// passes them through fn.
+type mapSource struct {
+	r  *Reader
+	fn func(Token) Token
+}
+
+func (s mapSource) NextToken() Token { return s.fn(s.r.Next()) }
+
+// mapReader returns a Reader that reads the tokens of r passed through fn.
+func mapReader(r *Reader, fn func(Token) Token) *Reader {
+	return &Reader{src: mapSource{r: r, fn: fn}, lex: r.lex}
+}
+
+// RemapTypes returns a Reader that reads the tokens of r with their types
+// translated by mapping, so that a lexer embedded in another one, such as
+// a generic expression lexer, can emit the types of the host grammar:
+//
+//	host := lex.RemapTypes(lex.NewReader(sub), map[lex.Type]lex.Type{
+//	    exprlex.TypeIdent:  TypeIdent,
+//	    exprlex.TypeNumber: TypeNumber,
+//	})
+//
+// Types not in mapping are not changed, and TypeEOF and TypeError should
+// not be remapped. Tokens must only be read from the returned Reader,
+// and mapping must not be modified while it is used.
+func RemapTypes(r *Reader, mapping map[Type]Type) *Reader {
+	return mapReader(r, func(t Token) Token {
+		if typ, ok := mapping[t.Type]; ok {
+			t.Type = typ
+		}
+		return t
+	})
+}
+
+// PromoteKeywords returns a Reader that reads the tokens of r, with the
+// tokens of type identType whose value is one of keywords changed to the
+// type of the keyword. This keeps the lexer simple while giving the parser
+// precise types:
+//
+//	r = lex.PromoteKeywords(r, TypeIdent, map[string]lex.Type{
+//	    "if":   TypeIf,
+//	    "else": TypeElse,
+//	})
+//
+// Keywords are matched exactly; for case-insensitive keywords, see
+// Lexer.EmitKeyword. Tokens must only be read from the returned Reader,
+// and keywords must not be modified while it is used.
+func PromoteKeywords(r *Reader, identType Type, keywords map[string]Type) *Reader {
+	return mapReader(r, func(t Token) Token {
+		if t.Type != identType {
+			return t
+		}
+		if typ, ok := keywords[t.Value]; ok {
+			t.Type = typ
+		}
+		return t
+	})
+}
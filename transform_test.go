@@ -0,0 +1,75 @@
+// Copyright (c) 2015, Ben Morgan. All rights reserved.
+// Use of this source code is governed by an MIT license
+// that can be found in the LICENSE file.
+
+package lex_test
+
+import (
+	"testing"
+
+	"github.com/goulash/lex"
+)
+
+// describeReader returns the tokens read from r up to the end of the
+// input, like describeTypes.
+func describeReader(r *lex.Reader) string {
+	var toks []lex.Token
+	for {
+		t := r.Next()
+		toks = append(toks, t)
+		if t.Type == lex.TypeEOF || t.Type == lex.TypeError {
+			return describeTypes(toks)
+		}
+	}
+}
+
+func TestRemapTypes(t *testing.T) {
+	const typeHostWord, typeHostSpace lex.Type = 20, 21
+	mapping := map[lex.Type]lex.Type{typeWord: typeHostWord, typeSpace: typeHostSpace}
+	r := lex.RemapTypes(lex.NewReader(lex.Lex("f", "ab cd!", lexWords)), mapping)
+	if tok := r.Peek(); tok.Type != typeHostWord {
+		t.Errorf("Peek = %d:%q, want type %d", tok.Type, tok.Value, typeHostWord)
+	}
+	want := `20:"ab" 21:" " 20:"cd" 4:"!" 1:""`
+	if got := describeReader(r); got != want {
+		t.Errorf("tokens = %s, want %s", got, want)
+	}
+	if _, line, col := r.PosInfo(); line != 1 || col != 7 {
+		t.Errorf("PosInfo = %d:%d, want 1:7", line, col)
+	}
+	if tok := r.Next(); tok.Type != lex.TypeError || tok.Value != "" {
+		t.Errorf("Next after EOF = %v, want the zero token", tok)
+	}
+}
+
+func TestRemapTypesBackup(t *testing.T) {
+	r := lex.RemapTypes(lex.NewReader(lex.Lex("f", "ab cd", lexWords)), map[lex.Type]lex.Type{typeWord: 20})
+	tok := r.Next()
+	r.Backup(tok)
+	if toks, ok := r.Expect(20, typeSpace, 20); !ok || toks[2].Value != "cd" {
+		t.Errorf("Expect = %v, %t", toks, ok)
+	}
+}
+
+func TestPromoteKeywords(t *testing.T) {
+	const typeIf, typeElse lex.Type = 20, 21
+	keywords := map[string]lex.Type{"if": typeIf, "else": typeElse}
+	r := lex.PromoteKeywords(lex.NewReader(lex.Lex("f", "if x else If iff", lexWords)), typeWord, keywords)
+	want := `20:"if" 3:" " 2:"x" 3:" " 21:"else" 3:" " 2:"If" 3:" " 2:"iff" 1:""`
+	if got := describeReader(r); got != want {
+		t.Errorf("tokens = %s, want %s", got, want)
+	}
+}
+
+func TestPromoteKeywordsIdentType(t *testing.T) {
+	// Only tokens of the identifier type are promoted, so that strings
+	// and comments that spell a keyword keep their type.
+	r := lex.PromoteKeywords(lex.NewReader(lex.Lex("f", "if", lexWords)), typeOther, map[string]lex.Type{"if": 20})
+	if got, want := describeReader(r), `2:"if" 1:""`; got != want {
+		t.Errorf("tokens = %s, want %s", got, want)
+	}
+	r = lex.PromoteKeywords(lex.RemapTypes(lex.NewReader(lex.Lex("f", "if", lexWords)), map[lex.Type]lex.Type{typeWord: typeOther}), typeOther, map[string]lex.Type{"if": 20})
+	if got, want := describeReader(r), `20:"if" 1:""`; got != want {
+		t.Errorf("remapped tokens = %s, want %s", got, want)
+	}
+}
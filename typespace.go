@@ -0,0 +1,74 @@
+// Copyright (c) 2015, Ben Morgan. All rights reserved.
+// Use of this source code is governed by an MIT license
+// that can be found in the LICENSE file.
+
+package lex
+
+import "sync"
+
+// firstSpaceType is the first type allocated by a TypeSpace, far above
+// the types that are defined as constants continuing after TypeEOF.
+const firstSpaceType Type = 1 << 20
+
+var nextSpaceType = struct {
+	sync.Mutex
+	t Type
+}{t: firstSpaceType}
+
+// A TypeSpace allocates types that are distinct from the types of all
+// other TypeSpaces, so that lexers composed from independent parts, such
+// as a sub-lexer for embedded code, cannot reuse each other's types by
+// accident:
+//
+//	var types = lex.NewTypeSpace()
+//
+//	var (
+//	    TypeIdent  = types.Next("Ident")
+//	    TypeNumber = types.Next("Number")
+//	)
+//
+// Allocated types are also distinct from types defined as constants
+// continuing after TypeEOF. A TypeSpace is safe for concurrent use.
+type TypeSpace struct {
+	mu    sync.Mutex
+	types []Type
+}
+
+// NewTypeSpace returns a new TypeSpace.
+func NewTypeSpace() *TypeSpace {
+	return new(TypeSpace)
+}
+
+// Next allocates a new type and registers name as its name,
+// see RegisterTypeName.
+func (ts *TypeSpace) Next(name string) Type {
+	nextSpaceType.Lock()
+	t := nextSpaceType.t
+	nextSpaceType.t++
+	nextSpaceType.Unlock()
+
+	RegisterTypeName(t, name)
+	ts.mu.Lock()
+	ts.types = append(ts.types, t)
+	ts.mu.Unlock()
+	return t
+}
+
+// Types returns the types allocated by ts, in order.
+func (ts *TypeSpace) Types() []Type {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	return append([]Type(nil), ts.types...)
+}
+
+// Contains reports whether t was allocated by ts.
+func (ts *TypeSpace) Contains(t Type) bool {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	for _, u := range ts.types {
+		if u == t {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,46 @@
+// Copyright (c) 2015, Ben Morgan. All rights reserved.
+// Use of this source code is governed by an MIT license
+// that can be found in the LICENSE file.
+
+package lex_test
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/goulash/lex"
+)
+
+func TestTypeSpace(t *testing.T) {
+	a, b := lex.NewTypeSpace(), lex.NewTypeSpace()
+	ident := a.Next("Ident")
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+		go func() { defer wg.Done(); a.Next("A") }()
+		go func() { defer wg.Done(); b.Next("B") }()
+	}
+	wg.Wait()
+
+	if ident.String() != "Ident" || ident <= typeWarning {
+		t.Errorf("Next allocated %d named %s", ident, ident)
+	}
+	seen := make(map[lex.Type]bool)
+	for _, ts := range []*lex.TypeSpace{a, b} {
+		for _, typ := range ts.Types() {
+			if seen[typ] {
+				t.Errorf("type %d allocated twice", typ)
+			}
+			seen[typ] = true
+			if !ts.Contains(typ) {
+				t.Errorf("type %d not contained by its type space", typ)
+			}
+		}
+	}
+	if len(seen) != 9 || a.Types()[0] != ident {
+		t.Errorf("got types %v and %v", a.Types(), b.Types())
+	}
+	if b.Contains(ident) || a.Contains(typeWord) {
+		t.Error("type space contains a type it did not allocate")
+	}
+}
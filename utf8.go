@@ -0,0 +1,61 @@
+// Copyright (c) 2015, Ben Morgan. All rights reserved.
+// Use of this source code is governed by an MIT license
+// that can be found in the LICENSE file.
+
+package lex
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// An InvalidUTF8Policy determines how a lexer handles input that is not
+// valid UTF-8, see WithInvalidUTF8Policy.
+type InvalidUTF8Policy int
+
+const (
+	// InvalidUTF8Raw passes invalid bytes through: Next returns
+	// utf8.RuneError for each of them, and token values contain them.
+	// This is the default.
+	InvalidUTF8Raw InvalidUTF8Policy = iota
+
+	// InvalidUTF8Replace makes Next return utf8.RuneError for each
+	// invalid byte, and replaces each of them with U+FFFD in token
+	// values. The raw text of the tokens is not changed.
+	InvalidUTF8Replace
+
+	// InvalidUTF8Error makes Next stop lexing at the first invalid byte
+	// with a TypeError token at its offset, and return EOF.
+	InvalidUTF8Error
+)
+
+// WithInvalidUTF8Policy sets how the lexer handles input that is not
+// valid UTF-8, which by default is passed through, see InvalidUTF8Raw.
+// Rejecting it lets lexers for languages that require UTF-8 report the
+// offending byte without checking every rune themselves:
+//
+//	l := lex.Lex(name, input, lexText, lex.WithInvalidUTF8Policy(lex.InvalidUTF8Error))
+func WithInvalidUTF8Policy(p InvalidUTF8Policy) Option {
+	return func(l *Lexer) { l.utf8Policy = p }
+}
+
+// invalidUTF8 stops lexing with an error for the invalid byte at pos.
+func (l *Lexer) invalidUTF8(pos int) {
+	l.emit(Token{
+		Type:  TypeError,
+		Pos:   pos,
+		End:   pos + 1,
+		Value: fmt.Sprintf("invalid UTF-8 byte %#02x at offset %d", l.input[pos], pos),
+		pb:    l.pb,
+	})
+	l.stop()
+}
+
+// replaceInvalidUTF8 returns s with each invalid byte replaced by U+FFFD.
+func replaceInvalidUTF8(s string) string {
+	if utf8.ValidString(s) {
+		return s
+	}
+	return strings.Map(func(r rune) rune { return r }, s)
+}
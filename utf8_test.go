@@ -0,0 +1,54 @@
+// Copyright (c) 2015, Ben Morgan. All rights reserved.
+// Use of this source code is governed by an MIT license
+// that can be found in the LICENSE file.
+
+package lex_test
+
+import (
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/goulash/lex"
+)
+
+func TestWithInvalidUTF8Policy(t *testing.T) {
+	const input = "a\xffb\xe2\x82 é"
+	var runes []rune
+	collect := func(l *lex.Lexer) lex.StateFn {
+		for r := l.Next(); r >= 0; r = l.Next() {
+			runes = append(runes, r)
+		}
+		l.Emit(typeWord)
+		return nil
+	}
+	tests := []struct {
+		policy lex.InvalidUTF8Policy
+		runes  string
+		want   string
+	}{
+		{lex.InvalidUTF8Raw, "a�b�� é", `2:"a\xffb\xe2\x82 é" 1:""`},
+		{lex.InvalidUTF8Replace, "a�b�� é", `2:"a�b�� é" 1:""`},
+		{lex.InvalidUTF8Error, "a", `0:"invalid UTF-8 byte 0xff at offset 1"`},
+	}
+	for _, tt := range tests {
+		runes = nil
+		toks := lex.Collect(lex.Lex("f", input, collect, lex.WithInvalidUTF8Policy(tt.policy)))
+		if got := describeTypes(toks); string(runes) != tt.runes || got != tt.want {
+			t.Errorf("policy %d: got runes %q and %s, want %q and %s", tt.policy, string(runes), got, tt.runes, tt.want)
+		}
+		if tt.policy == lex.InvalidUTF8Replace && toks[0].Raw != input {
+			t.Errorf("got raw %q, want the input", toks[0].Raw)
+		}
+	}
+
+	l := lex.NewStream("f", iotest.OneByteReader(strings.NewReader("é\n\xe2\x82")), lex.WithInvalidUTF8Policy(lex.InvalidUTF8Error))
+	go l.Run(lexWords)
+	toks := lex.Collect(l)
+	if got := describeTypes(toks); got != `2:"é" 4:"\n" 0:"invalid UTF-8 byte 0xe2 at offset 3"` {
+		t.Errorf("got %s from a stream", got)
+	}
+	if err := l.Err(); err == nil || err.Error() != "f:2:1: invalid UTF-8 byte 0xe2 at offset 3" {
+		t.Errorf("got error %v", err)
+	}
+}
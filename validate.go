@@ -0,0 +1,30 @@
+// Copyright (c) 2015, Ben Morgan. All rights reserved.
+// Use of this source code is governed by an MIT license
+// that can be found in the LICENSE file.
+
+package lex
+
+import "fmt"
+
+// Validate makes the lexer check its invariants on every operation that
+// moves the position, and panic with a description of the operation that
+// violated them. The start of the pending token may not be after the
+// current position, which may not be beyond the end of the input.
+//
+// This is meant for debugging state functions. Validation is always
+// enabled when built with the lexdebug build tag.
+func Validate() Option {
+	return func(l *Lexer) { l.validate = true }
+}
+
+// check panics if validation is enabled and the invariants of the
+// lexer are violated by the operation op.
+func (l *Lexer) check(op string) {
+	if !l.validate && !debug {
+		return
+	}
+	if l.base < 0 || l.base > l.pos || l.pos > len(l.input) {
+		panic(fmt.Sprintf("lex: %s violated 0 <= base (%d) <= pos (%d) <= len(input) (%d)",
+			op, l.base, l.pos, len(l.input)))
+	}
+}
@@ -0,0 +1,54 @@
+// Copyright (c) 2015, Ben Morgan. All rights reserved.
+// Use of this source code is governed by an MIT license
+// that can be found in the LICENSE file.
+
+package lex_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/goulash/lex"
+)
+
+func TestBackupGuard(t *testing.T) {
+	backupAfterEmit := func(l *lex.Lexer) lex.StateFn {
+		l.Next()
+		l.Emit(typeWord)
+		l.Backup()
+		l.AcceptRun("ab")
+		l.Emit(typeWord)
+		return nil
+	}
+	toks := lex.Collect(lex.Lex("a", "ab", backupAfterEmit))
+	if got := strings.Join(values(toks), "|"); got != "a|b|" {
+		t.Errorf("got %q, want Backup after Emit to have no effect", got)
+	}
+	toks = lex.Collect(lex.Lex("a", "ab", backupAfterEmit, lex.Validate()))
+	want := "violated 0 <= base (1) <= pos (0) <= len(input) (2)"
+	if last := toks[len(toks)-1]; last.Type != lex.TypeError || !strings.Contains(last.Value, "Backup "+want) {
+		t.Errorf("got %v, want Backup to panic with Validate", toks)
+	}
+	toks = lex.Collect(lex.Lex("a", "ab", func(l *lex.Lexer) lex.StateFn {
+		l.Inc(3)
+		return nil
+	}, lex.Validate()))
+	if last := toks[len(toks)-1]; last.Type != lex.TypeError || !strings.Contains(last.Value, "Inc violated") {
+		t.Errorf("got %v, want Inc to panic with Validate", toks)
+	}
+}
+
+func TestEmitMappedGuard(t *testing.T) {
+	toks := lex.Collect(lex.Lex("a", "ab", func(l *lex.Lexer) lex.StateFn {
+		l.Next()
+		func() {
+			defer func() { recover() }()
+			l.Dec(2)
+		}()
+		l.EmitMapped(typeWord, strings.ToUpper)
+		return nil
+	}, lex.Validate()))
+	if last := toks[len(toks)-1]; last.Type != lex.TypeError || !strings.Contains(last.Value, "EmitMapped violated") {
+		t.Errorf("got %v, want EmitMapped to panic with Validate", toks)
+	}
+}
@@ -0,0 +1,61 @@
+// Copyright (c) 2015, Ben Morgan. All rights reserved.
+// Use of this source code is governed by an MIT license
+// that can be found in the LICENSE file.
+
+package lex
+
+import "fmt"
+
+// Verify checks that toks, in the order they were emitted, cover input
+// exactly: every token starts where the previous one ended, the raw text
+// of every token is the input it spans, and the last token ends at the
+// end of the input. This is a strong invariant for lexers that emit all
+// of their input, including whitespace and comments, and is useful in
+// tests and fuzzing:
+//
+//	toks := lex.Collect(lex.Lex("fuzz", input, sf))
+//	if err := lex.Verify(input, toks); err != nil {
+//	    t.Fatal(err)
+//	}
+//
+// Input that the state functions ignore is part of the invariant as
+// well. To record it, run the lexer with WithEmitWhitespace, which emits
+// the ignored spans as tokens of their own:
+//
+//	l := lex.Lex("fuzz", input, sf, lex.WithEmitWhitespace(TypeSpace, TypeSpace))
+//	if err := lex.Verify(input, lex.Collect(l)); err != nil {
+//	    t.Fatal(err)
+//	}
+//
+// Checking stops at a TypeError token, since the lexer stops there.
+// Tokens skipped with WithSkip, or from input pushed with PushInput,
+// do not satisfy the invariant.
+func Verify(input string, toks []Token) error {
+	x := NewLineIndex(input)
+	errorf := func(pos int, format string, args ...interface{}) error {
+		line, col := x.Resolve(pos)
+		return fmt.Errorf("lex: %d:%d: %s", line, col, fmt.Sprintf(format, args...))
+	}
+	end := 0
+	for _, t := range toks {
+		switch {
+		case t.Pos > end:
+			return errorf(end, "input %q not covered before %v", input[end:min(t.Pos, len(input))], t)
+		case t.Pos < end:
+			return errorf(t.Pos, "%v overlaps previous token", t)
+		case t.End < t.Pos || t.End > len(input):
+			return errorf(t.Pos, "%v has invalid end offset %d", t, t.End)
+		}
+		if t.Type == TypeError {
+			return nil
+		}
+		if t.Raw != input[t.Pos:t.End] {
+			return errorf(t.Pos, "%v has raw text %q, but spans %q", t, t.Raw, input[t.Pos:t.End])
+		}
+		end = t.End
+	}
+	if end < len(input) {
+		return errorf(end, "input %q not covered at end", input[end:])
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+// Copyright (c) 2015, Ben Morgan. All rights reserved.
+// Use of this source code is governed by an MIT license
+// that can be found in the LICENSE file.
+
+package lex_test
+
+import (
+	"strings"
+	"testing"
+	"unicode"
+
+	"github.com/goulash/lex"
+)
+
+// lexIgnoreSpaces emits runs of letters and ignores everything else.
+func lexIgnoreSpaces(l *lex.Lexer) lex.StateFn {
+	for {
+		switch r := l.Peek(); {
+		case r < 0:
+			l.Emit(lex.TypeEOF)
+			return nil
+		case unicode.IsLetter(r):
+			l.AcceptFuncRun(unicode.IsLetter)
+			l.Emit(typeWord)
+		default:
+			l.Next()
+			l.Ignore()
+		}
+	}
+}
+
+func TestVerify(t *testing.T) {
+	const input = "one two\n\tthree  "
+	toks := lex.Collect(lex.Lex("f", input, lexWords))
+	if err := lex.Verify(input, toks); err != nil {
+		t.Errorf("lexWords: %v", err)
+	}
+
+	toks = lex.Collect(lex.Lex("f", input, lexIgnoreSpaces, lex.WithEmitWhitespace(typeSpace, typeSpace)))
+	if err := lex.Verify(input, toks); err != nil {
+		t.Errorf("ignored spans: %v", err)
+	}
+
+	toks = lex.Collect(lex.Lex("f", input, lexIgnoreSpaces))
+	if err := lex.Verify(input, toks); err == nil || !strings.Contains(err.Error(), "not covered") {
+		t.Errorf("unrecorded ignored spans: got %v", err)
+	}
+}
+
+func TestVerifyOverlap(t *testing.T) {
+	const input = "one two"
+	l := lex.Lex("f", input, func(l *lex.Lexer) lex.StateFn {
+		l.AcceptFuncRun(unicode.IsLetter)
+		l.Emit(typeWord)
+		l.EmitAt(typeOther, 1, "n")
+		return lexWords
+	})
+	err := lex.Verify(input, lex.Collect(l))
+	if err == nil || !strings.Contains(err.Error(), "overlaps") {
+		t.Errorf("got %v, want an overlap", err)
+	}
+}
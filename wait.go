@@ -0,0 +1,52 @@
+// Copyright (c) 2015, Ben Morgan. All rights reserved.
+// Use of this source code is governed by an MIT license
+// that can be found in the LICENSE file.
+
+package lex
+
+import (
+	"context"
+	"errors"
+)
+
+// LexAndWait lexes input with sf synchronously in the calling goroutine,
+// and returns all tokens emitted. No channel or goroutine is involved,
+// for users who do not need lexing and parsing to run concurrently.
+//
+// If lexing is stopped because ctx is canceled, the context error is
+// returned along with the tokens emitted so far. Otherwise, the first
+// error emitted by the lexer is returned, see Lexer.Err.
+func LexAndWait(ctx context.Context, name, input string, sf StateFn, opts ...Option) ([]Token, error) {
+	l := New(name, input, opts...)
+	l.ctx = ctx
+	var toks []Token
+	l.RunSink(sf, func(t Token) bool {
+		toks = append(toks, t)
+		return true
+	})
+	err := l.Err()
+	var e *Error
+	if errors.As(err, &e) && e.Err != nil && e.Err == ctx.Err() {
+		return toks, e.Err
+	}
+	return toks, err
+}
+
+// RunSink is like Run, but delivers the tokens by calling sink in the
+// calling goroutine instead of sending them on the channel, which avoids
+// the overhead of the channel when each token needs little work, such as
+// when counting or highlighting tokens:
+//
+//	n := 0
+//	l.RunSink(sf, func(t lex.Token) bool {
+//	    n++
+//	    return true
+//	})
+//
+// If sink returns false, lexing stops without emitting further tokens.
+// NextToken must not be used with RunSink.
+func (l *Lexer) RunSink(fn StateFn, sink func(Token) bool) {
+	l.sink = sink
+	defer func() { l.sink = nil }()
+	l.Run(fn)
+}
@@ -0,0 +1,98 @@
+// Copyright (c) 2015, Ben Morgan. All rights reserved.
+// Use of this source code is governed by an MIT license
+// that can be found in the LICENSE file.
+
+package lex_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/goulash/lex"
+)
+
+func TestLexAndWait(t *testing.T) {
+	toks, err := lex.LexAndWait(context.Background(), "a", "ab c", lexWords)
+	if got := strings.Join(values(toks), "|"); err != nil || got != "ab| |c|" {
+		t.Errorf("got %q, %v", got, err)
+	}
+
+	toks, err = lex.LexAndWait(context.Background(), "a", "ab", func(l *lex.Lexer) lex.StateFn {
+		return l.Errorf("bad")
+	})
+	if err == nil || err.Error() != "a:1:1: bad" || len(toks) != 1 {
+		t.Errorf("got %v, %v; want the error", toks, err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	toks, err = lex.LexAndWait(ctx, "a", "ab", lexWords)
+	if !errors.Is(err, context.Canceled) || len(toks) != 1 || toks[0].Type != lex.TypeError {
+		t.Errorf("got %v, %v; want the lexer canceled", toks, err)
+	}
+
+	ctx, cancel = context.WithCancel(context.Background())
+	defer cancel()
+	var spin lex.StateFn
+	spin = func(l *lex.Lexer) lex.StateFn {
+		cancel()
+		return spin
+	}
+	_, err = lex.LexAndWait(ctx, "a", "ab", spin)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("got %v, want the endless lexer canceled", err)
+	}
+
+	// A context canceled after the last state function does not
+	// affect the result.
+	ctx, cancel = context.WithCancel(context.Background())
+	toks, err = lex.LexAndWait(ctx, "a", "ab c", func(l *lex.Lexer) lex.StateFn {
+		lexWords(l)
+		cancel()
+		return nil
+	})
+	if got := strings.Join(values(toks), "|"); err != nil || got != "ab| |c|" {
+		t.Errorf("got %q, %v; want all tokens and no error", got, err)
+	}
+}
+
+func TestRunSink(t *testing.T) {
+	var got []string
+	l := lex.New("a", "ab c d")
+	l.RunSink(lexWords, func(tok lex.Token) bool {
+		got = append(got, tok.Value)
+		return len(got) < 3
+	})
+	if s := strings.Join(got, "|"); s != "ab| |c" {
+		t.Errorf("got %q, want lexing to stop after c", s)
+	}
+
+	// Next returns EOF as soon as the sink stops lexing.
+	read := 0
+	l = lex.New("a", "abcdef")
+	l.RunSink(func(l *lex.Lexer) lex.StateFn {
+		for l.Next() >= 0 {
+			read++
+			l.Emit(typeOther)
+		}
+		return nil
+	}, func(tok lex.Token) bool { return false })
+	if read != 1 {
+		t.Errorf("read %d runes, want 1", read)
+	}
+
+	var toks []lex.Token
+	l = lex.New("a", "ab c", lex.WithMemoryBudget(1))
+	l.RunSink(lexWords, func(tok lex.Token) bool {
+		toks = append(toks, tok)
+		return true
+	})
+	if s := strings.Join(values(toks), "|"); s != "ab| |c|" || toks[3].Type != lex.TypeEOF {
+		t.Errorf("got %q, want all tokens up to EOF regardless of the memory budget", s)
+	}
+	if err := l.Err(); err != nil {
+		t.Errorf("got %v", err)
+	}
+}
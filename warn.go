@@ -0,0 +1,50 @@
+// Copyright (c) 2015, Ben Morgan. All rights reserved.
+// Use of this source code is governed by an MIT license
+// that can be found in the LICENSE file.
+
+package lex
+
+import "fmt"
+
+// WithWarnings makes the lexer emit the warnings of Lexer.Warnf as
+// tokens of type t. Without it, warnings are discarded, so clients that
+// are not interested in them do not need to skip them.
+func WithWarnings(t Type) Option {
+	return func(l *Lexer) {
+		l.warning = t
+		l.warn = true
+	}
+}
+
+// Warnf emits a warning, a diagnostic that does not stop lexing, such as
+// for deprecated or suspicious syntax, if enabled with WithWarnings:
+//
+//	if l.Consume("<>") {
+//	    l.Warnf("<> is deprecated, use !=")
+//	    l.Emit(TypeNotEqual)
+//	}
+//
+// The warning is positioned at the pending input, which is its Raw
+// value, and stays pending. Its Value is the formatted message. Options
+// that apply to all tokens, such as WithSkip and WithCopyValues, apply
+// to warnings as well.
+func (l *Lexer) Warnf(format string, args ...interface{}) {
+	if !l.warn || l.halted {
+		return
+	}
+	raw := l.input[l.base:l.pos]
+	l.emit(Token{
+		Type:  l.warning,
+		Pos:   l.base,
+		End:   l.pos,
+		Value: fmt.Sprintf(format, args...),
+		Raw:   raw,
+		pb:    l.pb,
+	})
+}
+
+// isWarning reports whether t is a warning emitted by Warnf, which does
+// not consume the input it is positioned at.
+func (l *Lexer) isWarning(t Token) bool {
+	return l.warn && t.Type == l.warning
+}
@@ -0,0 +1,67 @@
+// Copyright (c) 2015, Ben Morgan. All rights reserved.
+// Use of this source code is governed by an MIT license
+// that can be found in the LICENSE file.
+
+package lex_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"unicode"
+
+	"github.com/goulash/lex"
+)
+
+const typeWarning = typeOther + 1
+
+// lexWarnWords is like lexIgnoreSpaces, but warns about every word
+// before emitting it.
+func lexWarnWords(l *lex.Lexer) lex.StateFn {
+	for {
+		switch r := l.Peek(); {
+		case r < 0:
+			return nil
+		case unicode.IsLetter(r):
+			l.AcceptFuncRun(unicode.IsLetter)
+			l.Warnf("word %s", l.Value())
+			l.Emit(typeWord)
+		default:
+			l.Next()
+			l.Ignore()
+		}
+	}
+}
+
+// describeTypes returns the types and values of toks.
+func describeTypes(toks []lex.Token) string {
+	var s []string
+	for _, t := range toks {
+		s = append(s, fmt.Sprintf("%d:%q", t.Type, t.Value))
+	}
+	return strings.Join(s, " ")
+}
+
+func TestWarnf(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []lex.Option
+		want string
+	}{
+		{"warnings", nil,
+			`5:"word ab" 2:"ab" 5:"word cd" 2:"cd" 1:""`},
+		{"skip", []lex.Option{lex.WithSkip(typeWarning)},
+			`2:"ab" 2:"cd" 1:""`},
+		{"whitespace", []lex.Option{lex.WithEmitWhitespace(typeSpace, typeSpace)},
+			`3:" " 5:"word ab" 2:"ab" 3:" " 5:"word cd" 2:"cd" 3:" " 1:""`},
+		{"max length", []lex.Option{lex.WithMaxTokenLength(1)},
+			`0:"token exceeds maximum length of 1 bytes"`},
+	}
+	for _, tt := range tests {
+		opts := append([]lex.Option{lex.WithWarnings(typeWarning)}, tt.opts...)
+		toks := lex.Collect(lex.Lex("f", " ab cd ", lexWarnWords, opts...))
+		if got := describeTypes(toks); got != tt.want {
+			t.Errorf("%s:\ngot  %s\nwant %s", tt.name, got, tt.want)
+		}
+	}
+}
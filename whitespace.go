@@ -0,0 +1,44 @@
+// Copyright (c) 2015, Ben Morgan. All rights reserved.
+// Use of this source code is governed by an MIT license
+// that can be found in the LICENSE file.
+
+package lex
+
+// WithEmitWhitespace makes the lexer emit the input that state functions
+// ignore, so that the original layout can be reconstructed from the
+// tokens, such as by a formatter, without changing the state functions.
+// Before each token, the input ignored since the previous token is
+// emitted as tokens of type newline for runs of line endings, and of type
+// space for everything else:
+//
+//	l := lex.New(name, input, lex.WithEmitWhitespace(TypeSpace, TypeNewline))
+//
+// Note that everything ignored is emitted, including text such as
+// comments if the state functions ignore it.
+func WithEmitWhitespace(space, newline Type) Option {
+	return func(l *Lexer) {
+		l.wsEmit = true
+		l.wsSpace, l.wsNewline = space, newline
+	}
+}
+
+// emitGap emits the input from the end of the last token up to pos.
+func (l *Lexer) emitGap(pos int) {
+	for start := l.wsEnd; start < pos; {
+		t, end := l.wsSpace, start
+		if IsEndline(rune(l.input[start])) {
+			t = l.wsNewline
+			for end < pos && IsEndline(rune(l.input[end])) {
+				end++
+			}
+		} else {
+			for end < pos && !IsEndline(rune(l.input[end])) {
+				end++
+			}
+		}
+		l.wsEnd = end
+		s := l.input[start:end]
+		l.emit(Token{Type: t, Pos: start, End: end, Value: s, Raw: s, pb: l.pb})
+		start = end
+	}
+}
@@ -0,0 +1,34 @@
+// Copyright (c) 2015, Ben Morgan. All rights reserved.
+// Use of this source code is governed by an MIT license
+// that can be found in the LICENSE file.
+
+package lex_test
+
+import (
+	"testing"
+
+	"github.com/goulash/lex"
+)
+
+func TestWithEmitWhitespace(t *testing.T) {
+	const typeNewline = typeWarning + 1
+	tests := []struct {
+		input string
+		sf    lex.StateFn
+		opts  []lex.Option
+		want  string
+	}{
+		{"a  b\r\n\n c;\n", lexIgnoreSpaces, nil, `2:"a" 4:"  " 2:"b" 6:"\r\n\n" 4:" " 2:"c" 4:";" 6:"\n" 1:""`},
+		{"", lexIgnoreSpaces, nil, `1:""`},
+		{"  ", lexIgnoreSpaces, nil, `4:"  " 1:""`},
+		{"a b", lexIgnoreSpaces, []lex.Option{lex.WithSkip(typeWord)}, `4:" " 1:""`},
+		{"a\nb", lexWarnWords, []lex.Option{lex.WithWarnings(typeWarning)}, `5:"word a" 2:"a" 6:"\n" 5:"word b" 2:"b" 1:""`},
+	}
+	for _, tt := range tests {
+		opts := append([]lex.Option{lex.WithEmitWhitespace(typeOther, typeNewline)}, tt.opts...)
+		toks := lex.Collect(lex.Lex("f", tt.input, tt.sf, opts...))
+		if got := describeTypes(toks); got != tt.want {
+			t.Errorf("%q:\ngot  %s\nwant %s", tt.input, got, tt.want)
+		}
+	}
+}